@@ -0,0 +1,102 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// adaptiveSlowdown is the factor of the fastest fetch latency seen above which a fetch is
+// considered slowed down by the load.
+const adaptiveSlowdown = 2
+
+// adaptiveLimit adjusts the number of fetches in flight between the given bounds. It starts at the
+// lower bound and increases the limit by one every time as many fetches as the limit complete
+// fast, i.e. once per round, and halves it on every fetch that fails or slows down, so that the
+// throughput is maximized without overloading Core.
+type adaptiveLimit struct {
+	min, max int
+	clock    Clock
+
+	lk        sync.Mutex
+	limit     int
+	inFlight  int
+	successes int
+	// the fastest fetch latency seen, as the reference for the slowdowns
+	baseline time.Duration
+	// closed and replaced whenever a fetch completes
+	freed chan struct{}
+}
+
+func newAdaptiveLimit(min, max int, clock Clock) *adaptiveLimit {
+	return &adaptiveLimit{
+		min:   min,
+		max:   max,
+		clock: clock,
+		limit: min,
+		freed: make(chan struct{}),
+	}
+}
+
+// acquire waits until the fetches in flight are below the limit and takes a slot, returning the
+// func to report the result of the fetch with.
+func (l *adaptiveLimit) acquire(ctx context.Context) (func(*BlockResult), error) {
+	for {
+		l.lk.Lock()
+		if l.inFlight < l.limit {
+			l.inFlight++
+			l.lk.Unlock()
+			start := l.clock.Now()
+			return func(res *BlockResult) {
+				l.complete(l.clock.Now().Sub(start), res)
+			}, nil
+		}
+		freed := l.freed
+		l.lk.Unlock()
+
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// complete frees the slot of a fetch, adjusting the limit by its latency and outcome. The cached
+// blocks tell nothing about the load of Core, so they leave the limit as is.
+func (l *adaptiveLimit) complete(latency time.Duration, res *BlockResult) {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	l.inFlight--
+	close(l.freed)
+	l.freed = make(chan struct{})
+	if res.Cached {
+		return
+	}
+
+	failed := res.Err != nil
+	if !failed && (l.baseline == 0 || latency < l.baseline) {
+		l.baseline = latency
+	}
+	if failed || latency > l.baseline*adaptiveSlowdown {
+		l.limit /= 2
+		if l.limit < l.min {
+			l.limit = l.min
+		}
+		l.successes = 0
+		return
+	}
+
+	l.successes++
+	if l.successes >= l.limit && l.limit < l.max {
+		l.limit++
+		l.successes = 0
+	}
+}
+
+// current returns the current limit.
+func (l *adaptiveLimit) current() int {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	return l.limit
+}
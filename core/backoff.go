@@ -0,0 +1,57 @@
+package core
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Backoff defines how long to wait before the next attempt of an operation that keeps failing,
+// e.g. re-subscribing to Core events or retrying a failed request.
+type Backoff interface {
+	// Next returns the delay to wait before the given attempt. Attempts are counted from 1.
+	Next(attempt int) time.Duration
+	// Reset is called once the operation succeeds, so that stateful implementations
+	// can start over.
+	Reset()
+}
+
+// exponentialBackoff doubles the delay with every attempt, starting from min and capped at max.
+// Every delay is jittered to be within the [delay/2, delay] range, so that multiple clients
+// backing off at the same time do not retry in lockstep.
+type exponentialBackoff struct {
+	min, max time.Duration
+}
+
+// NewExponentialBackoff creates a new exponential Backoff with jitter bounded by the given
+// min and max delays.
+func NewExponentialBackoff(min, max time.Duration) Backoff {
+	return &exponentialBackoff{min: min, max: max}
+}
+
+// DefaultBackoff returns the Backoff used by Core components unless configured otherwise.
+func DefaultBackoff() Backoff {
+	return NewExponentialBackoff(time.Second, time.Second*30)
+}
+
+func (b *exponentialBackoff) Next(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	delay := b.min
+	for i := 1; i < attempt && delay < b.max; i++ {
+		delay *= 2
+	}
+	if delay > b.max {
+		delay = b.max
+	}
+
+	half := int64(delay / 2)
+	if half == 0 {
+		return delay
+	}
+	//nolint:gosec // G404: jitter does not need a secure random number generator
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+func (b *exponentialBackoff) Reset() {}
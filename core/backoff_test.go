@@ -0,0 +1,246 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestExponentialBackoff_Bounds(t *testing.T) {
+	minDelay, maxDelay := time.Millisecond*100, time.Second
+	b := NewExponentialBackoff(minDelay, maxDelay)
+
+	expected := minDelay
+	for attempt := 1; attempt <= 10; attempt++ {
+		delay := b.Next(attempt)
+		assert.GreaterOrEqual(t, delay, expected/2)
+		assert.LessOrEqual(t, delay, expected)
+
+		expected *= 2
+		if expected > maxDelay {
+			expected = maxDelay
+		}
+	}
+}
+
+func TestBackoff_Resubscribe(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	var subscriptions int
+	events := make(chan ctypes.ResultEvent, 1)
+	client := &mockClient{
+		subscribe: func(context.Context, string, string) (<-chan ctypes.ResultEvent, error) {
+			subscriptions++
+			switch subscriptions {
+			case 1:
+				// lose the very first subscription right away
+				lost := make(chan ctypes.ResultEvent)
+				close(lost)
+				return lost, nil
+			case 2:
+				return nil, errors.New("connection refused")
+			default:
+				return events, nil
+			}
+		},
+	}
+
+	backoff := &fixedBackoff{interval: time.Millisecond}
+	fetcher, err := NewBlockFetcher(client,
+		WithBackoff[FetcherParameters](backoff),
+		WithDataHashVerification(false),
+	)
+	require.NoError(t, err)
+
+	sub, err := fetcher.SubscribeNewBlockEvent(ctx)
+	require.NoError(t, err)
+
+	events <- ctypes.ResultEvent{Data: types.EventDataNewBlock{Block: &types.Block{}}}
+	select {
+	case <-sub:
+	case <-ctx.Done():
+		require.NoError(t, ctx.Err())
+	}
+
+	assert.Equal(t, 3, subscriptions)
+	assert.EqualValues(t, 2, backoff.calls.Load())
+	assert.EqualValues(t, 1, backoff.resets.Load())
+	require.NoError(t, fetcher.UnsubscribeNewBlockEvent(ctx))
+}
+
+func TestBackoff_RetryRequest(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		_, err := w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"result":{}}`))
+		require.NoError(t, err)
+	}))
+	t.Cleanup(srv.Close)
+
+	backoff := &fixedBackoff{interval: time.Millisecond}
+	client := newTestRemote(t, srv.URL, WithBackoff[ClientParameters](backoff))
+
+	_, err := client.Health(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, requests.Load())
+	assert.EqualValues(t, 1, backoff.calls.Load())
+}
+
+func TestBackoff_MaxRetries(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+
+	for _, tc := range []struct {
+		name       string
+		maxRetries int
+	}{
+		{name: "none", maxRetries: 0},
+		{name: "finite", maxRetries: 3},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+			t.Cleanup(cancel)
+
+			requests.Store(0)
+			backoff := &fixedBackoff{interval: time.Millisecond}
+			client := newTestRemote(t, srv.URL,
+				WithBackoff[ClientParameters](backoff),
+				WithMaxRetries(tc.maxRetries),
+			)
+
+			_, err := client.Health(ctx)
+			require.Error(t, err)
+			assert.EqualValues(t, tc.maxRetries+1, requests.Load())
+			assert.EqualValues(t, tc.maxRetries, backoff.calls.Load())
+		})
+	}
+
+	t.Run("infinite", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*300)
+		t.Cleanup(cancel)
+
+		requests.Store(0)
+		client := newTestRemote(t, srv.URL,
+			WithBackoff[ClientParameters](&fixedBackoff{interval: time.Millisecond * 10}),
+			WithMaxRetries(-1),
+		)
+
+		// retried until the context is done
+		_, err := client.Health(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Greater(t, requests.Load(), int32(10))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewRemoteWithOptions("127.0.0.1", "26657", WithMaxRetries(-2))
+		assert.ErrorContains(t, err, "invalid MaxRetries")
+
+		// the retries of a supplied HTTPClient are its own, even when set to the default
+		_, err = NewRemoteWithOptions("127.0.0.1", "26657",
+			WithHTTPClient(http.DefaultClient),
+			WithMaxRetries(defaultMaxRetries),
+		)
+		assert.ErrorContains(t, err, "invalid MaxRetries")
+	})
+}
+
+func TestBlockFetcher_GetBlocks_FakeClock(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	// the first attempt fails
+	var calls atomic.Int32
+	client := &mockClient{
+		block: func(_ context.Context, height *int64) (*ctypes.ResultBlock, error) {
+			if calls.Add(1) == 1 {
+				return nil, errors.New("flaky")
+			}
+			return &ctypes.ResultBlock{Block: newHeightBlock(*height)}, nil
+		},
+	}
+	clock := NewFakeClock(time.Now())
+	fetcher, err := NewBlockFetcher(client,
+		WithClock(clock),
+		WithBackoff[FetcherParameters](&fixedBackoff{interval: time.Hour}),
+		WithBatchRetries(1),
+	)
+	require.NoError(t, err)
+
+	resultsCh := make(chan map[int64]*BlockResult, 1)
+	go func() {
+		results, err := fetcher.GetBlocks(ctx, []int64{1}, 1)
+		assert.NoError(t, err)
+		resultsCh <- results
+	}()
+
+	// the retry waits out the hour-long backoff only once the time is advanced
+	clock.BlockUntil(1)
+	assert.EqualValues(t, 1, calls.Load())
+	clock.Advance(time.Hour)
+
+	select {
+	case results := <-resultsCh:
+		require.NoError(t, results[1].Err)
+		assert.EqualValues(t, 1, results[1].Block.Height)
+	case <-ctx.Done():
+		require.NoError(t, ctx.Err())
+	}
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+// fixedBackoff is a custom Backoff waiting the same interval between all attempts.
+type fixedBackoff struct {
+	interval time.Duration
+
+	calls, resets atomic.Int32
+}
+
+func (b *fixedBackoff) Next(int) time.Duration {
+	b.calls.Add(1)
+	return b.interval
+}
+
+func (b *fixedBackoff) Reset() {
+	b.resets.Add(1)
+}
+
+// newTestRemote creates a new Client for the remote endpoint behind the given URL.
+func newTestRemote(t *testing.T, rawURL string, opts ...Option[ClientParameters]) Client {
+	u, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	ip, port, err := net.SplitHostPort(u.Host)
+	require.NoError(t, err)
+
+	client, err := NewRemoteWithOptions(ip, port, opts...)
+	require.NoError(t, err)
+	return client
+}
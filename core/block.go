@@ -0,0 +1,209 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/tendermint/tendermint/types"
+)
+
+// SignedBlock is a Core block bundled together with the Commit
+// finalizing it and the ValidatorSet that signed the Commit.
+type SignedBlock struct {
+	*types.Block
+
+	Commit       *types.Commit
+	ValidatorSet *types.ValidatorSet
+}
+
+// Round returns the consensus round the block was committed in. A non-zero round means
+// the block took multiple rounds to commit.
+func (b *SignedBlock) Round() int32 {
+	return b.Commit.Round
+}
+
+// RangeTxs calls fn for the raw bytes of every transaction in the block in order, until fn returns
+// false. The bytes must not be modified.
+// NOTE: The block is already decoded in full, as Core serves it as JSON, so this only spares
+// converting the transactions to a slice, e.g. with Txs.ToSliceOfBytes, and not decoding them.
+func (b *SignedBlock) RangeTxs(fn func(tx []byte) bool) {
+	for _, tx := range b.Txs {
+		if !fn(tx) {
+			return
+		}
+	}
+}
+
+// Verify ensures the Commit finalizes the block and is signed by more than 2/3 of
+// the ValidatorSet. A failure is reported as ErrCommitVerification.
+func (b *SignedBlock) Verify() error {
+	if !bytes.Equal(b.Commit.BlockID.Hash, b.Hash()) {
+		return &ErrCommitVerification{
+			Height:  b.Height,
+			Failure: CommitFailureBlockID,
+			Err:     fmt.Errorf("commit is for block %X, but the block hash is %X", b.Commit.BlockID.Hash, b.Hash()),
+		}
+	}
+
+	err := b.ValidatorSet.VerifyCommit(b.ChainID, b.Commit.BlockID, b.Height, b.Commit)
+	if err != nil {
+		return b.commitError(err)
+	}
+	return nil
+}
+
+// VerifyLastCommit ensures the LastCommit carried by the block finalizes the preceding block of the
+// given header and is signed by more than 2/3 of the given ValidatorSet of the preceding block,
+// e.g. to verify a chain of blocks without fetching their Commits separately. The LastCommit is
+// also checked against the LastCommitHash of the block. A failure is reported as
+// ErrCommitVerification at the preceding height.
+func VerifyLastCommit(block *types.Block, prevHeader *types.Header, prevValSet *types.ValidatorSet) error {
+	if block.LastCommit == nil {
+		return &ErrCommitVerification{
+			Height:  block.Height - 1,
+			Failure: CommitFailureUnknown,
+			Err:     fmt.Errorf("block at height %d has no last commit", block.Height),
+		}
+	}
+	if prevHeader.Height != block.Height-1 {
+		return &ErrCommitVerification{
+			Height:  block.Height - 1,
+			Failure: CommitFailureHeight,
+			Err:     fmt.Errorf("header does not precede block at height %d", block.Height),
+		}
+	}
+	if prevHash := prevHeader.Hash(); !bytes.Equal(block.LastCommit.BlockID.Hash, prevHash) ||
+		!bytes.Equal(block.LastBlockID.Hash, prevHash) {
+		return &ErrCommitVerification{
+			Height:  block.Height - 1,
+			Failure: CommitFailureBlockID,
+			Err: fmt.Errorf("last commit is for block %X and last block is %X, but the header hash is %X",
+				block.LastCommit.BlockID.Hash, block.LastBlockID.Hash, prevHash),
+		}
+	}
+
+	err := prevValSet.VerifyCommit(prevHeader.ChainID, block.LastCommit.BlockID, prevHeader.Height, block.LastCommit)
+	if err != nil {
+		prev := &SignedBlock{
+			Block:        &types.Block{Header: *prevHeader},
+			Commit:       block.LastCommit,
+			ValidatorSet: prevValSet,
+		}
+		return prev.commitError(err)
+	}
+	if hash := block.LastCommit.Hash(); !bytes.Equal(hash, block.LastCommitHash) {
+		return &ErrCommitVerification{
+			Height:  block.Height - 1,
+			Failure: CommitFailureLastCommitHash,
+			Err:     fmt.Errorf("last commit hash %X, but the header commits to %X", hash, block.LastCommitHash),
+		}
+	}
+	return nil
+}
+
+// commitError classifies the error the ValidatorSet failed to verify the Commit with.
+func (b *SignedBlock) commitError(err error) error {
+	verifyErr := &ErrCommitVerification{Height: b.Height, Err: err}
+
+	var (
+		sizeErr   types.ErrInvalidCommitSignatures
+		heightErr types.ErrInvalidCommitHeight
+		powerErr  types.ErrNotEnoughVotingPowerSigned
+	)
+	switch {
+	case errors.As(err, &sizeErr):
+		verifyErr.Failure = CommitFailureSignatureCount
+	case errors.As(err, &heightErr):
+		verifyErr.Failure = CommitFailureHeight
+	case errors.As(err, &powerErr):
+		verifyErr.Failure = CommitFailureVotingPower
+		verifyErr.Got, verifyErr.Needed = powerErr.Got, powerErr.Needed
+	default:
+		// the remaining checks are only reported as text, so find the offending signature
+		for idx, sig := range b.Commit.Signatures {
+			if sig.Absent() {
+				continue
+			}
+			val := b.ValidatorSet.Validators[idx]
+			if !val.PubKey.VerifySignature(b.Commit.VoteSignBytes(b.ChainID, int32(idx)), sig.Signature) {
+				verifyErr.Failure = CommitFailureSignature
+				verifyErr.Index, verifyErr.Validator = idx, val.Address
+				break
+			}
+		}
+	}
+	return verifyErr
+}
+
+// CommitFailure names the check a Commit failed verification on.
+type CommitFailure int
+
+const (
+	// CommitFailureUnknown is a failure not recognized as any of the known ones.
+	CommitFailureUnknown CommitFailure = iota
+	// CommitFailureBlockID means the Commit is for a different block.
+	CommitFailureBlockID
+	// CommitFailureHeight means the Commit is for a different height.
+	CommitFailureHeight
+	// CommitFailureSignatureCount means the number of signatures does not match the size of
+	// the ValidatorSet.
+	CommitFailureSignatureCount
+	// CommitFailureSignature means a signature does not verify against its validator.
+	CommitFailureSignature
+	// CommitFailureVotingPower means the validators signed with too little voting power.
+	CommitFailureVotingPower
+	// CommitFailureLastCommitHash means the LastCommit of a block does not match its
+	// LastCommitHash.
+	CommitFailureLastCommitHash
+)
+
+func (f CommitFailure) String() string {
+	switch f {
+	case CommitFailureBlockID:
+		return "wrong block ID"
+	case CommitFailureHeight:
+		return "wrong height"
+	case CommitFailureSignatureCount:
+		return "wrong signature count"
+	case CommitFailureSignature:
+		return "bad signature"
+	case CommitFailureVotingPower:
+		return "insufficient voting power"
+	case CommitFailureLastCommitHash:
+		return "wrong last commit hash"
+	default:
+		return "unknown failure"
+	}
+}
+
+// ErrCommitVerification is returned when a Commit fails verification against the block and
+// the ValidatorSet, describing the failed check.
+type ErrCommitVerification struct {
+	Height  int64
+	Failure CommitFailure
+	// Index and Validator identify the offending signature of CommitFailureSignature.
+	Index     int
+	Validator types.Address
+	// Got and Needed are the signed and the needed voting power of CommitFailureVotingPower.
+	Got, Needed int64
+	// Err is the underlying verification error.
+	Err error
+}
+
+func (e *ErrCommitVerification) Error() string {
+	switch e.Failure {
+	case CommitFailureSignature:
+		return fmt.Sprintf("core: verifying commit at height %d: %s #%d from validator %s",
+			e.Height, e.Failure, e.Index, e.Validator)
+	case CommitFailureVotingPower:
+		return fmt.Sprintf("core: verifying commit at height %d: %s: got %d, needed more than %d",
+			e.Height, e.Failure, e.Got, e.Needed)
+	default:
+		return fmt.Sprintf("core: verifying commit at height %d: %s: %v", e.Height, e.Failure, e.Err)
+	}
+}
+
+func (e *ErrCommitVerification) Unwrap() error {
+	return e.Err
+}
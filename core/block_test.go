@@ -0,0 +1,277 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tmrand "github.com/tendermint/tendermint/libs/rand"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+func TestSignedBlock_Verify(t *testing.T) {
+	const chainID = "private"
+	valSet, vals := RandValidatorSet(3, 1)
+
+	newSignedBlock := func(t *testing.T) *SignedBlock {
+		block := newTestBlock(chainID, 1, valSet)
+		voteSet := tmtypes.NewVoteSet(chainID, block.Height, 0, tmproto.PrecommitType, valSet)
+		sb, err := MakeSignedBlock(block, voteSet, valSet, vals, time.Now())
+		require.NoError(t, err)
+		return sb
+	}
+
+	require.NoError(t, newSignedBlock(t).Verify())
+
+	tests := []struct {
+		name    string
+		mutate  func(t *testing.T) *SignedBlock
+		failure CommitFailure
+		check   func(t *testing.T, err *ErrCommitVerification)
+	}{
+		{
+			name: "wrong block ID",
+			mutate: func(t *testing.T) *SignedBlock {
+				sb := newSignedBlock(t)
+				corrupted, err := CorruptBlock(sb.Block, DefectDataHash)
+				require.NoError(t, err)
+				sb.Block = corrupted
+				return sb
+			},
+			failure: CommitFailureBlockID,
+		},
+		{
+			name: "wrong height",
+			mutate: func(t *testing.T) *SignedBlock {
+				sb := newSignedBlock(t)
+				commit := *sb.Commit
+				commit.Height++
+				sb.Commit = &commit
+				return sb
+			},
+			failure: CommitFailureHeight,
+		},
+		{
+			name: "wrong signature count",
+			mutate: func(t *testing.T) *SignedBlock {
+				sb := newSignedBlock(t)
+				sb.ValidatorSet, _ = RandValidatorSet(4, 1)
+				return sb
+			},
+			failure: CommitFailureSignatureCount,
+		},
+		{
+			name: "bad signature",
+			mutate: func(t *testing.T) *SignedBlock {
+				sb := newSignedBlock(t)
+				sig := sb.Commit.Signatures[1]
+				sig.Signature = append([]byte{}, sig.Signature...)
+				sig.Signature[0] ^= 0xFF
+				sb.Commit.Signatures[1] = sig
+				return sb
+			},
+			failure: CommitFailureSignature,
+			check: func(t *testing.T, err *ErrCommitVerification) {
+				assert.Equal(t, 1, err.Index)
+				assert.Equal(t, valSet.Validators[1].Address, err.Validator)
+			},
+		},
+		{
+			name: "insufficient voting power",
+			mutate: func(t *testing.T) *SignedBlock {
+				sb := newSignedBlock(t)
+				sb.Commit.Signatures[1] = tmtypes.NewCommitSigAbsent()
+				sb.Commit.Signatures[2] = tmtypes.NewCommitSigAbsent()
+				return sb
+			},
+			failure: CommitFailureVotingPower,
+			check: func(t *testing.T, err *ErrCommitVerification) {
+				assert.Equal(t, int64(1), err.Got)
+				assert.Equal(t, int64(2), err.Needed)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.mutate(t).Verify()
+
+			var verifyErr *ErrCommitVerification
+			require.True(t, errors.As(err, &verifyErr))
+			assert.Equal(t, tt.failure, verifyErr.Failure, verifyErr.Error())
+			if tt.check != nil {
+				tt.check(t, verifyErr)
+			}
+		})
+	}
+}
+
+func TestSignedBlock_Round(t *testing.T) {
+	const chainID = "private"
+	valSet, vals := RandValidatorSet(3, 1)
+	block := newTestBlock(chainID, 1, valSet)
+
+	voteSet := tmtypes.NewVoteSet(chainID, block.Height, 2, tmproto.PrecommitType, valSet)
+	sb, err := MakeSignedBlock(block, voteSet, valSet, vals, time.Now())
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, sb.Round())
+
+	// the round survives the encoding Core responds with
+	commit, err := tmtypes.CommitFromProto(sb.Commit.ToProto())
+	require.NoError(t, err)
+	sb.Commit = commit
+	assert.EqualValues(t, 2, sb.Round())
+	require.NoError(t, sb.Verify())
+}
+
+func TestSignedBlock_RangeTxs(t *testing.T) {
+	sb := &SignedBlock{Block: newSizedBlock(1, 0)}
+	sb.Txs = tmtypes.Txs{[]byte("a"), []byte("b"), []byte("c")}
+
+	var txs [][]byte
+	sb.RangeTxs(func(tx []byte) bool {
+		txs = append(txs, tx)
+		return true
+	})
+	assert.Equal(t, sb.Txs.ToSliceOfBytes(), txs)
+
+	// the iteration stops early
+	var count int
+	sb.RangeTxs(func([]byte) bool {
+		count++
+		return count < 2
+	})
+	assert.Equal(t, 2, count)
+}
+
+func BenchmarkSignedBlock_Txs(b *testing.B) {
+	sb := &SignedBlock{Block: newSizedBlock(1, 0)}
+	sb.Txs = make(tmtypes.Txs, 10000)
+	for i := range sb.Txs {
+		sb.Txs[i] = tmrand.Bytes(256)
+	}
+
+	b.Run("RangeTxs", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var size int
+			sb.RangeTxs(func(tx []byte) bool {
+				size += len(tx)
+				return true
+			})
+		}
+	})
+
+	b.Run("ToSliceOfBytes", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var size int
+			for _, tx := range sb.Txs.ToSliceOfBytes() {
+				size += len(tx)
+			}
+		}
+	})
+}
+
+func TestVerifyLastCommit(t *testing.T) {
+	valSet, vals := RandValidatorSet(3, 1)
+	chain, err := MakeSignedBlockChain(1, 3, valSet, vals)
+	require.NoError(t, err)
+	for i := 1; i < len(chain); i++ {
+		require.NoError(t, VerifyLastCommit(chain[i].Block, &chain[i-1].Header, valSet))
+	}
+
+	// the block is tampered with by replacing its LastCommit with a copy
+	newBlock := func() *tmtypes.Block {
+		last := chain[2].LastCommit
+		commit := tmtypes.NewCommit(last.Height, last.Round, last.BlockID,
+			append([]tmtypes.CommitSig{}, last.Signatures...))
+		block := tmtypes.MakeBlock(chain[2].Height, tmtypes.Data{}, commit)
+		block.Header = chain[2].Header
+		return block
+	}
+	tests := []struct {
+		name       string
+		block      func() *tmtypes.Block
+		prevHeader *tmtypes.Header
+		prevValSet *tmtypes.ValidatorSet
+		failure    CommitFailure
+	}{
+		{
+			name: "bad signature",
+			block: func() *tmtypes.Block {
+				block := newBlock()
+				sig := &block.LastCommit.Signatures[1]
+				sig.Signature = append([]byte{}, sig.Signature...)
+				sig.Signature[0] ^= 0xFF
+				return block
+			},
+			failure: CommitFailureSignature,
+		},
+		{
+			name: "insufficient voting power",
+			block: func() *tmtypes.Block {
+				block := newBlock()
+				block.LastCommit.Signatures[0] = tmtypes.NewCommitSigAbsent()
+				block.LastCommit.Signatures[1] = tmtypes.NewCommitSigAbsent()
+				return block
+			},
+			failure: CommitFailureVotingPower,
+		},
+		{
+			name: "wrong last commit hash",
+			block: func() *tmtypes.Block {
+				block := newBlock()
+				block.LastCommitHash = tmrand.Bytes(32)
+				return block
+			},
+			failure: CommitFailureLastCommitHash,
+		},
+		{
+			name:       "wrong previous header",
+			prevHeader: &chain[0].Header,
+			failure:    CommitFailureHeight,
+		},
+		{
+			name: "wrong previous block",
+			prevHeader: func() *tmtypes.Header {
+				header := chain[1].Header
+				header.AppHash = tmrand.Bytes(32)
+				return &header
+			}(),
+			failure: CommitFailureBlockID,
+		},
+		{
+			name: "wrong previous validator set",
+			prevValSet: func() *tmtypes.ValidatorSet {
+				other, _ := RandValidatorSet(3, 1)
+				return other
+			}(),
+			failure: CommitFailureSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block, prevHeader, prevValSet := newBlock(), &chain[1].Header, valSet
+			if tt.block != nil {
+				block = tt.block()
+			}
+			if tt.prevHeader != nil {
+				prevHeader = tt.prevHeader
+			}
+			if tt.prevValSet != nil {
+				prevValSet = tt.prevValSet
+			}
+
+			err := VerifyLastCommit(block, prevHeader, prevValSet)
+			var verifyErr *ErrCommitVerification
+			require.True(t, errors.As(err, &verifyErr), err)
+			assert.Equal(t, tt.failure, verifyErr.Failure, verifyErr.Error())
+			assert.Equal(t, chain[1].Height, verifyErr.Height)
+		})
+	}
+}
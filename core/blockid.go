@@ -0,0 +1,50 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	"github.com/tendermint/tendermint/types"
+)
+
+// ErrInvalidBlockID is returned for a malformed block ID or block hash.
+var ErrInvalidBlockID = errors.New("core: invalid block ID")
+
+// BlockIDFromHash returns the BlockID of the block with the given hash, e.g. the hash of an
+// ExtendedHeader. The PartSetHeader can't be derived from the hash, so it is left empty, i.e. the
+// BlockID is only to be compared by its Hash.
+func BlockIDFromHash(hash tmbytes.HexBytes) (types.BlockID, error) {
+	if err := validateBlockHash(hash); err != nil {
+		return types.BlockID{}, err
+	}
+	return types.BlockID{Hash: hash}, nil
+}
+
+// HashFromBlockID returns the hash of the block with the given BlockID, validating it first.
+func HashFromBlockID(id types.BlockID) (tmbytes.HexBytes, error) {
+	if err := ValidateBlockID(id); err != nil {
+		return nil, err
+	}
+	return id.Hash, nil
+}
+
+// ValidateBlockID checks the given BlockID is well-formed and refers to a block, unlike the zero
+// BlockID of the LastBlockID at the initial height.
+func ValidateBlockID(id types.BlockID) error {
+	if err := validateBlockHash(id.Hash); err != nil {
+		return err
+	}
+	if err := id.PartSetHeader.ValidateBasic(); err != nil {
+		return fmt.Errorf("%w: part set header: %v", ErrInvalidBlockID, err)
+	}
+	return nil
+}
+
+func validateBlockHash(hash tmbytes.HexBytes) error {
+	if len(hash) != tmhash.Size {
+		return fmt.Errorf("%w: hash %X of %d bytes, expected %d", ErrInvalidBlockID, hash, len(hash), tmhash.Size)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestBlockID(t *testing.T) {
+	valSet, vals := RandValidatorSet(1, 1)
+	chain, err := MakeSignedBlockChain(1, 1, valSet, vals)
+	require.NoError(t, err)
+	sb := chain[0]
+	blockID := sb.Commit.BlockID
+
+	hash, err := HashFromBlockID(blockID)
+	require.NoError(t, err)
+	assert.Equal(t, sb.Hash(), hash)
+	id, err := BlockIDFromHash(hash)
+	require.NoError(t, err)
+	assert.Equal(t, blockID.Hash, id.Hash)
+	assert.NoError(t, ValidateBlockID(id))
+
+	malformedHashes := map[string]tmbytes.HexBytes{
+		"empty": nil,
+		"short": hash[:tmhash.Size-1],
+		"long":  append(hash.Bytes(), 0),
+	}
+	for name, hash := range malformedHashes {
+		hash := hash
+		t.Run(name, func(t *testing.T) {
+			_, err := BlockIDFromHash(hash)
+			assert.ErrorIs(t, err, ErrInvalidBlockID)
+			_, err = HashFromBlockID(types.BlockID{Hash: hash})
+			assert.ErrorIs(t, err, ErrInvalidBlockID)
+		})
+	}
+
+	t.Run("malformed part set header", func(t *testing.T) {
+		id := blockID
+		id.PartSetHeader.Hash = []byte{1, 2, 3}
+		_, err := HashFromBlockID(id)
+		assert.ErrorIs(t, err, ErrInvalidBlockID)
+		assert.ErrorContains(t, err, "part set header")
+	})
+}
@@ -0,0 +1,80 @@
+package core
+
+import (
+	"context"
+	"sync"
+)
+
+// memoryBudget bounds the total size in bytes of the blocks buffered by the range fetches in
+// flight. The size of a block is unknown until it is fetched, so every fetch reserves the size of
+// the largest block seen so far, settling the reservation to the actual size once fetched.
+// Until the first block is seen, a fetch reserves the whole budget, i.e. runs alone.
+type memoryBudget struct {
+	max int
+
+	lk       sync.Mutex
+	used     int
+	estimate int
+	// closed and replaced whenever bytes are freed
+	freed chan struct{}
+}
+
+func newMemoryBudget(max int) *memoryBudget {
+	return &memoryBudget{
+		max:   max,
+		freed: make(chan struct{}),
+	}
+}
+
+// reserve waits until the estimated size of a block fits the budget and reserves it, returning
+// the reserved size. A fetch is always let through while nothing is reserved, so that blocks
+// larger than the whole budget are still fetched, one at a time.
+func (b *memoryBudget) reserve(ctx context.Context) (int, error) {
+	for {
+		b.lk.Lock()
+		size := b.estimate
+		if size == 0 || size > b.max {
+			size = b.max
+		}
+		if b.used == 0 || b.used+size <= b.max {
+			b.used += size
+			b.lk.Unlock()
+			return size, nil
+		}
+		freed := b.freed
+		b.lk.Unlock()
+
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// settle replaces the reserved size with the actual size of the fetched block. A block larger
+// than the estimate is accounted for as is, exceeding the budget by at most the difference.
+func (b *memoryBudget) settle(reserved, size int) {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+	if size > b.estimate {
+		b.estimate = size
+	}
+	b.used += size - reserved
+	if size < reserved {
+		b.notify()
+	}
+}
+
+// release frees the size of a block no longer buffered.
+func (b *memoryBudget) release(size int) {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+	b.used -= size
+	b.notify()
+}
+
+func (b *memoryBudget) notify() {
+	close(b.freed)
+	b.freed = make(chan struct{})
+}
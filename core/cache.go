@@ -0,0 +1,76 @@
+package core
+
+import (
+	"sync"
+
+	"github.com/hashicorp/golang-lru/simplelru"
+	"github.com/tendermint/tendermint/types"
+)
+
+// blockCache is an LRU cache of blocks keyed by height. It is bounded by the number of blocks,
+// as well as by their total size in bytes, as blocks vary wildly in size.
+type blockCache struct {
+	lk    sync.Mutex
+	cache *simplelru.LRU
+
+	size, maxSize int
+}
+
+// cachedBlock is a block stored in the blockCache along with its size.
+type cachedBlock struct {
+	block *types.Block
+	size  int
+}
+
+// newBlockCache creates a new blockCache holding up to `maxBlocks` blocks of up to `maxSize`
+// bytes in total. Zero `maxSize` means no bound on the size.
+func newBlockCache(maxBlocks, maxSize int) (*blockCache, error) {
+	c := &blockCache{maxSize: maxSize}
+	cache, err := simplelru.NewLRU(maxBlocks, func(_, val interface{}) {
+		c.size -= val.(*cachedBlock).size
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.cache = cache
+	return c, nil
+}
+
+// Get returns the cached block at the given height, if any.
+func (c *blockCache) Get(height int64) (*types.Block, bool) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	val, ok := c.cache.Get(height)
+	if !ok {
+		return nil, false
+	}
+	return val.(*cachedBlock).block, true
+}
+
+// Add caches the given block, evicting the oldest blocks until the cache is within its bounds.
+// A block larger than the whole cache is not cached.
+func (c *blockCache) Add(block *types.Block) {
+	size := block.Size()
+	if c.maxSize > 0 && size > c.maxSize {
+		return
+	}
+
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	// the replaced block of the same height is accounted for by the eviction callback
+	c.cache.Remove(block.Height)
+	c.cache.Add(block.Height, &cachedBlock{block: block, size: size})
+	c.size += size
+	for c.maxSize > 0 && c.size > c.maxSize {
+		c.cache.RemoveOldest()
+	}
+}
+
+// Size returns the total size of the cached blocks in bytes.
+func (c *blockCache) Size() int {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	return c.size
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tmrand "github.com/tendermint/tendermint/libs/rand"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestBlockCache_MaxSize(t *testing.T) {
+	const maxSize = 64 << 10
+	cache, err := newBlockCache(100, maxSize)
+	require.NoError(t, err)
+
+	for height := int64(1); height <= 50; height++ {
+		// blocks between 1KiB and 32KiB
+		block := newSizedBlock(height, 1<<10+tmrand.Intn(31<<10))
+		cache.Add(block)
+		assert.LessOrEqual(t, cache.Size(), maxSize)
+
+		// the latest block is always kept
+		cached, ok := cache.Get(height)
+		require.True(t, ok)
+		assert.Equal(t, block, cached)
+	}
+
+	// the oldest blocks are evicted
+	_, ok := cache.Get(1)
+	assert.False(t, ok)
+
+	// a block larger than the whole cache is not cached
+	cache.Add(newSizedBlock(51, maxSize*2))
+	_, ok = cache.Get(51)
+	assert.False(t, ok)
+	assert.LessOrEqual(t, cache.Size(), maxSize)
+}
+
+func TestBlockCache_Replace(t *testing.T) {
+	cache, err := newBlockCache(10, 0)
+	require.NoError(t, err)
+
+	cache.Add(newSizedBlock(1, 1<<10))
+	block := newSizedBlock(1, 2<<10)
+	cache.Add(block)
+	assert.Equal(t, block.Size(), cache.Size())
+}
+
+// newSizedBlock creates a block at the given height carrying a transaction of the given size.
+func newSizedBlock(height int64, txSize int) *types.Block {
+	return types.MakeBlock(height, types.Data{Txs: types.Txs{tmrand.Bytes(txSize)}}, &types.Commit{})
+}
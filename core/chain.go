@@ -0,0 +1,141 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/tendermint/tendermint/types"
+)
+
+// ChainFailure names the check a header of a chain failed verification on.
+type ChainFailure int
+
+const (
+	// ChainFailureInvalidHeader means the signed header is malformed or of another chain.
+	ChainFailureInvalidHeader ChainFailure = iota
+	// ChainFailureValidatorSet means the ValidatorSet does not match the ValidatorsHash of the
+	// header.
+	ChainFailureValidatorSet
+	// ChainFailureHeight means the header does not follow the preceding one by height.
+	ChainFailureHeight
+	// ChainFailureNextValidators means the ValidatorsHash of the header is not the
+	// NextValidatorsHash of the preceding one.
+	ChainFailureNextValidators
+	// ChainFailureLastBlockID means the LastBlockID of the header is not the preceding block.
+	ChainFailureLastBlockID
+	// ChainFailureCommit means the Commit is not signed by more than 2/3 of the ValidatorSet.
+	ChainFailureCommit
+)
+
+func (f ChainFailure) String() string {
+	switch f {
+	case ChainFailureInvalidHeader:
+		return "invalid header"
+	case ChainFailureValidatorSet:
+		return "wrong validator set"
+	case ChainFailureHeight:
+		return "non-adjacent height"
+	case ChainFailureNextValidators:
+		return "wrong next validators"
+	case ChainFailureLastBlockID:
+		return "wrong last block ID"
+	case ChainFailureCommit:
+		return "invalid commit"
+	default:
+		return "unknown failure"
+	}
+}
+
+// ErrChainVerification is returned when a header of a chain fails verification, identifying the
+// first failing header and the check it failed.
+type ErrChainVerification struct {
+	// Index is the index of the failing header in the chain.
+	Index   int
+	Height  int64
+	Failure ChainFailure
+	Err     error
+}
+
+func (e *ErrChainVerification) Error() string {
+	return fmt.Sprintf("core: verifying header #%d at height %d: %s: %v", e.Index, e.Height, e.Failure, e.Err)
+}
+
+func (e *ErrChainVerification) Unwrap() error {
+	return e.Err
+}
+
+// VerifySignedHeaderChain verifies the given signed headers form an adjacent chain, with every
+// header following the preceding one by height, by the ValidatorsHash promised in its
+// NextValidatorsHash and by LastBlockID, and every Commit signed by more than 2/3 of the
+// ValidatorSet given at the same index. The first failing header is reported as
+// ErrChainVerification.
+// NOTE: The first header is only verified on its own, so it has to be trusted otherwise.
+func VerifySignedHeaderChain(
+	ctx context.Context,
+	headers []*types.SignedHeader,
+	valSets []*types.ValidatorSet,
+) error {
+	if len(headers) != len(valSets) {
+		return fmt.Errorf("core: got %d validator sets for %d headers", len(valSets), len(headers))
+	}
+	if len(headers) == 0 {
+		return nil
+	}
+
+	chainID := headers[0].ChainID
+	for i, sh := range headers {
+		// verifying the signatures is CPU-bound, so long chains can take a while
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if failure, err := verifyChainLink(chainID, headers, valSets, i); err != nil {
+			return &ErrChainVerification{Index: i, Height: sh.Height, Failure: failure, Err: err}
+		}
+	}
+	return nil
+}
+
+// verifyChainLink verifies the header at the given index of the chain, along with its link to the
+// preceding header, if any.
+func verifyChainLink(
+	chainID string,
+	headers []*types.SignedHeader,
+	valSets []*types.ValidatorSet,
+	i int,
+) (ChainFailure, error) {
+	sh, valSet := headers[i], valSets[i]
+	if sh == nil || sh.Header == nil || sh.Commit == nil {
+		return ChainFailureInvalidHeader, fmt.Errorf("missing header or commit")
+	}
+	if err := sh.ValidateBasic(chainID); err != nil {
+		return ChainFailureInvalidHeader, err
+	}
+	if valSet == nil {
+		return ChainFailureValidatorSet, fmt.Errorf("missing validator set")
+	}
+	if hash := valSet.Hash(); !bytes.Equal(hash, sh.ValidatorsHash) {
+		return ChainFailureValidatorSet, fmt.Errorf("validator set hash %X, header validators hash %X",
+			hash, sh.ValidatorsHash)
+	}
+
+	if i > 0 {
+		prev := headers[i-1]
+		if sh.Height != prev.Height+1 {
+			return ChainFailureHeight, fmt.Errorf("previous height %d", prev.Height)
+		}
+		if !bytes.Equal(sh.ValidatorsHash, prev.NextValidatorsHash) {
+			return ChainFailureNextValidators, fmt.Errorf("validators hash %X, previous next validators hash %X",
+				sh.ValidatorsHash, prev.NextValidatorsHash)
+		}
+		if !bytes.Equal(sh.LastBlockID.Hash, prev.Commit.BlockID.Hash) {
+			return ChainFailureLastBlockID, fmt.Errorf("last block %X, previous block %X",
+				sh.LastBlockID.Hash, prev.Commit.BlockID.Hash)
+		}
+	}
+
+	if err := valSet.VerifyCommit(chainID, sh.Commit.BlockID, sh.Height, sh.Commit); err != nil {
+		return ChainFailureCommit, err
+	}
+	return 0, nil
+}
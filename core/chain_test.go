@@ -0,0 +1,129 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+// headerChain is a chain of signed headers along with their validator sets.
+type headerChain struct {
+	headers []*types.SignedHeader
+	valSets []*types.ValidatorSet
+}
+
+func TestVerifySignedHeaderChain(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	valSet, vals := RandValidatorSet(3, 1)
+	chain, err := MakeSignedBlockChain(1, 10, valSet, vals)
+	require.NoError(t, err)
+	// a chain of the same heights and validators, but of other blocks
+	fork, err := MakeSignedBlockChain(1, 10, valSet, vals)
+	require.NoError(t, err)
+
+	// copies the headers, so that they can be corrupted
+	headers := func(blocks []*SignedBlock) *headerChain {
+		c := &headerChain{}
+		for _, sb := range blocks {
+			header := sb.Header
+			commit := *sb.Commit
+			commit.Signatures = append([]types.CommitSig(nil), sb.Commit.Signatures...)
+			c.headers = append(c.headers, &types.SignedHeader{Header: &header, Commit: &commit})
+			c.valSets = append(c.valSets, sb.ValidatorSet)
+		}
+		return c
+	}
+
+	valid := headers(chain)
+	require.NoError(t, VerifySignedHeaderChain(ctx, valid.headers, valid.valSets))
+
+	otherSet, _ := RandValidatorSet(3, 1)
+	// the previous block promises other validators for the next one, but is still properly signed
+	promising := types.MakeBlock(chain[3].Height, chain[3].Data, chain[3].LastCommit)
+	promising.Header = chain[3].Header
+	promising.NextValidatorsHash = otherSet.Hash()
+	voteSet := types.NewVoteSet(signedBlockChainID, promising.Height, 0, tmproto.PrecommitType, valSet)
+	promised, err := MakeSignedBlock(promising, voteSet, valSet, vals, promising.Time)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		corrupt func(c *headerChain)
+		index   int
+		failure ChainFailure
+	}{
+		{
+			name: "invalid header",
+			corrupt: func(c *headerChain) {
+				c.headers[2].Time = c.headers[2].Time.Add(time.Second)
+			},
+			index:   2,
+			failure: ChainFailureInvalidHeader,
+		},
+		{
+			name: "wrong validator set",
+			corrupt: func(c *headerChain) {
+				c.valSets[3] = otherSet
+			},
+			index:   3,
+			failure: ChainFailureValidatorSet,
+		},
+		{
+			name: "missing height",
+			corrupt: func(c *headerChain) {
+				c.headers = append(c.headers[:5], c.headers[6:]...)
+				c.valSets = append(c.valSets[:5], c.valSets[6:]...)
+			},
+			index:   5,
+			failure: ChainFailureHeight,
+		},
+		{
+			name: "wrong next validators",
+			corrupt: func(c *headerChain) {
+				c.headers[3] = &types.SignedHeader{Header: &promised.Header, Commit: promised.Commit}
+			},
+			index:   4,
+			failure: ChainFailureNextValidators,
+		},
+		{
+			name: "forked block",
+			corrupt: func(c *headerChain) {
+				c.headers[6] = headers(fork).headers[6]
+			},
+			index:   6,
+			failure: ChainFailureLastBlockID,
+		},
+		{
+			name: "bad signature",
+			corrupt: func(c *headerChain) {
+				sig := &c.headers[8].Commit.Signatures[0]
+				sig.Signature = append([]byte(nil), sig.Signature...)
+				sig.Signature[0] ^= 0xff
+			},
+			index:   8,
+			failure: ChainFailureCommit,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := headers(chain)
+			tt.corrupt(c)
+			err := VerifySignedHeaderChain(ctx, c.headers, c.valSets)
+			var errChain *ErrChainVerification
+			require.ErrorAs(t, err, &errChain)
+			assert.Equal(t, tt.index, errChain.Index)
+			assert.Equal(t, c.headers[tt.index].Height, errChain.Height)
+			assert.Equal(t, tt.failure, errChain.Failure, errChain.Error())
+		})
+	}
+
+	err = VerifySignedHeaderChain(ctx, valid.headers, valid.valSets[1:])
+	require.Error(t, err)
+}
@@ -1,27 +1,394 @@
 package core
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
 
 	retryhttp "github.com/hashicorp/go-retryablehttp"
 
 	"github.com/tendermint/tendermint/rpc/client"
-	"github.com/tendermint/tendermint/rpc/client/http"
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
 )
 
-// Client is an alias to Core Client.
-type Client = client.Client
+// Client is a Core client: the Tendermint RPC client extended with Celestia specifics.
+type Client interface {
+	client.Client
+
+	// Raw returns the underlying Tendermint RPC client as an escape hatch for the functionality
+	// not covered by Client.
+	// NOTE: Calls made through the raw client bypass any behavior Client adds on top of it.
+	Raw() client.Client
+	// Ping issues the cheapest possible request to Core, ensuring the connection is alive.
+	Ping(ctx context.Context) error
+	// Versions returns the versions Core runs, e.g. for upgrade decisions.
+	Versions(ctx context.Context) (*Versions, error)
+	// AppInfo returns the metadata of the application run by Core, as reported over ABCI.
+	// It is the canonical source of the app version, e.g. during upgrades.
+	AppInfo(ctx context.Context) (*AppInfo, error)
+	// Tx looks up the transaction with the given hash, returning the height and the index it was
+	// included at along with its result, and its inclusion proof if `prove` is set.
+	// ErrTxNotFound is returned for an unknown hash.
+	Tx(ctx context.Context, hash []byte, prove bool) (*ctypes.ResultTx, error)
+	// SearchTxs returns the given page of the transactions with events matching the query,
+	// e.g. "message.sender='celestia1...'", along with the total count of the matching ones.
+	// Pages are counted from 1 and hold up to maxTxSearchPerPage transactions.
+	SearchTxs(ctx context.Context, query string, page, perPage int) (*ctypes.ResultTxSearch, error)
+	// ConnState returns the state of the connection to Core as observed by the latest requests,
+	// without making one.
+	ConnState() ConnState
+	// Endpoint returns the address of Core the client connects to, e.g. "tcp://127.0.0.1:26657".
+	Endpoint() string
+}
+
+// AppInfo is the metadata of the application run by Core.
+type AppInfo struct {
+	// Name is the name of the application, as reported in the data of the ABCI info.
+	Name string
+	// Version is the version of the application software.
+	Version string
+	// AppVersion is the version of the application protocol.
+	AppVersion uint64
+	// LastBlockHeight is the height of the last block committed by the application.
+	LastBlockHeight int64
+	// LastBlockAppHash is the app hash the application committed the last block with.
+	LastBlockAppHash []byte
+}
+
+// maxTxSearchPerPage is the largest page of transactions Core serves.
+const maxTxSearchPerPage = 100
+
+// ErrTxNotFound is returned when Core does not know the transaction with the requested hash.
+type ErrTxNotFound struct {
+	Hash []byte
+}
+
+func (e *ErrTxNotFound) Error() string {
+	return fmt.Sprintf("core: tx %X not found", e.Hash)
+}
+
+// Versions are the versions of the protocols and the software run by Core.
+type Versions struct {
+	// App is the version of the application protocol.
+	App uint64
+	// Block is the version of the block protocol.
+	Block uint64
+	// Tendermint is the version of the Tendermint software.
+	Tendermint string
+}
+
+// remoteClient is a Client communicating with a remote Core endpoint.
+// NOTE: Its subscriptions go over a websocket of its own, so they are not available through the
+// raw client.
+type remoteClient struct {
+	tendermintClient
+	events *wsEvents
+
+	endpoint  string
+	chainID   string
+	keepAlive time.Duration
+	// closed on stop to end the keepalive
+	keepAliveDone chan struct{}
+	// serves the reads if PreferWebsocket is set
+	ws    *wsTransport
+	state *connStateTracker
+	// closed on stop to cancel the requests in flight
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// statusCheckTimeout bounds the request checking the status of Core on start or, with
+// EagerConnect, on construction.
+const statusCheckTimeout = time.Second * 30
+
+// ErrChainIDMismatch is returned on start when Core serves a chain other than the expected one,
+// e.g. because the endpoint points to the wrong network.
+type ErrChainIDMismatch struct {
+	Expected string
+	Actual   string
+}
+
+func (e *ErrChainIDMismatch) Error() string {
+	return fmt.Sprintf("core: endpoint serves chain ID %q, but %q is expected", e.Actual, e.Expected)
+}
 
 // NewRemote creates a new Client that communicates with a remote Core endpoint over HTTP.
 func NewRemote(ip, port string) (Client, error) {
-	httpClient := retryhttp.NewClient()
-	httpClient.RetryMax = 2
-	// suppress logging
-	httpClient.Logger = nil
-
-	return http.NewWithClient(
-		fmt.Sprintf("tcp://%s:%s", ip, port),
-		"/websocket",
-		httpClient.StandardClient(),
-	)
+	return NewRemoteWithOptions(ip, port)
+}
+
+// NewRemoteWithOptions creates a new Client that communicates with a remote Core endpoint over
+// HTTP, configured with the given options.
+func NewRemoteWithOptions(ip, port string, opts ...Option[ClientParameters]) (Client, error) {
+	params := DefaultClientParameters()
+	for _, opt := range opts {
+		opt(params)
+	}
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("core: invalid client parameters: %w", err)
+	}
+
+	events, err := newWSEvents(ip, port, params)
+	if err != nil {
+		return nil, err
+	}
+	var ws *wsTransport
+	if params.PreferWebsocket {
+		ws, err = newWSTransport(ip, port, params)
+		if err != nil {
+			return nil, err
+		}
+	}
+	state := &connStateTracker{observer: params.ConnStateObserver}
+	httpClient, err := newHTTPClient(params, ws, state)
+	if err != nil {
+		return nil, err
+	}
+	stopped := make(chan struct{})
+	// outermost, so that all the requests are cancelled, including the ones waiting for retries
+	httpClient.Transport = &stopTransport{
+		base:    httpClient.Transport,
+		stopped: stopped,
+	}
+
+	scheme := "tcp"
+	if params.TLS != nil {
+		scheme = "https"
+	}
+	endpoint := fmt.Sprintf("%s://%s:%s", scheme, ip, port)
+	rpcClient, err := rpchttp.NewWithClient(endpoint, "/websocket", httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &remoteClient{
+		tendermintClient: tendermintClient{Client: rpcClient},
+		events:           events,
+		endpoint:         endpoint,
+		chainID:          params.ChainID,
+		keepAlive:        params.KeepAlive,
+		ws:               ws,
+		state:            state,
+		stopped:          stopped,
+	}
+	if params.EagerConnect {
+		if err := c.checkStatus(); err != nil {
+			close(stopped)
+			if ws != nil {
+				ws.close()
+			}
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// checkStatus ensures Core is reachable and serves the expected chain, if configured.
+func (c *remoteClient) checkStatus() error {
+	ctx, cancel := context.WithTimeout(context.Background(), statusCheckTimeout)
+	defer cancel()
+
+	status, err := c.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("core: checking status: %w", err)
+	}
+	if c.chainID != "" && status.NodeInfo.Network != c.chainID {
+		return &ErrChainIDMismatch{Expected: c.chainID, Actual: status.NodeInfo.Network}
+	}
+	return nil
+}
+
+// Start ensures Core serves the expected chain, if configured, and starts the client.
+func (c *remoteClient) Start() error {
+	if c.chainID != "" {
+		if err := c.checkStatus(); err != nil {
+			return err
+		}
+	}
+
+	if err := c.events.Start(); err != nil {
+		return err
+	}
+	if c.keepAlive > 0 {
+		c.keepAliveDone = make(chan struct{})
+		go c.keepConnAlive(c.keepAliveDone)
+	}
+	return nil
+}
+
+// Stop cancels the requests in flight, ends the keepalive, if enabled, and stops the client.
+func (c *remoteClient) Stop() error {
+	c.stopOnce.Do(func() {
+		close(c.stopped)
+	})
+	if c.keepAliveDone != nil {
+		close(c.keepAliveDone)
+		c.keepAliveDone = nil
+	}
+	if c.ws != nil {
+		c.ws.close()
+	}
+	return c.events.Stop()
+}
+
+func (c *remoteClient) IsRunning() bool {
+	return c.events.IsRunning()
+}
+
+func (c *remoteClient) Quit() <-chan struct{} {
+	return c.events.Quit()
+}
+
+func (c *remoteClient) Subscribe(
+	ctx context.Context,
+	subscriber, query string,
+	outCapacity ...int,
+) (<-chan ctypes.ResultEvent, error) {
+	return c.events.Subscribe(ctx, subscriber, query, outCapacity...)
+}
+
+func (c *remoteClient) Unsubscribe(ctx context.Context, subscriber, query string) error {
+	return c.events.Unsubscribe(ctx, subscriber, query)
+}
+
+func (c *remoteClient) UnsubscribeAll(ctx context.Context, subscriber string) error {
+	return c.events.UnsubscribeAll(ctx, subscriber)
+}
+
+// keepConnAlive pings Core every KeepAlive interval until done is closed, so that the idle
+// connection is not silently dropped by intermediaries.
+func (c *remoteClient) keepConnAlive(done <-chan struct{}) {
+	ticker := time.NewTicker(c.keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), c.keepAlive)
+			err := c.Ping(ctx)
+			cancel()
+			if err != nil {
+				log.Warnw("keepalive ping failed", "err", err)
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+func (c *remoteClient) ConnState() ConnState {
+	return c.state.get()
+}
+
+func (c *remoteClient) Endpoint() string {
+	return c.endpoint
+}
+
+// newHTTPClient builds the HTTP client for requests to Core as configured by the given params,
+// routing the reads over the given wsTransport, if any, and tracking the ConnState with the given
+// tracker.
+func newHTTPClient(params *ClientParameters, ws *wsTransport, state *connStateTracker) (*http.Client, error) {
+	var httpClient *http.Client
+	if params.HTTPClient != nil {
+		// copy, so that the supplied client stays untouched
+		c := *params.HTTPClient
+		httpClient = &c
+		if httpClient.Transport == nil {
+			httpClient.Transport = http.DefaultTransport
+		}
+		httpClient.Transport = newWireTransport(params, httpClient.Transport)
+	} else {
+		retryClient := retryhttp.NewClient()
+		retryClient.RetryMax = params.MaxRetries
+		if params.MaxRetries == -1 {
+			// bounded by the request timeout or the context instead
+			retryClient.RetryMax = math.MaxInt
+		}
+		retryClient.Backoff = func(_, _ time.Duration, attempt int, _ *http.Response) time.Duration {
+			// retryablehttp counts attempts from 0
+			return params.Backoff.Next(attempt + 1)
+		}
+		retryClient.CheckRetry = state.checkRetry
+		// suppress logging
+		retryClient.Logger = nil
+		transport := retryClient.HTTPClient.Transport.(*http.Transport)
+		if params.TLS != nil {
+			tlsConfig, err := newTLSConfig(params)
+			if err != nil {
+				return nil, err
+			}
+			transport.TLSClientConfig = tlsConfig
+		}
+		if params.MaxIdleConnsPerHost > 0 {
+			transport.MaxIdleConnsPerHost = params.MaxIdleConnsPerHost
+		}
+		transport.MaxConnsPerHost = params.MaxConnsPerHost
+		if dial := dialFunc(params); dial != nil {
+			transport.DialContext = dial
+		}
+		transport.DialContext = dialWithTCPOptions(params, transport.DialContext)
+		// under the retries, so that every attempt is reported as on the wire
+		retryClient.HTTPClient.Transport = newWireTransport(params, transport)
+		httpClient = retryClient.StandardClient()
+	}
+	if _, ok := params.Codec.(TendermintCodec); !ok {
+		httpClient.Transport = &codecTransport{
+			base:  httpClient.Transport,
+			codec: params.Codec,
+		}
+	}
+	if params.RequestIDGenerator != nil {
+		httpClient.Transport = &requestIDTransport{
+			base:     httpClient.Transport,
+			generate: params.RequestIDGenerator,
+		}
+	}
+	if ws != nil {
+		ws.base = httpClient.Transport
+		httpClient.Transport = ws
+	}
+	httpClient.Transport = &connStateTransport{
+		base:  httpClient.Transport,
+		state: state,
+	}
+
+	httpClient.Transport = &deadlineTransport{
+		base: &userAgentTransport{
+			base:      httpClient.Transport,
+			userAgent: params.UserAgent,
+		},
+		timeout:        params.RequestTimeout,
+		methodTimeouts: params.MethodTimeouts,
+	}
+	if params.RateLimit > 0 {
+		burst := params.RateBurst
+		if burst == 0 {
+			burst = 1
+		}
+		// outermost, so that waiting for the limit doesn't count against the request timeout
+		httpClient.Transport = &rateLimitTransport{
+			base:    httpClient.Transport,
+			limiter: newRateLimiter(params.RateLimit, burst),
+		}
+	}
+	return httpClient, nil
+}
+
+// newTLSConfig builds the TLS config for requests to Core, adding the client certificate, if any,
+// to authenticate the client with mutual TLS.
+func newTLSConfig(params *ClientParameters) (*tls.Config, error) {
+	tlsConfig := params.TLS.Clone()
+	if params.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(params.ClientCertFile, params.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("core: loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = append(tlsConfig.Certificates, cert)
+	}
+	return tlsConfig, nil
 }
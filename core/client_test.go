@@ -1,12 +1,38 @@
 package core
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/p2p"
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
 	"github.com/tendermint/tendermint/types"
+	"github.com/tendermint/tendermint/version"
+	"golang.org/x/sync/errgroup"
 )
 
 func TestRemoteClient_Status(t *testing.T) {
@@ -41,3 +67,1104 @@ func TestRemoteClient_StartBlockSubscription_And_GetBlock(t *testing.T) {
 	// unsubscribe to event channel
 	require.NoError(t, client.Unsubscribe(ctx, newBlockSubscriber, newBlockEventQuery))
 }
+
+func TestRemoteClient_Raw(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	_, client := StartTestCoreWithApp(t)
+	status, err := client.Raw().Status(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, status)
+	require.NotEmpty(t, status.NodeInfo.Network)
+}
+
+func TestNewClientFromTendermint(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	srv := newRPCServer(t, func(method string, _ json.RawMessage) (any, error) {
+		switch method {
+		case "health":
+			return struct{}{}, nil
+		case "status":
+			return &ctypes.ResultStatus{NodeInfo: p2p.DefaultNodeInfo{
+				Version:         "0.34.20",
+				ProtocolVersion: p2p.ProtocolVersion{App: 1, Block: 11},
+			}}, nil
+		}
+		return nil, fmt.Errorf("unexpected method %s", method)
+	})
+	raw, err := rpchttp.New(srv.URL, "/websocket")
+	require.NoError(t, err)
+
+	client := NewClientFromTendermint(raw)
+	assert.Same(t, raw, client.Raw())
+	assert.Equal(t, ConnStateIdle, client.ConnState())
+	require.NoError(t, client.Ping(ctx))
+
+	versions, err := client.Versions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "0.34.20", versions.Tendermint)
+	assert.Equal(t, uint64(1), versions.App)
+	assert.Equal(t, uint64(11), versions.Block)
+}
+
+func TestRemoteClient_MethodTimeouts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	srv := newRPCServer(t, func(string, json.RawMessage) (any, error) {
+		// every call is equally slow
+		time.Sleep(time.Millisecond * 200)
+		return struct{}{}, nil
+	})
+	client := newTestRemote(t, srv.URL,
+		WithRequestTimeout(time.Second),
+		WithMethodTimeouts(map[string]time.Duration{
+			"block":  time.Second * 2,
+			"status": time.Millisecond * 50,
+		}),
+	)
+
+	_, err := client.Block(ctx, nil)
+	require.NoError(t, err)
+
+	_, err = client.Status(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// health has no timeout of its own and falls back to the request timeout
+	_, err = client.Health(ctx)
+	require.NoError(t, err)
+}
+
+func TestRemoteClient_UserAgent(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	userAgents := make(chan string, 1)
+	rpcHandler := newRPCHTTPHandler(func(string, json.RawMessage) (any, error) {
+		return struct{}{}, nil
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents <- r.Header.Get("User-Agent")
+		rpcHandler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	t.Run("default", func(t *testing.T) {
+		client := newTestRemote(t, srv.URL)
+		_, err := client.Health(ctx)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(<-userAgents, "celestia-node/"))
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		client := newTestRemote(t, srv.URL, WithUserAgent("bridge/1.0"))
+		_, err := client.Health(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "bridge/1.0", <-userAgents)
+	})
+}
+
+func TestRemoteClient_HTTPClient(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	srv := newRPCServer(t, func(string, json.RawMessage) (any, error) {
+		return struct{}{}, nil
+	})
+
+	transport := &recordingTransport{base: http.DefaultTransport}
+	client := newTestRemote(t, srv.URL, WithHTTPClient(&http.Client{Transport: transport}))
+	_, err := client.Health(ctx)
+	require.NoError(t, err)
+
+	require.Len(t, transport.requests, 1)
+	assert.True(t, strings.HasPrefix(transport.requests[0].Header.Get("User-Agent"), "celestia-node/"))
+
+	_, err = NewRemoteWithOptions("127.0.0.1", "26657", WithHTTPClient(nil))
+	require.Error(t, err)
+}
+
+// recordingTransport records the requests sent through it.
+type recordingTransport struct {
+	base     http.RoundTripper
+	requests []*http.Request
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests = append(t.requests, req)
+	return t.base.RoundTrip(req)
+}
+
+func TestRemoteClient_ChainID(t *testing.T) {
+	srv := newRPCServer(t, func(method string, _ json.RawMessage) (any, error) {
+		require.Equal(t, "status", method)
+		return &ctypes.ResultStatus{NodeInfo: p2p.DefaultNodeInfo{Network: "mocha"}}, nil
+	})
+
+	client := newTestRemote(t, srv.URL, WithChainID("private"))
+	err := client.Start()
+
+	var errMismatch *ErrChainIDMismatch
+	require.ErrorAs(t, err, &errMismatch)
+	assert.Equal(t, "private", errMismatch.Expected)
+	assert.Equal(t, "mocha", errMismatch.Actual)
+	assert.False(t, client.IsRunning())
+}
+
+func TestRemoteClient_EagerConnect(t *testing.T) {
+	backoff := WithBackoff[ClientParameters](&fixedBackoff{interval: time.Millisecond})
+
+	t.Run("unreachable", func(t *testing.T) {
+		// nothing listens on the port once the listener is closed
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		ip, port, err := net.SplitHostPort(lis.Addr().String())
+		require.NoError(t, err)
+		require.NoError(t, lis.Close())
+
+		start := time.Now()
+		_, err = NewRemoteWithOptions(ip, port, WithEagerConnect(true), backoff)
+		assert.ErrorContains(t, err, "checking status")
+		assert.Less(t, time.Since(start), time.Second)
+
+		// the failure only surfaces later without the option
+		_, err = NewRemoteWithOptions(ip, port, backoff)
+		assert.NoError(t, err)
+	})
+
+	t.Run("wrong chain", func(t *testing.T) {
+		srv := newRPCServer(t, func(string, json.RawMessage) (any, error) {
+			return &ctypes.ResultStatus{NodeInfo: p2p.DefaultNodeInfo{Network: "mocha"}}, nil
+		})
+		ip, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+		require.NoError(t, err)
+
+		_, err = NewRemoteWithOptions(ip, port, WithEagerConnect(true), WithChainID("private"), backoff)
+		var errMismatch *ErrChainIDMismatch
+		require.ErrorAs(t, err, &errMismatch)
+		assert.Equal(t, "mocha", errMismatch.Actual)
+
+		client, err := NewRemoteWithOptions(ip, port, WithEagerConnect(true), WithChainID("mocha"), backoff)
+		require.NoError(t, err)
+		assert.Equal(t, ConnStateConnected, client.ConnState())
+	})
+}
+
+func TestRemoteClient_KeepAlive(t *testing.T) {
+	pings := make(chan string, 10)
+	srv := newRPCServer(t, func(method string, _ json.RawMessage) (any, error) {
+		select {
+		case pings <- method:
+		default:
+		}
+		return &ctypes.ResultHealth{}, nil
+	})
+
+	client := newTestRemote(t, srv.URL, WithKeepAlive(time.Millisecond*10))
+	require.NoError(t, client.Start())
+	for i := 0; i < 3; i++ {
+		select {
+		case method := <-pings:
+			assert.Equal(t, "health", method)
+		case <-time.After(time.Second):
+			t.Fatal("keepalive did not ping")
+		}
+	}
+
+	// no pings once stopped, past the one that may have been in flight
+	require.NoError(t, client.Stop())
+	time.Sleep(time.Millisecond * 50)
+	for len(pings) > 0 {
+		<-pings
+	}
+	time.Sleep(time.Millisecond * 50)
+	assert.Empty(t, pings)
+}
+
+func TestRemoteClient_DialContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	srv := newRPCServer(t, func(string, json.RawMessage) (any, error) {
+		return &ctypes.ResultHealth{}, nil
+	})
+	srvAddr := srv.Listener.Addr().String()
+	_, port, err := net.SplitHostPort(srvAddr)
+	require.NoError(t, err)
+
+	// resolves the made up host to the test server, as a split-horizon DNS would
+	dialed := make(chan string, 1)
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed <- addr
+		return (&net.Dialer{}).DialContext(ctx, network, srvAddr)
+	}
+	client, err := NewRemoteWithOptions("core.internal", port, WithDialContext(dial))
+	require.NoError(t, err)
+
+	require.NoError(t, client.Ping(ctx))
+	assert.Equal(t, net.JoinHostPort("core.internal", port), <-dialed)
+	assert.Equal(t, "tcp://"+net.JoinHostPort("core.internal", port), client.Endpoint())
+
+	_, err = NewRemoteWithOptions("core.internal", port, WithDialContext(dial), WithHTTPClient(&http.Client{}))
+	assert.Error(t, err)
+}
+
+// recordingDialer is a proxy.Dialer recording the addresses it dials, tunneling the connections
+// to the given address.
+type recordingDialer struct {
+	to     string
+	dialed chan string
+}
+
+func (d *recordingDialer) Dial(network, addr string) (net.Conn, error) {
+	d.dialed <- addr
+	return net.Dial(network, d.to)
+}
+
+func TestRemoteClient_ProxyDialer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	var wsCalls, httpCalls atomic.Int32
+	handler := func(calls *atomic.Int32) rpcHandler {
+		return func(string, json.RawMessage) (any, error) {
+			calls.Add(1)
+			return &ctypes.ResultBroadcastTx{}, nil
+		}
+	}
+	wsHandler, _ := newRPCWSHandler(handler(&wsCalls))
+	mux := http.NewServeMux()
+	mux.Handle("/websocket", wsHandler)
+	mux.Handle("/", newRPCHTTPHandler(handler(&httpCalls)))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	srvAddr := srv.Listener.Addr().String()
+	_, port, err := net.SplitHostPort(srvAddr)
+	require.NoError(t, err)
+
+	// only the proxy can reach the made up host
+	dialer := &recordingDialer{to: srvAddr, dialed: make(chan string, 2)}
+	client, err := NewRemoteWithOptions("core.internal", port, WithProxyDialer(dialer), WithPreferWebsocket(true))
+	require.NoError(t, err)
+
+	// the reads go over the websocket and the writes over HTTP
+	require.NoError(t, client.Ping(ctx))
+	_, err = client.BroadcastTxSync(ctx, types.Tx("tx"))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, wsCalls.Load())
+	assert.EqualValues(t, 1, httpCalls.Load())
+	assert.Equal(t, net.JoinHostPort("core.internal", port), <-dialer.dialed)
+	assert.Equal(t, net.JoinHostPort("core.internal", port), <-dialer.dialed)
+
+	// the subscriptions are dialed through the proxy as well
+	events := make(chan ctypes.ResultEvent, 1)
+	events <- ctypes.ResultEvent{Data: types.EventDataNewBlock{Block: newHeightBlock(1)}}
+	subSrv := httptest.NewServer(newEventsWSHandler(handler(&wsCalls), events))
+	t.Cleanup(subSrv.Close)
+	_, subPort, err := net.SplitHostPort(subSrv.Listener.Addr().String())
+	require.NoError(t, err)
+	dialer = &recordingDialer{to: subSrv.Listener.Addr().String(), dialed: make(chan string, 1)}
+	client, err = NewRemoteWithOptions("core.internal", subPort, WithProxyDialer(dialer))
+	require.NoError(t, err)
+	require.NoError(t, client.Start())
+	t.Cleanup(func() {
+		require.NoError(t, client.Stop())
+	})
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+	sub, err := fetcher.SubscribeNewBlockEvent(ctx)
+	require.NoError(t, err)
+	block := <-sub
+	assert.EqualValues(t, 1, block.Height)
+	assert.Equal(t, net.JoinHostPort("core.internal", subPort), <-dialer.dialed)
+	require.NoError(t, fetcher.UnsubscribeNewBlockEvent(ctx))
+
+	_, err = NewRemoteWithOptions("core.internal", port, WithProxyDialer(nil))
+	assert.Error(t, err)
+	_, err = NewRemoteWithOptions("core.internal", port, WithProxyDialer(dialer), WithResolver(net.DefaultResolver))
+	assert.Error(t, err)
+	_, err = NewRemoteWithOptions("core.internal", port, WithProxyDialer(dialer), WithHTTPClient(&http.Client{}))
+	assert.Error(t, err)
+}
+
+// recordingTCPConn records how the client configures the TCP connection.
+type recordingTCPConn struct {
+	net.Conn
+
+	noDelay         []bool
+	keepAlive       []bool
+	keepAlivePeriod []time.Duration
+}
+
+func (c *recordingTCPConn) SetNoDelay(noDelay bool) error {
+	c.noDelay = append(c.noDelay, noDelay)
+	return nil
+}
+
+func (c *recordingTCPConn) SetKeepAlive(keepAlive bool) error {
+	c.keepAlive = append(c.keepAlive, keepAlive)
+	return nil
+}
+
+func (c *recordingTCPConn) SetKeepAlivePeriod(period time.Duration) error {
+	c.keepAlivePeriod = append(c.keepAlivePeriod, period)
+	return nil
+}
+
+func TestRemoteClient_TCPOptions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	srv := newRPCServer(t, func(string, json.RawMessage) (any, error) {
+		return &ctypes.ResultHealth{}, nil
+	})
+	dialed := make(chan *recordingTCPConn, 1)
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		rec := &recordingTCPConn{Conn: conn}
+		dialed <- rec
+		return rec, nil
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		client := newTestRemote(t, srv.URL, WithDialContext(dial))
+		require.NoError(t, client.Ping(ctx))
+		conn := <-dialed
+		assert.Empty(t, conn.noDelay)
+		assert.Empty(t, conn.keepAlive)
+		conn.Close()
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		client := newTestRemote(t, srv.URL,
+			WithDialContext(dial),
+			WithTCPNoDelay(false),
+			WithTCPKeepAlive(time.Second*5),
+		)
+		require.NoError(t, client.Ping(ctx))
+		conn := <-dialed
+		assert.Equal(t, []bool{false}, conn.noDelay)
+		assert.Equal(t, []bool{true}, conn.keepAlive)
+		assert.Equal(t, []time.Duration{time.Second * 5}, conn.keepAlivePeriod)
+		conn.Close()
+	})
+
+	t.Run("keepalive disabled", func(t *testing.T) {
+		client := newTestRemote(t, srv.URL, WithDialContext(dial), WithTCPKeepAlive(-1))
+		require.NoError(t, client.Ping(ctx))
+		conn := <-dialed
+		assert.Equal(t, []bool{true}, conn.noDelay)
+		assert.Equal(t, []bool{false}, conn.keepAlive)
+		assert.Empty(t, conn.keepAlivePeriod)
+		conn.Close()
+	})
+
+	_, err := NewRemoteWithOptions("localhost", "26657", WithTCPNoDelay(false), WithHTTPClient(&http.Client{}))
+	assert.Error(t, err)
+}
+
+// countingCodec passes the messages as they are, counting them.
+type countingCodec struct {
+	requests, responses atomic.Int32
+}
+
+func (c *countingCodec) EncodeRequest(req []byte) ([]byte, error) {
+	c.requests.Add(1)
+	return req, nil
+}
+
+func (c *countingCodec) DecodeResponse(resp []byte) ([]byte, error) {
+	c.responses.Add(1)
+	return resp, nil
+}
+
+func TestRemoteClient_Codec(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	srv := newRPCServer(t, func(string, json.RawMessage) (any, error) {
+		return &ctypes.ResultStatus{NodeInfo: p2p.DefaultNodeInfo{Network: "private"}}, nil
+	})
+
+	codec := &countingCodec{}
+	client := newTestRemote(t, srv.URL, WithCodec(codec))
+	status, err := client.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "private", status.NodeInfo.Network)
+	assert.EqualValues(t, 1, codec.requests.Load())
+	assert.EqualValues(t, 1, codec.responses.Load())
+
+	_, err = NewRemoteWithOptions("localhost", "26657", WithCodec(nil))
+	assert.Error(t, err)
+	_, err = NewRemoteWithOptions("localhost", "26657", WithCodec(codec), WithPreferWebsocket(true))
+	assert.Error(t, err)
+}
+
+func TestRemoteClient_RequestIDGenerator(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	// record the IDs of the requests as seen by Core
+	var (
+		idsLk sync.Mutex
+		ids   []string
+	)
+	handler := newRPCHTTPHandler(func(string, json.RawMessage) (any, error) {
+		return &ctypes.ResultStatus{NodeInfo: p2p.DefaultNodeInfo{Network: "private"}}, nil
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rpcReq, ok := readRPCRequest(r)
+		assert.True(t, ok)
+		idsLk.Lock()
+		ids = append(ids, string(rpcReq.ID))
+		idsLk.Unlock()
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	var next atomic.Int64
+	generator := func() any {
+		return fmt.Sprintf("gateway-%d", next.Add(1))
+	}
+	client := newTestRemote(t, srv.URL, WithRequestIDGenerator(generator))
+
+	const calls = 50
+	errGroup, ctx := errgroup.WithContext(ctx)
+	for i := 0; i < calls; i++ {
+		errGroup.Go(func() error {
+			status, err := client.Status(ctx)
+			if err == nil && status.NodeInfo.Network != "private" {
+				return fmt.Errorf("unexpected network %s", status.NodeInfo.Network)
+			}
+			return err
+		})
+	}
+	require.NoError(t, errGroup.Wait())
+
+	require.Len(t, ids, calls)
+	unique := make(map[string]bool, calls)
+	for _, id := range ids {
+		assert.Regexp(t, `^"gateway-\d+"$`, id)
+		unique[id] = true
+	}
+	assert.Len(t, unique, calls)
+
+	sequential := SequentialRequestIDs()
+	assert.EqualValues(t, 1, sequential())
+	assert.EqualValues(t, 2, sequential())
+}
+
+func TestRemoteClient_StopCancelsRequests(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	// the blocks are not served until the end of the test
+	var inFlight atomic.Int32
+	release := make(chan struct{})
+	blocked := func(method string, _ json.RawMessage) (any, error) {
+		if method != "block" {
+			return &ctypes.ResultHealth{}, nil
+		}
+		inFlight.Add(1)
+		<-release
+		return nil, errors.New("released")
+	}
+	wsHandler, stopWS := newRPCWSHandler(blocked)
+	t.Cleanup(stopWS)
+	mux := http.NewServeMux()
+	mux.Handle("/websocket", wsHandler)
+	mux.Handle("/", newRPCHTTPHandler(blocked))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() {
+		close(release)
+	})
+
+	client := newTestRemote(t, srv.URL)
+	require.NoError(t, client.Start())
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	const concurrency = 4
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := fetcher.GetBlockRange(ctx, 1, 100, concurrency)
+		errCh <- err
+	}()
+	require.Eventually(t, func() bool {
+		return inFlight.Load() == concurrency
+	}, time.Second, time.Millisecond*10)
+
+	start := time.Now()
+	require.NoError(t, client.Stop())
+	select {
+	case err := <-errCh:
+		assert.ErrorIs(t, err, ErrClientStopped)
+		assert.Less(t, time.Since(start), time.Second)
+	case <-ctx.Done():
+		require.NoError(t, ctx.Err())
+	}
+
+	// the requests after the stop fail right away
+	_, err = client.Health(ctx)
+	assert.ErrorIs(t, err, ErrClientStopped)
+}
+
+func TestRemoteClient_WireObserver(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	srv := newRPCServer(t, func(string, json.RawMessage) (any, error) {
+		return &ctypes.ResultStatus{NodeInfo: p2p.DefaultNodeInfo{Network: "private"}}, nil
+	})
+
+	var events []WireEvent
+	client := newTestRemote(t, srv.URL, WithWireObserver(func(event WireEvent) {
+		events = append(events, event)
+	}))
+	status, err := client.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "private", status.NodeInfo.Network)
+
+	require.Len(t, events, 1)
+	event := events[0]
+	assert.Equal(t, "status", event.Method)
+	assert.Equal(t, http.StatusOK, event.StatusCode)
+	assert.NoError(t, event.Err)
+	var req struct {
+		Method string `json:"method"`
+	}
+	require.NoError(t, json.Unmarshal(event.Request, &req))
+	assert.Equal(t, "status", req.Method)
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(event.Response, &resp))
+	assert.Contains(t, string(resp.Result), `"network":"private"`)
+
+	// the credentials never leak
+	transport := &wireTransport{
+		base: srv.Client().Transport,
+		observer: func(e WireEvent) {
+			event = e
+		},
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, bytes.NewReader(event.Request))
+	require.NoError(t, err)
+	httpReq.SetBasicAuth("user", "secret")
+	httpResp, err := transport.RoundTrip(httpReq)
+	require.NoError(t, err)
+	require.NoError(t, httpResp.Body.Close())
+	assert.Equal(t, "REDACTED", event.Header.Get("Authorization"))
+	assert.NotEmpty(t, httpReq.Header.Get("Authorization"))
+
+	// every retry is reported
+	var requests atomic.Int32
+	retrySrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		srv.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(retrySrv.Close)
+	events = nil
+	client = newTestRemote(t, retrySrv.URL,
+		WithBackoff[ClientParameters](&fixedBackoff{interval: time.Millisecond}),
+		WithWireObserver(func(event WireEvent) {
+			events = append(events, event)
+		}),
+	)
+	_, err = client.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, http.StatusServiceUnavailable, events[0].StatusCode)
+	assert.Equal(t, http.StatusOK, events[1].StatusCode)
+	assert.Equal(t, events[0].Request, events[1].Request)
+}
+
+func TestRemoteClient_PreferWebsocket(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	var wsCalls, httpCalls atomic.Int32
+	handler := func(calls *atomic.Int32) rpcHandler {
+		return func(method string, _ json.RawMessage) (any, error) {
+			calls.Add(1)
+			if method == "tx" {
+				return nil, fmt.Errorf("tx not found")
+			}
+			return &ctypes.ResultHealth{}, nil
+		}
+	}
+	wsHandler, stopWS := newRPCWSHandler(handler(&wsCalls))
+	mux := http.NewServeMux()
+	mux.Handle("/websocket", wsHandler)
+	mux.Handle("/", newRPCHTTPHandler(handler(&httpCalls)))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	client := newTestRemote(t, srv.URL, WithPreferWebsocket(true))
+	require.NoError(t, client.Ping(ctx))
+	require.NoError(t, client.Ping(ctx))
+	assert.EqualValues(t, 2, wsCalls.Load())
+	assert.Zero(t, httpCalls.Load())
+
+	// errors surface the same way as over HTTP
+	_, wsErr := client.Tx(ctx, []byte{0x1}, false)
+	require.Error(t, wsErr)
+	_, httpErr := newTestRemote(t, srv.URL).Tx(ctx, []byte{0x1}, false)
+	assert.Equal(t, httpErr.Error(), wsErr.Error())
+	httpCalls.Store(0)
+
+	// falls back to HTTP once the websocket goes down
+	stopWS()
+	require.NoError(t, client.Ping(ctx))
+	require.NoError(t, client.Ping(ctx))
+	assert.EqualValues(t, 3, wsCalls.Load())
+	assert.EqualValues(t, 2, httpCalls.Load())
+}
+
+func TestRemoteClient_PreferWebsocket_ConcurrentDial(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	var wsCalls, httpCalls, dials atomic.Int32
+	handler := func(calls *atomic.Int32) rpcHandler {
+		return func(string, json.RawMessage) (any, error) {
+			calls.Add(1)
+			return &ctypes.ResultHealth{}, nil
+		}
+	}
+	wsHandler, _ := newRPCWSHandler(handler(&wsCalls))
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/websocket", func(w http.ResponseWriter, r *http.Request) {
+		dials.Add(1)
+		<-release
+		wsHandler.ServeHTTP(w, r)
+	})
+	mux.Handle("/", newRPCHTTPHandler(handler(&httpCalls)))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	client := newTestRemote(t, srv.URL, WithPreferWebsocket(true))
+	errGroup, errCtx := errgroup.WithContext(ctx)
+	for i := 0; i < 5; i++ {
+		errGroup.Go(func() error {
+			return client.Ping(errCtx)
+		})
+	}
+	// the read whose context is done while dialing fails, without failing the dial for the others
+	shortCtx, shortCancel := context.WithTimeout(ctx, time.Millisecond*100)
+	defer shortCancel()
+	assert.Error(t, client.Ping(shortCtx))
+
+	close(release)
+	require.NoError(t, errGroup.Wait())
+	assert.EqualValues(t, 1, dials.Load())
+	assert.EqualValues(t, 5, wsCalls.Load())
+	assert.Zero(t, httpCalls.Load())
+}
+
+func TestRemoteClient_Subscribe_LargeEvent(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	t.Cleanup(cancel)
+
+	_, _, cfg := StartTestKVApp(ctx, t)
+	endpoint, err := GetEndpoint(cfg)
+	require.NoError(t, err)
+	ip, port, err := net.SplitHostPort(endpoint)
+	require.NoError(t, err)
+	client, err := NewRemote(ip, port)
+	require.NoError(t, err)
+	require.NoError(t, client.Start())
+	t.Cleanup(func() {
+		require.NoError(t, client.Stop())
+	})
+
+	// the capacity is large enough for no event to be dropped while this test is reading
+	events, err := client.Subscribe(ctx, newBlockSubscriber, newBlockEventQuery, 1000)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, client.Unsubscribe(ctx, newBlockSubscriber, newBlockEventQuery))
+	})
+
+	// the subscription is only confirmed by the first event
+	select {
+	case <-events:
+	case <-ctx.Done():
+		t.Fatal("no block event delivered")
+	}
+
+	// way above the 32KiB read limit websocket clients commonly default to
+	tx := types.Tx("large=" + strings.Repeat("a", 600<<10))
+	_, err = client.BroadcastTxSync(ctx, tx)
+	require.NoError(t, err)
+
+	for {
+		select {
+		case event := <-events:
+			block := event.Data.(types.EventDataNewBlock).Block
+			if len(block.Txs) == 0 {
+				continue
+			}
+			assert.Equal(t, tx, block.Txs[0])
+			return
+		case <-ctx.Done():
+			t.Fatal("large block event not delivered")
+		}
+	}
+}
+
+func TestRemoteClient_Subscribe_ReadLimit(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	t.Cleanup(cancel)
+
+	_, _, cfg := StartTestKVApp(ctx, t)
+	endpoint, err := GetEndpoint(cfg)
+	require.NoError(t, err)
+	ip, port, err := net.SplitHostPort(endpoint)
+	require.NoError(t, err)
+	// fits the events of empty blocks, but not of the large one
+	client, err := NewRemoteWithOptions(ip, port, WithWSReadLimit[ClientParameters](64<<10))
+	require.NoError(t, err)
+	require.NoError(t, client.Start())
+	t.Cleanup(func() {
+		require.NoError(t, client.Stop())
+	})
+
+	events, err := client.Subscribe(ctx, newBlockSubscriber, newBlockEventQuery, 1000)
+	require.NoError(t, err)
+
+	tx := types.Tx("large=" + strings.Repeat("a", 600<<10))
+	_, err = client.BroadcastTxSync(ctx, tx)
+	require.NoError(t, err)
+
+	for open := true; open; {
+		select {
+		case event, ok := <-events:
+			if ok {
+				require.Empty(t, event.Data.(types.EventDataNewBlock).Block.Txs)
+			}
+			open = ok
+		case <-ctx.Done():
+			t.Fatal("subscription not ended by the large block event")
+		}
+	}
+
+	var errLimit *ErrWSReadLimit
+	_, err = client.Subscribe(ctx, newBlockSubscriber, newBlockEventQuery)
+	require.ErrorAs(t, err, &errLimit)
+	assert.EqualValues(t, 64<<10, errLimit.Limit)
+	// reported once, so that subscribing again can be attempted
+	_, err = client.Subscribe(ctx, newBlockSubscriber, newBlockEventQuery)
+	require.NoError(t, err)
+}
+
+func TestRemoteClient_Versions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	_, client := StartTestCoreWithApp(t)
+	versions, err := client.Versions(ctx)
+	require.NoError(t, err)
+
+	info, err := client.ABCIInfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, info.Response.AppVersion, versions.App)
+	assert.Equal(t, version.BlockProtocol, versions.Block)
+	assert.Equal(t, version.TMCoreSemVer, versions.Tendermint)
+}
+
+func TestRemoteClient_AppInfo(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	t.Cleanup(cancel)
+
+	_, client := StartTestCoreWithApp(t)
+	versions, err := client.Versions(ctx)
+	require.NoError(t, err)
+
+	info, err := client.AppInfo(ctx)
+	require.NoError(t, err)
+	assert.NotEmpty(t, info.Name)
+	assert.Equal(t, versions.App, info.AppVersion)
+}
+
+func TestRemoteClient_MutualTLS(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	certFile, keyFile, cert := newClientCertificate(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert)
+
+	handler := func(string, json.RawMessage) (any, error) {
+		return &ctypes.ResultHealth{}, nil
+	}
+	events := make(chan ctypes.ResultEvent, 1)
+	events <- ctypes.ResultEvent{Data: types.EventDataNewBlock{Block: newHeightBlock(1)}}
+	mux := http.NewServeMux()
+	mux.Handle("/websocket", newEventsWSHandler(handler, events))
+	mux.Handle("/", newRPCHTTPHandler(handler))
+	srv := httptest.NewUnstartedServer(mux)
+	srv.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+		MinVersion: tls.VersionTLS12,
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(srv.Certificate())
+	tlsConfig := &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}
+
+	t.Run("with client certificate", func(t *testing.T) {
+		client := newTestRemote(t, srv.URL, WithTLS(tlsConfig), WithClientCertificate(certFile, keyFile))
+		require.NoError(t, client.Ping(ctx))
+	})
+
+	t.Run("without client certificate", func(t *testing.T) {
+		client := newTestRemote(t, srv.URL, WithTLS(tlsConfig), WithBackoff[ClientParameters](&fixedBackoff{}))
+		require.Error(t, client.Ping(ctx))
+		require.NoError(t, client.Start())
+		t.Cleanup(func() {
+			require.NoError(t, client.Stop())
+		})
+		_, err := client.Subscribe(ctx, newBlockSubscriber, newBlockEventQuery)
+		require.Error(t, err)
+	})
+
+	t.Run("subscription with client certificate", func(t *testing.T) {
+		client := newTestRemote(t, srv.URL, WithTLS(tlsConfig), WithClientCertificate(certFile, keyFile))
+		require.NoError(t, client.Start())
+		t.Cleanup(func() {
+			require.NoError(t, client.Stop())
+		})
+		sub, err := client.Subscribe(ctx, newBlockSubscriber, newBlockEventQuery)
+		require.NoError(t, err)
+		event := <-sub
+		assert.EqualValues(t, 1, event.Data.(types.EventDataNewBlock).Block.Height)
+	})
+
+	t.Run("bad client certificate", func(t *testing.T) {
+		_, err := NewRemoteWithOptions("127.0.0.1", "26657",
+			WithTLS(tlsConfig), WithClientCertificate(keyFile, certFile))
+		require.ErrorContains(t, err, "loading client certificate")
+	})
+}
+
+// newClientCertificate creates a self-signed client certificate, returning the paths to its
+// PEM encoded certificate and key files.
+func newClientCertificate(t *testing.T) (certFile, keyFile string, cert *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "celestia-node"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err = x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	require.NoError(t, err)
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	require.NoError(t, err)
+	return certFile, keyFile, cert
+}
+
+func TestRemoteClient_Tx(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	t.Cleanup(cancel)
+
+	_, _, cfg := StartTestKVApp(ctx, t)
+	endpoint, err := GetEndpoint(cfg)
+	require.NoError(t, err)
+	ip, port, err := net.SplitHostPort(endpoint)
+	require.NoError(t, err)
+	client, err := NewRemote(ip, port)
+	require.NoError(t, err)
+
+	res, err := client.BroadcastTxCommit(ctx, types.Tx("celestia=node"))
+	require.NoError(t, err)
+	require.Zero(t, res.DeliverTx.Code)
+
+	tx, err := client.Tx(ctx, res.Hash, true)
+	require.NoError(t, err)
+	assert.Equal(t, res.Height, tx.Height)
+	assert.Equal(t, types.Tx("celestia=node"), tx.Tx)
+	require.NoError(t, tx.Proof.Validate())
+
+	unknown := types.Tx("unknown").Hash()
+	_, err = client.Tx(ctx, unknown, false)
+	var errNotFound *ErrTxNotFound
+	require.ErrorAs(t, err, &errNotFound)
+	assert.Equal(t, unknown, errNotFound.Hash)
+}
+
+func TestRemoteClient_SearchTxs(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	t.Cleanup(cancel)
+
+	_, _, cfg := StartTestKVApp(ctx, t)
+	endpoint, err := GetEndpoint(cfg)
+	require.NoError(t, err)
+	ip, port, err := net.SplitHostPort(endpoint)
+	require.NoError(t, err)
+	client, err := NewRemote(ip, port)
+	require.NoError(t, err)
+
+	// the KV app tags every tx with its key
+	hashes := make(map[string]int64)
+	for i := 0; i < 3; i++ {
+		res, err := client.BroadcastTxCommit(ctx, types.Tx(fmt.Sprintf("search=%d", i)))
+		require.NoError(t, err)
+		hashes[res.Hash.String()] = res.Height
+	}
+	_, err = client.BroadcastTxCommit(ctx, types.Tx("other=1"))
+	require.NoError(t, err)
+
+	const query = "app.key='search'"
+	found := make(map[string]int64)
+	for page := 1; page <= 2; page++ {
+		res, err := client.SearchTxs(ctx, query, page, 2)
+		require.NoError(t, err)
+		assert.Equal(t, 3, res.TotalCount)
+		for _, tx := range res.Txs {
+			found[tx.Hash.String()] = tx.Height
+		}
+	}
+	assert.Equal(t, hashes, found)
+
+	_, err = client.SearchTxs(ctx, "app.key=", 1, 2)
+	require.ErrorContains(t, err, "invalid tx search query")
+}
+
+func TestRemoteClient_ConnState(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	handler := newRPCHTTPHandler(func(string, json.RawMessage) (any, error) {
+		return struct{}{}, nil
+	})
+	serve := func(lis net.Listener) *http.Server {
+		srv := &http.Server{Handler: handler} //nolint:gosec
+		go func() {
+			_ = srv.Serve(lis)
+		}()
+		return srv
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := lis.Addr().String()
+	srv := serve(lis)
+
+	var (
+		statesLk     sync.Mutex
+		states       []ConnState
+		reconnecting = make(chan struct{}, 1)
+		client       Client
+	)
+	client = newTestRemote(t, "http://"+addr,
+		WithBackoff[ClientParameters](&fixedBackoff{interval: time.Millisecond * 100}),
+		WithConnStateObserver(func(state ConnState) {
+			// the observer can query the client
+			assert.Equal(t, state, client.ConnState())
+			statesLk.Lock()
+			defer statesLk.Unlock()
+			states = append(states, state)
+			if state == ConnStateReconnecting {
+				reconnecting <- struct{}{}
+			}
+		}),
+	)
+	assert.Equal(t, ConnStateIdle, client.ConnState())
+
+	require.NoError(t, client.Ping(ctx))
+	assert.Equal(t, ConnStateConnected, client.ConnState())
+
+	// the endpoint is killed and recovers while the request is retried
+	require.NoError(t, srv.Close())
+	recovered := make(chan *http.Server, 1)
+	go func() {
+		<-reconnecting
+		lis, err := net.Listen("tcp", addr)
+		if !assert.NoError(t, err) {
+			return
+		}
+		recovered <- serve(lis)
+	}()
+	require.NoError(t, client.Ping(ctx))
+	assert.Equal(t, ConnStateConnected, client.ConnState())
+
+	// the endpoint is killed for good
+	require.NoError(t, (<-recovered).Close())
+	require.Error(t, client.Ping(ctx))
+	assert.Equal(t, ConnStateDown, client.ConnState())
+
+	statesLk.Lock()
+	defer statesLk.Unlock()
+	assert.Equal(t, []ConnState{
+		ConnStateConnected,
+		ConnStateReconnecting,
+		ConnStateConnected,
+		ConnStateReconnecting,
+		ConnStateDown,
+	}, states)
+}
+
+func TestConnStateTracker_Order(t *testing.T) {
+	var (
+		observedLk sync.Mutex
+		observed   []ConnState
+		tracker    *connStateTracker
+	)
+	tracker = &connStateTracker{observer: func(state ConnState) {
+		// slow enough for the concurrent transitions to pile up
+		time.Sleep(time.Microsecond * 100)
+		observedLk.Lock()
+		defer observedLk.Unlock()
+		observed = append(observed, state)
+	}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		state := ConnStateConnected
+		if i%2 == 1 {
+			state = ConnStateDown
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tracker.set(state)
+		}()
+	}
+	wg.Wait()
+
+	// the last transition observed is the state of the client, and none is repeated
+	require.NotEmpty(t, observed)
+	assert.Equal(t, tracker.get(), observed[len(observed)-1])
+	for i := 1; i < len(observed); i++ {
+		assert.NotEqual(t, observed[i-1], observed[i])
+	}
+}
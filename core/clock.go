@@ -0,0 +1,87 @@
+package core
+
+import "time"
+
+// Clock provides the current time and timers to the time-dependent code of the BlockFetcher, such
+// as the Tip cache, the Backoff waits and the Heartbeat, so that tests can control the time.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After waits for the duration to elapse and then sends the current time on the returned
+	// channel.
+	After(d time.Duration) <-chan time.Time
+	// NewTimer creates a Timer sending the current time on its channel once the duration elapses.
+	NewTimer(d time.Duration) Timer
+	// NewTicker creates a Ticker sending the current time on its channel every period.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is the timer of a Clock, like time.Timer.
+type Timer interface {
+	// C returns the channel the time is sent on.
+	C() <-chan time.Time
+	// Stop prevents the Timer from firing, returning false if it already fired or was stopped.
+	Stop() bool
+	// Reset changes the Timer to fire after the duration, returning true if it was active.
+	Reset(d time.Duration) bool
+}
+
+// Ticker is the ticker of a Clock, like time.Ticker.
+type Ticker interface {
+	// C returns the channel the ticks are sent on.
+	C() <-chan time.Time
+	// Stop turns off the Ticker.
+	Stop()
+}
+
+// realClock is the Clock of the time package.
+type realClock struct{}
+
+// RealClock returns the Clock backed by the system time, used unless configured otherwise.
+func RealClock() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTimer struct {
+	timer *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t realTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+func (t realTimer) Reset(d time.Duration) bool {
+	return t.timer.Reset(d)
+}
+
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t realTicker) Stop() {
+	t.ticker.Stop()
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Codec translates the JSON-RPC messages between the Tendermint encoding the client speaks and
+// the encoding of the Core endpoint, e.g. for a Core variant encoding some of the types slightly
+// differently.
+// NOTE: It applies to the requests made over HTTP, but not to the websocket subscriptions.
+type Codec interface {
+	// EncodeRequest translates the Tendermint encoded JSON-RPC request into the encoding of Core.
+	EncodeRequest(req []byte) ([]byte, error)
+	// DecodeResponse translates the JSON-RPC response encoded by Core into the Tendermint encoding.
+	DecodeResponse(resp []byte) ([]byte, error)
+}
+
+// TendermintCodec is the default Codec for Core speaking the Tendermint encoding, passing the
+// messages as they are.
+type TendermintCodec struct{}
+
+func (TendermintCodec) EncodeRequest(req []byte) ([]byte, error) {
+	return req, nil
+}
+
+func (TendermintCodec) DecodeResponse(resp []byte) ([]byte, error) {
+	return resp, nil
+}
+
+// codecTransport translates the bodies of the requests to Core and of its responses with the Codec.
+type codecTransport struct {
+	base  http.RoundTripper
+	codec Codec
+}
+
+func (t *codecTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body, err = t.codec.EncodeRequest(body)
+		if err != nil {
+			return nil, fmt.Errorf("core: encoding request: %w", err)
+		}
+
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	body, err = t.codec.DecodeResponse(body)
+	if err != nil {
+		return nil, fmt.Errorf("core: decoding response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	retryhttp "github.com/hashicorp/go-retryablehttp"
+)
+
+// ConnState is the state of the connection of the Client to Core, as observed by its requests.
+type ConnState int
+
+const (
+	// ConnStateIdle is the state of the client before any of its requests completed.
+	ConnStateIdle ConnState = iota
+	// ConnStateConnected is the state of the client once its last request reached Core.
+	ConnStateConnected
+	// ConnStateReconnecting is the state of the client while retrying a request that failed to
+	// reach Core.
+	ConnStateReconnecting
+	// ConnStateDown is the state of the client once its last request failed to reach Core,
+	// including the retries.
+	ConnStateDown
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case ConnStateIdle:
+		return "idle"
+	case ConnStateConnected:
+		return "connected"
+	case ConnStateReconnecting:
+		return "reconnecting"
+	case ConnStateDown:
+		return "down"
+	default:
+		return "unknown"
+	}
+}
+
+// connStateTracker tracks the ConnState of the client, notifying the observer of the transitions.
+// The observer is called without the lock of the state held, so that it can query the state of the
+// client, but under the lock of the notifications, so that it sees the transitions in order.
+type connStateTracker struct {
+	observer func(ConnState)
+	// serializes the transitions along with their notifications
+	notifyLk sync.Mutex
+
+	lk    sync.Mutex
+	state ConnState
+}
+
+func (t *connStateTracker) get() ConnState {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	return t.state
+}
+
+func (t *connStateTracker) set(state ConnState) {
+	t.notifyLk.Lock()
+	defer t.notifyLk.Unlock()
+
+	t.lk.Lock()
+	if t.state == state {
+		t.lk.Unlock()
+		return
+	}
+	t.state = state
+	t.lk.Unlock()
+
+	if t.observer != nil {
+		t.observer(state)
+	}
+}
+
+// checkRetry is the retry policy of the requests to Core, marking the client reconnecting while
+// retrying the requests that failed to reach Core.
+func (t *connStateTracker) checkRetry(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	retry, checkErr := retryhttp.DefaultRetryPolicy(ctx, resp, err)
+	if retry && err != nil {
+		t.set(ConnStateReconnecting)
+	}
+	return retry, checkErr
+}
+
+// connStateTransport updates the ConnState of the client with the outcome of every request.
+type connStateTransport struct {
+	base  http.RoundTripper
+	state *connStateTracker
+}
+
+func (t *connStateTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	switch {
+	case err == nil:
+		t.state.set(ConnStateConnected)
+	case req.Context().Err() == nil:
+		t.state.set(ConnStateDown)
+	}
+	return resp, err
+}
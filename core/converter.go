@@ -0,0 +1,77 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Converter converts signed blocks fetched from Core into headers of type H, so that the same
+// fetching machinery can feed the construction of custom header types.
+type Converter[H any] interface {
+	Convert(ctx context.Context, sb *SignedBlock) (H, error)
+}
+
+// ConverterFunc is an adapter allowing to use an ordinary function as a Converter.
+type ConverterFunc[H any] func(ctx context.Context, sb *SignedBlock) (H, error)
+
+func (fn ConverterFunc[H]) Convert(ctx context.Context, sb *SignedBlock) (H, error) {
+	return fn(ctx, sb)
+}
+
+// GetConverted queries Core for the verified block at the given height and converts it with
+// the given Converter. A nil height requests the latest block.
+func GetConverted[H any](ctx context.Context, f *BlockFetcher, conv Converter[H], height *int64) (H, error) {
+	var zero H
+	sb, err := f.GetVerifiedBlock(ctx, height)
+	if err != nil {
+		return zero, err
+	}
+	return conv.Convert(ctx, sb)
+}
+
+// GetConvertedRange queries Core for the verified blocks of the contiguous range of heights
+// [from:to] and converts them with the given Converter, returning the results in height order.
+// The blocks are fetched, their Commits verified and converted across up to `parallelism`
+// goroutines, as verifying the signatures is CPU-bound and dominates building many headers.
+func GetConvertedRange[H any](
+	ctx context.Context,
+	f *BlockFetcher,
+	conv Converter[H],
+	from, to int64,
+	parallelism int,
+) ([]H, error) {
+	if err := validateHeight(&from); err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, fmt.Errorf("core/fetcher: invalid range: from %d is above to %d", from, to)
+	}
+	if parallelism <= 0 {
+		return nil, fmt.Errorf("core/fetcher: invalid parallelism: %d", parallelism)
+	}
+
+	// every goroutine writes its own slot, so the order is kept without synchronization
+	converted := make([]H, to-from+1)
+	errGroup, ctx := errgroup.WithContext(ctx)
+	errGroup.SetLimit(parallelism)
+	for i := range converted {
+		i, height := i, from+int64(i)
+		errGroup.Go(func() error {
+			sb, err := f.GetVerifiedBlock(ctx, &height)
+			if err != nil {
+				return err
+			}
+			converted[i], err = conv.Convert(ctx, sb)
+			if err != nil {
+				return fmt.Errorf("core/fetcher: converting block at height %d: %w", height, err)
+			}
+			return nil
+		})
+	}
+	if err := errGroup.Wait(); err != nil {
+		return nil, err
+	}
+	return converted, nil
+}
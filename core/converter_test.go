@@ -0,0 +1,146 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+func TestGetConverted(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	const chainID = "private"
+	valSet, vals := RandValidatorSet(3, 1)
+	block := newTestBlock(chainID, 1, valSet)
+	voteSet := tmtypes.NewVoteSet(chainID, block.Height, 0, tmproto.PrecommitType, valSet)
+	sb, err := MakeSignedBlock(block, voteSet, valSet, vals, time.Now())
+	require.NoError(t, err)
+
+	client := &mockClient{
+		block: func(context.Context, *int64) (*ctypes.ResultBlock, error) {
+			return &ctypes.ResultBlock{Block: sb.Block}, nil
+		},
+		commit: func(context.Context, *int64) (*ctypes.ResultCommit, error) {
+			return &ctypes.ResultCommit{SignedHeader: tmtypes.SignedHeader{Header: &sb.Header, Commit: sb.Commit}}, nil
+		},
+		validators: func(context.Context, *int64, *int, *int) (*ctypes.ResultValidators, error) {
+			return &ctypes.ResultValidators{Validators: valSet.Validators, Total: valSet.Size()}, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	// a trivial header type only carrying the height and the hash
+	type header struct {
+		height int64
+		hash   []byte
+	}
+	var received *SignedBlock
+	conv := ConverterFunc[header](func(_ context.Context, sb *SignedBlock) (header, error) {
+		received = sb
+		return header{height: sb.Height, hash: sb.Hash()}, nil
+	})
+
+	h, err := GetConverted[header](ctx, fetcher, conv, nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, h.height)
+	assert.EqualValues(t, block.Hash(), h.hash)
+
+	require.NotNil(t, received)
+	require.NoError(t, received.Verify())
+	assert.Equal(t, sb.Commit, received.Commit)
+}
+
+// newSignedChainClient returns a mockClient serving the given chain of signed blocks.
+func newSignedChainClient(chain []*SignedBlock) *mockClient {
+	byHeight := func(height *int64) *SignedBlock {
+		return chain[*height-chain[0].Height]
+	}
+	return &mockClient{
+		block: func(_ context.Context, height *int64) (*ctypes.ResultBlock, error) {
+			return &ctypes.ResultBlock{Block: byHeight(height).Block}, nil
+		},
+		commit: func(_ context.Context, height *int64) (*ctypes.ResultCommit, error) {
+			sb := byHeight(height)
+			return &ctypes.ResultCommit{SignedHeader: tmtypes.SignedHeader{Header: &sb.Header, Commit: sb.Commit}}, nil
+		},
+		validators: func(_ context.Context, height *int64, _, _ *int) (*ctypes.ResultValidators, error) {
+			valSet := byHeight(height).ValidatorSet
+			return &ctypes.ResultValidators{Validators: valSet.Validators, Total: valSet.Size()}, nil
+		},
+		blockchainInfo: func(_ context.Context, minHeight, maxHeight int64) (*ctypes.ResultBlockchainInfo, error) {
+			// as Core, serve the metas it has from the highest down
+			last := chain[len(chain)-1].Height
+			if maxHeight > last {
+				maxHeight = last
+			}
+			res := &ctypes.ResultBlockchainInfo{LastHeight: last}
+			for height := maxHeight; height >= minHeight; height-- {
+				res.BlockMetas = append(res.BlockMetas, tmtypes.NewBlockMeta(byHeight(&height).Block, nil))
+			}
+			return res, nil
+		},
+	}
+}
+
+func TestGetConvertedRange(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	valSet, vals := RandValidatorSet(3, 1)
+	chain, err := MakeSignedBlockChain(1, 20, valSet, vals)
+	require.NoError(t, err)
+	fetcher, err := NewBlockFetcher(newSignedChainClient(chain))
+	require.NoError(t, err)
+
+	// the lower the height, the longer the conversion, so that the conversions finish out of order
+	conv := ConverterFunc[int64](func(_ context.Context, sb *SignedBlock) (int64, error) {
+		time.Sleep(time.Duration(21-sb.Height) * time.Millisecond)
+		return sb.Height, nil
+	})
+	heights, err := GetConvertedRange[int64](ctx, fetcher, conv, 1, 20, 8)
+	require.NoError(t, err)
+	require.Len(t, heights, 20)
+	for i, height := range heights {
+		assert.EqualValues(t, i+1, height)
+	}
+
+	// a block with a broken commit fails the range
+	broken := *chain[9]
+	broken.Commit = chain[8].Commit
+	chain[9] = &broken
+	_, err = GetConvertedRange[int64](ctx, fetcher, conv, 1, 20, 8)
+	var errVerify *ErrCommitVerification
+	require.ErrorAs(t, err, &errVerify)
+	assert.EqualValues(t, 10, errVerify.Height)
+}
+
+func BenchmarkGetConvertedRange(b *testing.B) {
+	ctx := context.Background()
+	// many validators, so that verifying the signatures dominates
+	valSet, vals := RandValidatorSet(100, 1)
+	chain, err := MakeSignedBlockChain(1, 32, valSet, vals)
+	require.NoError(b, err)
+	fetcher, err := NewBlockFetcher(newSignedChainClient(chain))
+	require.NoError(b, err)
+	conv := ConverterFunc[int64](func(_ context.Context, sb *SignedBlock) (int64, error) {
+		return sb.Height, nil
+	})
+
+	for _, parallelism := range []int{1, 4, 8} {
+		b.Run(fmt.Sprintf("parallelism=%d", parallelism), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, err := GetConvertedRange[int64](ctx, fetcher, conv, 1, int64(len(chain)), parallelism)
+				require.NoError(b, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	"github.com/tendermint/tendermint/types"
+
+	"github.com/celestiaorg/celestia-app/pkg/da"
+	appshares "github.com/celestiaorg/celestia-app/pkg/shares"
+)
+
+// ErrDataHashMismatch is returned when the data of a fetched block does not extend into the data
+// square committed to by the DataHash of its header, meaning the block data can't be trusted.
+type ErrDataHashMismatch struct {
+	Height   int64
+	Expected tmbytes.HexBytes
+	Computed tmbytes.HexBytes
+}
+
+func (e *ErrDataHashMismatch) Error() string {
+	return fmt.Sprintf("core/fetcher: data hash mismatch at height %d: header %X, computed %X",
+		e.Height, e.Expected, e.Computed)
+}
+
+// DataAvailabilityHeader computes the DataAvailabilityHeader of the block by extending its data
+// into the data square, the same way header.MakeExtendedHeader does, but without storing the
+// square. Its hash is the DataHash of a valid block.
+func DataAvailabilityHeader(block *types.Block) (*da.DataAvailabilityHeader, error) {
+	if len(block.Txs) == 0 {
+		dah := da.MinDataAvailabilityHeader()
+		return &dah, nil
+	}
+
+	shares, err := appshares.Split(block.Data, true)
+	if err != nil {
+		return nil, fmt.Errorf("core: splitting data of block at height %d: %w", block.Height, err)
+	}
+	eds, err := da.ExtendShares(block.Data.OriginalSquareSize, appshares.ToBytes(shares))
+	if err != nil {
+		return nil, fmt.Errorf("core: extending data of block at height %d: %w", block.Height, err)
+	}
+	dah := da.NewDataAvailabilityHeader(eds)
+	return &dah, nil
+}
+
+// verifyDataHash recomputes the DataAvailabilityHeader of the block and checks its hash against
+// the DataHash of the block header.
+func verifyDataHash(block *types.Block) error {
+	dah, err := DataAvailabilityHeader(block)
+	if err != nil {
+		return err
+	}
+	if computed := dah.Hash(); !bytes.Equal(computed, block.DataHash) {
+		return &ErrDataHashMismatch{Height: block.Height, Expected: block.DataHash, Computed: computed}
+	}
+	return nil
+}
+
+// emptyDataHash returns the DataHash of a block without transactions.
+func emptyDataHash() []byte {
+	dah := da.MinDataAvailabilityHeader()
+	return dah.Hash()
+}
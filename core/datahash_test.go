@@ -0,0 +1,59 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	mdutils "github.com/ipfs/go-merkledag/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/rand"
+	"github.com/tendermint/tendermint/types"
+
+	"github.com/celestiaorg/celestia-app/pkg/da"
+	appshares "github.com/celestiaorg/celestia-app/pkg/shares"
+
+	"github.com/celestiaorg/celestia-node/share"
+)
+
+func TestDataAvailabilityHeader(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	t.Run("empty block", func(t *testing.T) {
+		valSet, vals := RandValidatorSet(1, 1)
+		chain, err := MakeSignedBlockChain(1, 1, valSet, vals)
+		require.NoError(t, err)
+
+		dah, err := DataAvailabilityHeader(chain[0].Block)
+		require.NoError(t, err)
+		assert.EqualValues(t, chain[0].DataHash, dah.Hash())
+	})
+
+	t.Run("block with txs", func(t *testing.T) {
+		txs := make(types.Txs, 16)
+		for i := range txs {
+			txs[i] = rand.Bytes(512)
+		}
+		block := &types.Block{Data: types.Data{Txs: txs, OriginalSquareSize: 8}}
+		// commit to the data square as the block producer does, storing the square
+		shares, err := appshares.Split(block.Data, true)
+		require.NoError(t, err)
+		extended, err := share.AddShares(ctx, appshares.ToBytes(shares), mdutils.Bserv())
+		require.NoError(t, err)
+		expected := da.NewDataAvailabilityHeader(extended)
+		block.DataHash = expected.Hash()
+
+		dah, err := DataAvailabilityHeader(block)
+		require.NoError(t, err)
+		assert.EqualValues(t, block.DataHash, dah.Hash())
+		assert.Equal(t, expected.RowsRoots, dah.RowsRoots)
+		assert.Equal(t, expected.ColumnRoots, dah.ColumnRoots)
+	})
+
+	t.Run("malformed block", func(t *testing.T) {
+		block := &types.Block{Data: types.Data{Txs: types.Txs{rand.Bytes(32)}, OriginalSquareSize: 3}}
+		_, err := DataAvailabilityHeader(block)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/tendermint/tendermint/crypto/merkle"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	"github.com/tendermint/tendermint/pkg/consts"
+)
+
+// ErrDataCommitmentRange is returned when the range of blocks of a data commitment is invalid or
+// does not contain the requested height.
+type ErrDataCommitmentRange struct {
+	Height     int64
+	BeginBlock int64
+	EndBlock   int64
+	Reason     string
+}
+
+func (e *ErrDataCommitmentRange) Error() string {
+	return fmt.Sprintf("core/fetcher: invalid data commitment range [%d:%d] for height %d: %s",
+		e.BeginBlock, e.EndBlock, e.Height, e.Reason)
+}
+
+// DataRootProof proves the data root of the block at Height, i.e. its DataHash, is committed to by
+// the DataCommitment over the blocks in the range [BeginBlock:EndBlock].
+type DataRootProof struct {
+	Height     int64
+	BeginBlock int64
+	EndBlock   int64
+	DataRoot   tmbytes.HexBytes
+	// DataCommitment is the Merkle root of the data roots of the blocks in the range.
+	DataCommitment tmbytes.HexBytes
+	Proof          merkle.Proof
+}
+
+// Verify checks the Proof of the DataRoot against the DataCommitment.
+func (p *DataRootProof) Verify() error {
+	if p.Proof.Index != p.Height-p.BeginBlock || p.Proof.Total != p.EndBlock-p.BeginBlock+1 {
+		return fmt.Errorf("core: data root proof of index %d of %d, expected %d of %d",
+			p.Proof.Index, p.Proof.Total, p.Height-p.BeginBlock, p.EndBlock-p.BeginBlock+1)
+	}
+	return p.Proof.Verify(p.DataCommitment, p.DataRoot)
+}
+
+// GetDataRootProof computes the proof that the data root of the block at the given height is
+// committed to by the data commitment over the range [beginBlock:endBlock], as served by Core's
+// DataCommitment. The data roots of the range are fetched from the block metas, and the
+// commitment computed from them is checked against Core's. ErrDataCommitmentRange is returned
+// for an invalid range.
+func (f *BlockFetcher) GetDataRootProof(
+	ctx context.Context,
+	height, beginBlock, endBlock int64,
+) (*DataRootProof, error) {
+	if err := validateDataCommitmentRange(height, beginBlock, endBlock); err != nil {
+		return nil, err
+	}
+
+	metas, err := f.GetBlockMetas(ctx, beginBlock, endBlock)
+	if err != nil {
+		return nil, err
+	}
+	dataRoots := make([][]byte, len(metas))
+	for i, meta := range metas {
+		dataRoots[i] = meta.Header.DataHash
+	}
+	root, proofs := merkle.ProofsFromByteSlices(dataRoots)
+
+	res, err := f.client.DataCommitment(ctx, uint64(beginBlock), uint64(endBlock))
+	if err != nil {
+		return nil, fmt.Errorf("core/fetcher: getting data commitment [%d:%d]: %w", beginBlock, endBlock, err)
+	}
+	if !bytes.Equal(root, res.DataCommitment) {
+		return nil, fmt.Errorf("core/fetcher: data commitment [%d:%d] mismatch: Core %X, computed %X",
+			beginBlock, endBlock, res.DataCommitment, root)
+	}
+
+	return &DataRootProof{
+		Height:         height,
+		BeginBlock:     beginBlock,
+		EndBlock:       endBlock,
+		DataRoot:       dataRoots[height-beginBlock],
+		DataCommitment: root,
+		Proof:          *proofs[height-beginBlock],
+	}, nil
+}
+
+// validateDataCommitmentRange checks the range of a data commitment is valid for Core and contains
+// the given height.
+func validateDataCommitmentRange(height, beginBlock, endBlock int64) error {
+	var reason string
+	switch {
+	case beginBlock < 1:
+		reason = "begin block should be positive"
+	case beginBlock > endBlock:
+		reason = "end block is lower than begin block"
+	case endBlock-beginBlock+1 > int64(consts.DataCommitmentBlocksLimit):
+		reason = fmt.Sprintf("more than %d blocks", consts.DataCommitmentBlocksLimit)
+	case height < beginBlock || height > endBlock:
+		reason = "height out of range"
+	default:
+		return nil
+	}
+	return &ErrDataCommitmentRange{Height: height, BeginBlock: beginBlock, EndBlock: endBlock, Reason: reason}
+}
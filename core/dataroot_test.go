@@ -0,0 +1,63 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockFetcher_GetDataRootProof(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	t.Cleanup(cancel)
+
+	_, client := StartTestCoreWithApp(t)
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+	// the end block of a data commitment has to be indexed
+	for {
+		tip, err := fetcher.Tip(ctx)
+		require.NoError(t, err)
+		if tip > 5 {
+			break
+		}
+		time.Sleep(time.Millisecond * 50)
+	}
+
+	const begin, end = 1, 4
+	commitment, err := client.DataCommitment(ctx, begin, end)
+	require.NoError(t, err)
+	for height := int64(begin); height <= end; height++ {
+		proof, err := fetcher.GetDataRootProof(ctx, height, begin, end)
+		require.NoError(t, err)
+
+		block, err := fetcher.GetBlock(ctx, &height)
+		require.NoError(t, err)
+		assert.Equal(t, block.DataHash, proof.DataRoot)
+		assert.Equal(t, commitment.DataCommitment, proof.DataCommitment)
+		assert.NoError(t, proof.Verify())
+	}
+
+	// a proof of another height doesn't verify
+	proof, err := fetcher.GetDataRootProof(ctx, 2, begin, end)
+	require.NoError(t, err)
+	proof.Height = 3
+	assert.Error(t, proof.Verify())
+
+	invalid := map[string][3]int64{
+		"zero begin block":    {1, 0, 4},
+		"reversed range":      {2, 4, 1},
+		"height out of range": {5, 1, 4},
+		"too many blocks":     {1, 1, 1001},
+	}
+	for name, args := range invalid {
+		args := args
+		t.Run(name, func(t *testing.T) {
+			_, err := fetcher.GetDataRootProof(ctx, args[0], args[1], args[2])
+			var errRange *ErrDataCommitmentRange
+			assert.ErrorAs(t, err, &errRange)
+		})
+	}
+}
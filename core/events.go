@@ -0,0 +1,277 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	tmjson "github.com/tendermint/tendermint/libs/json"
+	"github.com/tendermint/tendermint/libs/service"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	rpctypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+)
+
+// wsEvents subscribes to the events of Core over a websocket connection of its own, in place of
+// the subscriptions of the Tendermint HTTP client. Unlike those, it dials the websocket only once
+// subscribing, as configured for the client, and waits for Core to confirm every subscription, so
+// that establishing it is bounded by the context and its rejection is reported.
+// There is a single subscription per query, whichever the subscriber, as Core identifies the
+// subscribers by their connection anyway. The subscriptions end, closing their channels, once
+// unsubscribed or once the connection breaks.
+type wsEvents struct {
+	service.BaseService
+
+	url       string
+	header    http.Header
+	dialer    *websocket.Dialer
+	readLimit int64
+
+	// serializes the changes of the subscriptions, so that they are confirmed in order
+	opLk sync.Mutex
+
+	lk   sync.Mutex
+	conn *wsConn
+	subs map[string]chan ctypes.ResultEvent
+	// the ErrWSReadLimit the last connection broke with, reported by the next subscription
+	errLimit error
+}
+
+// newWSEvents creates the wsEvents subscribing to the events of the Core endpoint at the given
+// address, as configured by the given params.
+func newWSEvents(ip, port string, params *ClientParameters) (*wsEvents, error) {
+	url, dialer, err := newWSDialer(ip, port, params)
+	if err != nil {
+		return nil, err
+	}
+	e := &wsEvents{
+		url:       url,
+		header:    http.Header{"User-Agent": []string{params.UserAgent}},
+		dialer:    dialer,
+		readLimit: params.WSReadLimit,
+		subs:      make(map[string]chan ctypes.ResultEvent),
+	}
+	e.BaseService = *service.NewBaseService(nil, "wsEvents", e)
+	return e, nil
+}
+
+// OnStop closes the connection, ending all the subscriptions.
+func (e *wsEvents) OnStop() {
+	e.lk.Lock()
+	defer e.lk.Unlock()
+	if e.conn != nil {
+		e.conn.close()
+		e.conn = nil
+	}
+	e.closeSubs()
+}
+
+// Subscribe subscribes to the events matching the query, returning them on a channel of the given
+// capacity, one by default. The events are dropped while the channel is full, as by the Tendermint
+// HTTP client. If the subscriptions ended as an event exceeded the WSReadLimit, it fails once with
+// ErrWSReadLimit.
+func (e *wsEvents) Subscribe(
+	ctx context.Context,
+	_, query string,
+	outCapacity ...int,
+) (<-chan ctypes.ResultEvent, error) {
+	if !e.IsRunning() {
+		return nil, ErrSubscriptionClosed
+	}
+	e.opLk.Lock()
+	defer e.opLk.Unlock()
+
+	e.lk.Lock()
+	_, ok := e.subs[query]
+	errLimit := e.errLimit
+	e.errLimit = nil
+	e.lk.Unlock()
+	if errLimit != nil {
+		return nil, errLimit
+	}
+	if ok {
+		return nil, fmt.Errorf("core: already subscribed to %q", query)
+	}
+
+	conn, err := e.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	outCap := 1
+	if len(outCapacity) > 0 && outCapacity[0] > 0 {
+		outCap = outCapacity[0]
+	}
+	out := make(chan ctypes.ResultEvent, outCap)
+	// registered before subscribing, so that the first events are not missed
+	e.lk.Lock()
+	if e.conn != conn {
+		e.lk.Unlock()
+		return nil, ErrSubscriptionClosed
+	}
+	e.subs[query] = out
+	e.lk.Unlock()
+
+	if err := e.call(ctx, conn, "subscribe", map[string]string{"query": query}); err != nil {
+		e.lk.Lock()
+		if e.subs[query] == out {
+			delete(e.subs, query)
+		}
+		e.lk.Unlock()
+		return nil, err
+	}
+	return out, nil
+}
+
+// Unsubscribe ends the subscription to the events matching the query, closing its channel.
+func (e *wsEvents) Unsubscribe(ctx context.Context, _, query string) error {
+	e.opLk.Lock()
+	defer e.opLk.Unlock()
+
+	e.lk.Lock()
+	out, ok := e.subs[query]
+	if ok {
+		delete(e.subs, query)
+		close(out)
+	}
+	conn := e.conn
+	e.lk.Unlock()
+	if !ok {
+		return fmt.Errorf("core: not subscribed to %q", query)
+	}
+	return e.callIfConnected(ctx, conn, "unsubscribe", map[string]string{"query": query})
+}
+
+// UnsubscribeAll ends all the subscriptions, closing their channels.
+func (e *wsEvents) UnsubscribeAll(ctx context.Context, _ string) error {
+	e.opLk.Lock()
+	defer e.opLk.Unlock()
+
+	e.lk.Lock()
+	e.closeSubs()
+	conn := e.conn
+	e.lk.Unlock()
+	return e.callIfConnected(ctx, conn, "unsubscribe_all", map[string]string{})
+}
+
+// connect returns the live websocket connection, dialing a new one if there is none.
+func (e *wsEvents) connect(ctx context.Context) (*wsConn, error) {
+	e.lk.Lock()
+	conn := e.conn
+	e.lk.Unlock()
+	if conn != nil {
+		return conn, nil
+	}
+
+	wsConn, _, err := e.dialer.DialContext(ctx, e.url, e.header) //nolint:bodyclose
+	if err != nil {
+		return nil, fmt.Errorf("core: dialing websocket: %w", err)
+	}
+	conn = newWSConn(wsConn, e.readLimit, e.dispatch)
+
+	e.lk.Lock()
+	defer e.lk.Unlock()
+	// the client may have been stopped while dialing
+	if !e.IsRunning() {
+		conn.close()
+		return nil, ErrSubscriptionClosed
+	}
+	e.conn = conn
+	go e.watch(conn)
+	return conn, nil
+}
+
+// watch ends all the subscriptions once the given connection breaks.
+func (e *wsEvents) watch(conn *wsConn) {
+	<-conn.done
+	e.lk.Lock()
+	defer e.lk.Unlock()
+	if e.conn != conn {
+		return
+	}
+	log.Warnw("subscription websocket broke", "err", conn.err)
+	var errLimit *ErrWSReadLimit
+	if errors.As(conn.err, &errLimit) {
+		e.errLimit = conn.err
+	}
+	e.conn = nil
+	e.closeSubs()
+}
+
+// closeSubs closes the channels of all the subscriptions and forgets them.
+// It must be called with the lock held.
+func (e *wsEvents) closeSubs() {
+	for query, out := range e.subs {
+		close(out)
+		delete(e.subs, query)
+	}
+}
+
+// call makes the JSON-RPC call over the given connection, returning the error Core responds with.
+func (e *wsEvents) call(ctx context.Context, conn *wsConn, method string, params any) error {
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	resp, err := conn.call(ctx, rpcRequest{Method: method, Params: rawParams})
+	switch {
+	case errors.Is(err, errWSDown):
+		return ErrSubscriptionClosed
+	case err != nil:
+		return err
+	}
+
+	var rpcResp rpctypes.RPCResponse
+	if err := json.Unmarshal(resp, &rpcResp); err != nil {
+		return fmt.Errorf("core: decoding %s response: %w", method, err)
+	}
+	if rpcResp.Error != nil {
+		return rpcResp.Error
+	}
+	return nil
+}
+
+// callIfConnected is call for the calls ending subscriptions, which end with the connection anyway.
+func (e *wsEvents) callIfConnected(ctx context.Context, conn *wsConn, method string, params any) error {
+	if conn == nil {
+		return nil
+	}
+	err := e.call(ctx, conn, method, params)
+	if errors.Is(err, ErrSubscriptionClosed) {
+		return nil
+	}
+	return err
+}
+
+// dispatch delivers the event carried by the given message to its subscription.
+func (e *wsEvents) dispatch(msg []byte) {
+	var resp rpctypes.RPCResponse
+	if err := json.Unmarshal(msg, &resp); err != nil {
+		log.Errorw("decoding subscription message", "err", err)
+		return
+	}
+	if resp.Error != nil {
+		log.Errorw("subscription error", "err", resp.Error)
+		return
+	}
+	event := new(ctypes.ResultEvent)
+	if err := tmjson.Unmarshal(resp.Result, event); err != nil {
+		log.Errorw("decoding subscription event", "err", err)
+		return
+	}
+
+	e.lk.Lock()
+	defer e.lk.Unlock()
+	out, ok := e.subs[event.Query]
+	if !ok {
+		return
+	}
+	select {
+	case out <- *event:
+	default:
+		log.Errorw("dropping subscription event, channel is full", "query", event.Query)
+	}
+}
@@ -0,0 +1,46 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tendermint/tendermint/types"
+)
+
+// Misbehavior is the validator misbehavior proven by a piece of evidence committed in a block.
+type Misbehavior struct {
+	// Kind names the misbehavior, e.g. "duplicate vote".
+	Kind string
+	// Height is the height the misbehavior happened at.
+	Height int64
+	// Time is the time of the block at the height the misbehavior happened at.
+	Time time.Time
+	// Validators are the addresses of the validators that misbehaved.
+	Validators []types.Address
+}
+
+// DecodeEvidence decodes the evidence of the common types into the Misbehavior it proves.
+func DecodeEvidence(ev types.Evidence) (*Misbehavior, error) {
+	switch ev := ev.(type) {
+	case *types.DuplicateVoteEvidence:
+		return &Misbehavior{
+			Kind:       "duplicate vote",
+			Height:     ev.Height(),
+			Time:       ev.Time(),
+			Validators: []types.Address{ev.VoteA.ValidatorAddress},
+		}, nil
+	case *types.LightClientAttackEvidence:
+		validators := make([]types.Address, len(ev.ByzantineValidators))
+		for i, val := range ev.ByzantineValidators {
+			validators[i] = val.Address
+		}
+		return &Misbehavior{
+			Kind:       "light client attack",
+			Height:     ev.Height(),
+			Time:       ev.Time(),
+			Validators: validators,
+		}, nil
+	default:
+		return nil, fmt.Errorf("core: unknown evidence type %T", ev)
+	}
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+func TestBlockFetcher_GetBlockEvidence(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	const chainID = "private"
+	valSet, vals := RandValidatorSet(3, 1)
+
+	// the first validator double-signs at height 1, which is committed in the block at height 2
+	evidence := tmtypes.NewMockDuplicateVoteEvidenceWithValidator(1, time.Now(), vals[0], chainID)
+	blocks := map[int64]*tmtypes.Block{
+		1: newTestBlock(chainID, 1, valSet),
+		2: newTestBlock(chainID, 2, valSet),
+	}
+	blocks[2].Evidence = tmtypes.EvidenceData{Evidence: tmtypes.EvidenceList{evidence}}
+
+	client := &mockClient{
+		block: func(_ context.Context, height *int64) (*ctypes.ResultBlock, error) {
+			return &ctypes.ResultBlock{Block: blocks[*height]}, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	height := int64(1)
+	list, err := fetcher.GetBlockEvidence(ctx, &height)
+	require.NoError(t, err)
+	assert.NotNil(t, list)
+	assert.Empty(t, list)
+
+	height = 2
+	list, err = fetcher.GetBlockEvidence(ctx, &height)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	misbehavior, err := DecodeEvidence(list[0])
+	require.NoError(t, err)
+	pubKey, err := vals[0].GetPubKey()
+	require.NoError(t, err)
+	assert.Equal(t, "duplicate vote", misbehavior.Kind)
+	assert.Equal(t, int64(1), misbehavior.Height)
+	assert.Equal(t, []tmtypes.Address{pubKey.Address()}, misbehavior.Validators)
+}
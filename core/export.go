@@ -0,0 +1,77 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tendermint/tendermint/types"
+)
+
+const (
+	// exportBatchSize defines the number of blocks ExportRange fetches at once.
+	exportBatchSize = 16
+	// exportConcurrency defines the number of parallel requests ExportRange fetches a batch with.
+	exportConcurrency = 4
+)
+
+// BlockSink receives the blocks exported by ExportRange, e.g. to write them to disk.
+type BlockSink interface {
+	Write(ctx context.Context, block *types.Block) error
+}
+
+// ExportProgress records the progress of ExportRange, so that an interrupted export can resume.
+type ExportProgress interface {
+	// LastExported returns the last exported height or zero if nothing was exported yet.
+	LastExported(ctx context.Context) (int64, error)
+	// SetLastExported checkpoints the last exported height.
+	SetLastExported(ctx context.Context, height int64) error
+}
+
+// ExportRange fetches the blocks of the range [from:to] and writes them to the sink in order,
+// checkpointing every exported height with the progress. Once interrupted, e.g. by a failed
+// request or a canceled context, it can be called again with the same progress to resume,
+// skipping the heights exported already. The ProgressObserver, if set, is notified of every
+// exported block.
+func (f *BlockFetcher) ExportRange(
+	ctx context.Context,
+	from, to int64,
+	sink BlockSink,
+	progress ExportProgress,
+) error {
+	event := ProgressEvent{Total: to - from + 1}
+	start := from
+	last, err := progress.LastExported(ctx)
+	if err != nil {
+		return fmt.Errorf("core/fetcher: getting export progress: %w", err)
+	}
+	if last >= from {
+		from = last + 1
+	}
+
+	for from <= to {
+		batchTo := from + exportBatchSize - 1
+		if batchTo > to {
+			batchTo = to
+		}
+
+		// export the blocks fetched before a failure, if any, so that they are not fetched again
+		blocks, fetchErr := f.getBlockRange(ctx, from, batchTo, exportConcurrency, nil, nil)
+		for _, block := range blocks {
+			if err := sink.Write(ctx, block); err != nil {
+				return fmt.Errorf("core/fetcher: exporting block at height %d: %w", block.Height, err)
+			}
+			if err := progress.SetLastExported(ctx, block.Height); err != nil {
+				return fmt.Errorf("core/fetcher: checkpointing export at height %d: %w", block.Height, err)
+			}
+			if f.params.ProgressObserver != nil {
+				event.Height, event.Done = block.Height, block.Height-start+1
+				f.params.ProgressObserver(event)
+			}
+		}
+		if fetchErr != nil {
+			return fetchErr
+		}
+		from = batchTo + 1
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestBlockFetcher_ExportRange(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	// the export is interrupted once at height 42
+	const failHeight = 42
+	failed := false
+	client := &mockClient{
+		block: func(_ context.Context, height *int64) (*ctypes.ResultBlock, error) {
+			if *height == failHeight && !failed {
+				failed = true
+				return nil, errors.New("connection reset")
+			}
+			return &ctypes.ResultBlock{Block: newHeightBlock(*height)}, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	sink, progress := &memorySink{}, &memoryProgress{}
+	err = fetcher.ExportRange(ctx, 10, 100, sink, progress)
+	require.Error(t, err)
+	assert.EqualValues(t, failHeight-1, progress.last)
+
+	err = fetcher.ExportRange(ctx, 10, 100, sink, progress)
+	require.NoError(t, err)
+	assert.EqualValues(t, 100, progress.last)
+
+	// every height is exported once and in order
+	require.Len(t, sink.heights, 91)
+	for i, height := range sink.heights {
+		assert.EqualValues(t, 10+i, height)
+	}
+}
+
+func TestBlockFetcher_ExportRange_Progress(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	client := &mockClient{
+		block: func(_ context.Context, height *int64) (*ctypes.ResultBlock, error) {
+			return &ctypes.ResultBlock{Block: newHeightBlock(*height)}, nil
+		},
+	}
+	var events []ProgressEvent
+	fetcher, err := NewBlockFetcher(client, WithProgressObserver(func(event ProgressEvent) {
+		events = append(events, event)
+	}))
+	require.NoError(t, err)
+
+	// resumes a half done export
+	progress := &memoryProgress{last: 50}
+	err = fetcher.ExportRange(ctx, 1, 100, &memorySink{}, progress)
+	require.NoError(t, err)
+
+	require.Len(t, events, 50)
+	for i := 1; i < len(events); i++ {
+		assert.Greater(t, events[i].Fraction(), events[i-1].Fraction())
+		assert.Greater(t, events[i].Height, events[i-1].Height)
+	}
+	assert.InDelta(t, 0.51, events[0].Fraction(), 1e-9)
+	last := events[len(events)-1]
+	assert.EqualValues(t, 100, last.Height)
+	assert.Equal(t, 1.0, last.Fraction())
+}
+
+type memorySink struct {
+	heights []int64
+}
+
+func (s *memorySink) Write(_ context.Context, block *types.Block) error {
+	s.heights = append(s.heights, block.Height)
+	return nil
+}
+
+type memoryProgress struct {
+	last int64
+}
+
+func (p *memoryProgress) LastExported(context.Context) (int64, error) {
+	return p.last, nil
+}
+
+func (p *memoryProgress) SetLastExported(_ context.Context, height int64) error {
+	p.last = height
+	return nil
+}
@@ -1,11 +1,20 @@
 package core
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	logging "github.com/ipfs/go-log/v2"
 	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
 	"github.com/tendermint/tendermint/types"
 )
 
@@ -16,25 +25,144 @@ var (
 	newBlockEventQuery = types.QueryForEvent(types.EventNewBlock).String()
 )
 
+// ErrInvalidHeight is returned when a height below 1 is requested.
+// NOTE: Core does not treat height 0 as the latest height. To request the latest height,
+// a nil height has to be passed instead.
+var ErrInvalidHeight = errors.New("core/fetcher: invalid height")
+
+// ErrHeightPruned is returned when the requested height is below the lowest height retained by
+// Core, meaning the data for it has already been pruned.
+type ErrHeightPruned struct {
+	Height int64
+	Lowest int64
+}
+
+func (e *ErrHeightPruned) Error() string {
+	return fmt.Sprintf("core/fetcher: height %d is pruned, lowest available height is %d", e.Height, e.Lowest)
+}
+
+// ErrHeightAhead is returned when the requested height is above the latest height of Core,
+// meaning the block is not produced yet.
+type ErrHeightAhead struct {
+	Height int64
+	Latest int64
+}
+
+func (e *ErrHeightAhead) Error() string {
+	return fmt.Sprintf("core/fetcher: height %d is ahead of the latest height %d", e.Height, e.Latest)
+}
+
+// ErrNoNextBlock is returned by GetLastCommitForHeight for the latest height, as the block
+// carrying its LastCommit is not produced yet.
+type ErrNoNextBlock struct {
+	Height int64
+}
+
+func (e *ErrNoNextBlock) Error() string {
+	return fmt.Sprintf("core/fetcher: no block following height %d yet", e.Height)
+}
+
+// ErrHeightMismatch is returned when Core responds with data for a height other than the requested
+// one, e.g. because of a buggy caching proxy in front of it.
+type ErrHeightMismatch struct {
+	Requested int64
+	Returned  int64
+}
+
+func (e *ErrHeightMismatch) Error() string {
+	return fmt.Sprintf("core/fetcher: requested height %d, but got height %d", e.Requested, e.Returned)
+}
+
+// ErrBlockDecode is returned when the block served by Core can't be decoded into the expected
+// structures, e.g. because of version skew or corruption, as opposed to failing in transport.
+type ErrBlockDecode struct {
+	// Height is the requested height, or zero when requesting the latest block.
+	Height int64
+	Err    error
+}
+
+func (e *ErrBlockDecode) Error() string {
+	return fmt.Sprintf("core/fetcher: decoding block at height %d: %s", e.Height, e.Err)
+}
+
+func (e *ErrBlockDecode) Unwrap() error {
+	return e.Err
+}
+
+// ErrClientStopped is reported for the requests cancelled by stopping the client, as well as when
+// the subscription to new block events is lost and cannot be re-established because the client
+// was stopped.
+var ErrClientStopped = errors.New("core/fetcher: client stopped")
+
 type BlockFetcher struct {
 	client Client
+	params *FetcherParameters
+	// cache is nil when disabled
+	cache *blockCache
+	// budget is nil when the in-flight bytes are not bounded
+	budget *memoryBudget
+	// prefetching holds a slot for every block being prefetched, nil when disabled
+	prefetching chan struct{}
 
 	newBlockCh chan *types.Block
 	doneCh     chan struct{}
+
+	// the subscriptions to the queries of Core, shared by their subscribers
+	subsLk sync.Mutex
+	subs   map[string]*querySubscription
+
+	subErrLk sync.Mutex
+	subErr   error
+
+	tipLk     sync.Mutex
+	tipHeight int64
+	// the lowest height retained by Core, refreshed along with the tip
+	tipLowest int64
+	tipExpiry time.Time
+
+	// the latest fetched validator set, reused while the validators of blocks don't change
+	valSetLk   sync.Mutex
+	valSet     *types.ValidatorSet
+	valSetHash tmbytes.HexBytes
 }
 
 // NewBlockFetcher returns a new `BlockFetcher`.
-func NewBlockFetcher(client Client) *BlockFetcher {
-	return &BlockFetcher{
+func NewBlockFetcher(client Client, opts ...Option[FetcherParameters]) (*BlockFetcher, error) {
+	params := DefaultFetcherParameters()
+	for _, opt := range opts {
+		opt(params)
+	}
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("core/fetcher: invalid parameters: %w", err)
+	}
+
+	f := &BlockFetcher{
 		client: client,
+		params: params,
+		subs:   make(map[string]*querySubscription),
+	}
+	if params.CacheSize > 0 {
+		cache, err := newBlockCache(params.CacheSize, params.CacheMaxBytes)
+		if err != nil {
+			return nil, fmt.Errorf("core/fetcher: creating block cache: %w", err)
+		}
+		f.cache = cache
+	}
+	if params.MaxInFlightBytes > 0 {
+		f.budget = newMemoryBudget(params.MaxInFlightBytes)
 	}
+	if params.PrefetchWindow > 0 {
+		f.prefetching = make(chan struct{}, params.PrefetchWindow)
+	}
+	return f, nil
 }
 
 // GetBlockInfo queries Core for additional block information, like Commit and ValidatorSet.
+// The ValidatorSet is only re-fetched once the validators of the block change.
 func (f *BlockFetcher) GetBlockInfo(ctx context.Context, height *int64) (*types.Commit, *types.ValidatorSet, error) {
-	commit, err := f.Commit(ctx, height)
+	sh, err := f.GetSignedHeader(ctx, height)
 	if err != nil {
-		return nil, nil, fmt.Errorf("core/fetcher: getting commit at height %d: %w", height, err)
+		return nil, nil, fmt.Errorf("core/fetcher: getting commit at height %s: %w", formatHeight(height), err)
 	}
 
 	// If a nil `height` is given as a parameter, there is a chance
@@ -42,26 +170,263 @@ func (f *BlockFetcher) GetBlockInfo(ctx context.Context, height *int64) (*types.
 	// commit and getting the latest validator set. Therefore, it is
 	// best to get the validator set at the latest commit's height to
 	// prevent this potential inconsistency.
-	valSet, err := f.ValidatorSet(ctx, &commit.Height)
+	valSet, err := f.validatorSetByHash(ctx, sh.Height, sh.ValidatorsHash)
 	if err != nil {
-		return nil, nil, fmt.Errorf("core/fetcher: getting validator set at height %d: %w", height, err)
+		return nil, nil, fmt.Errorf("core/fetcher: getting validator set at height %s: %w", formatHeight(height), err)
 	}
 
-	return commit, valSet, nil
+	return sh.Commit, valSet, nil
+}
+
+// validatorSetByHash returns the ValidatorSet with the given hash at the given height, reusing
+// the previously fetched one if its hash matches.
+func (f *BlockFetcher) validatorSetByHash(
+	ctx context.Context,
+	height int64,
+	hash tmbytes.HexBytes,
+) (*types.ValidatorSet, error) {
+	f.valSetLk.Lock()
+	valSet, valSetHash := f.valSet, f.valSetHash
+	f.valSetLk.Unlock()
+	if valSet != nil && bytes.Equal(valSetHash, hash) {
+		return valSet, nil
+	}
+
+	valSet, err := f.ValidatorSet(ctx, &height)
+	if err != nil {
+		return nil, err
+	}
+	valSetHash = valSet.Hash()
+	if !bytes.Equal(valSetHash, hash) {
+		return nil, fmt.Errorf("core/fetcher: validator set hash %X does not match validators hash %X "+
+			"of the block at height %d", valSetHash, hash, height)
+	}
+
+	f.valSetLk.Lock()
+	f.valSet, f.valSetHash = valSet, valSetHash
+	f.valSetLk.Unlock()
+	return valSet, nil
 }
 
 // GetBlock queries Core for a `Block` at the given height.
+// A nil height requests the latest block.
 func (f *BlockFetcher) GetBlock(ctx context.Context, height *int64) (*types.Block, error) {
-	res, err := f.client.Block(ctx, height)
+	block, _, err := f.getObservedBlock(ctx, height)
+	return block, err
+}
+
+// getObservedBlock is getBlock reporting the call to the Observer, if set.
+func (f *BlockFetcher) getObservedBlock(ctx context.Context, height *int64) (*types.Block, bool, error) {
+	if f.params.Observer == nil {
+		return f.getBlock(ctx, height)
+	}
+
+	start := f.params.Clock.Now()
+	block, cached, err := f.getBlock(ctx, height)
+	event := FetchEvent{
+		Duration: f.params.Clock.Now().Sub(start),
+		Cached:   cached,
+		Err:      err,
+	}
+	switch {
+	case block != nil:
+		event.Height, event.Bytes = block.Height, block.Size()
+	case height != nil:
+		event.Height = *height
+	}
+	f.params.Observer(event)
+	return block, cached, err
+}
+
+// GetLatestBlock queries Core for the latest block in a single request, returning it along with
+// its height. Unlike getting the Tip and then the block at it, the height always matches the block,
+// even if the chain advances in between.
+func (f *BlockFetcher) GetLatestBlock(ctx context.Context) (*types.Block, int64, error) {
+	block, err := f.GetBlock(ctx, nil)
 	if err != nil {
+		return nil, 0, err
+	}
+	return block, block.Height, nil
+}
+
+// getBlock implements GetBlock, also reporting whether the block came from the cache.
+func (f *BlockFetcher) getBlock(ctx context.Context, height *int64) (*types.Block, bool, error) {
+	if err := validateHeight(height); err != nil {
+		return nil, false, err
+	}
+
+	if f.cache != nil && height != nil {
+		if block, ok := f.cache.Get(*height); ok {
+			return block, true, nil
+		}
+	}
+
+	res, err := f.fetchBlock(ctx, height)
+	if err != nil {
+		return nil, false, err
+	}
+	if f.cache != nil {
+		f.cache.Add(res.Block)
+	}
+	return res.Block, false, nil
+}
+
+// fetchBlock queries Core for the block at the given height, bypassing the cache, and checks it.
+func (f *BlockFetcher) fetchBlock(ctx context.Context, height *int64) (*ctypes.ResultBlock, error) {
+	if err := validateHeight(height); err != nil {
 		return nil, err
 	}
 
+	res, err := f.client.Block(ctx, height)
+	if err != nil {
+		if isDecodeError(err) {
+			decodeErr := &ErrBlockDecode{Err: err}
+			if height != nil {
+				decodeErr.Height = *height
+			}
+			return nil, decodeErr
+		}
+		return nil, heightError(err)
+	}
+
 	if res != nil && res.Block == nil {
-		return nil, fmt.Errorf("core/fetcher: block not found, height: %d", height)
+		return nil, fmt.Errorf("core/fetcher: block not found, height: %s", formatHeight(height))
 	}
+	if height != nil && res.Block.Height != *height {
+		return nil, &ErrHeightMismatch{Requested: *height, Returned: res.Block.Height}
+	}
+	if f.params.VerifyDataHash {
+		if err := verifyDataHash(res.Block); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
 
-	return res.Block, nil
+// FetchEvent describes a completed GetBlock call reported to the Observer.
+type FetchEvent struct {
+	// Height is the height of the fetched block, or the requested height on failure.
+	// It is zero when fetching the latest block fails.
+	Height int64
+	// Duration is how long the call took.
+	Duration time.Duration
+	// Bytes is the size of the fetched block.
+	Bytes int
+	// Cached tells whether the block came from the cache rather than Core.
+	Cached bool
+	// Err is the error the call failed with, if any.
+	Err error
+}
+
+// GetRawBlock queries Core for a `Block` at the given height, returning it along with its
+// protobuf encoding, e.g. to store and re-serve the block without encoding it once again.
+// A nil height requests the latest block.
+// NOTE: Core serves blocks as JSON, so the bytes are not the ones stored by Core, but the block
+// re-encoded by the fetcher. They are ensured to decode back to a block with the hash of the block
+// ID served by Core, so that the encoding is faithful. The block is always queried from Core, as
+// the cache does not keep the block IDs.
+func (f *BlockFetcher) GetRawBlock(ctx context.Context, height *int64) (*types.Block, []byte, error) {
+	res, err := f.fetchBlock(ctx, height)
+	if err != nil {
+		return nil, nil, err
+	}
+	block := res.Block
+
+	pb, err := block.ToProto()
+	if err != nil {
+		return nil, nil, fmt.Errorf("core/fetcher: encoding block at height %d: %w", block.Height, err)
+	}
+	raw, err := pb.Marshal()
+	if err != nil {
+		return nil, nil, fmt.Errorf("core/fetcher: encoding block at height %d: %w", block.Height, err)
+	}
+
+	decodedPB := &tmproto.Block{}
+	if err := decodedPB.Unmarshal(raw); err != nil {
+		return nil, nil, fmt.Errorf("core/fetcher: decoding block at height %d: %w", block.Height, err)
+	}
+	decoded, err := types.BlockFromProto(decodedPB)
+	if err != nil {
+		return nil, nil, fmt.Errorf("core/fetcher: decoding block at height %d: %w", block.Height, err)
+	}
+	if !bytes.Equal(decoded.Hash(), res.BlockID.Hash) {
+		return nil, nil, fmt.Errorf("core/fetcher: encoded block at height %d hashes to %X, expected %X",
+			block.Height, decoded.Hash(), res.BlockID.Hash)
+	}
+	return block, raw, nil
+}
+
+// GetBlockEvidence queries Core for the evidence of validator misbehavior committed in the block
+// at the given height. A nil height requests the latest block. The evidence is empty, but not nil,
+// for a block without any. See DecodeEvidence to decode it.
+func (f *BlockFetcher) GetBlockEvidence(ctx context.Context, height *int64) (types.EvidenceList, error) {
+	block, err := f.GetBlock(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+	if block.Evidence.Evidence == nil {
+		return types.EvidenceList{}, nil
+	}
+	return block.Evidence.Evidence, nil
+}
+
+// GetLastCommitForHeight queries Core for the LastCommit carried by the block following the given
+// height, which verifies the block at the height. For the latest height, ErrNoNextBlock is returned.
+func (f *BlockFetcher) GetLastCommitForHeight(ctx context.Context, height int64) (*types.Commit, error) {
+	if err := validateHeight(&height); err != nil {
+		return nil, err
+	}
+
+	next := height + 1
+	block, err := f.GetBlock(ctx, &next)
+	if err != nil {
+		var errAhead *ErrHeightAhead
+		if errors.As(err, &errAhead) {
+			return nil, &ErrNoNextBlock{Height: height}
+		}
+		return nil, err
+	}
+	return block.LastCommit, nil
+}
+
+// GetSignedBlock queries Core for a `Block` at the given height together with the Commit
+// finalizing it and the ValidatorSet that signed the Commit. A nil height requests the latest block.
+func (f *BlockFetcher) GetSignedBlock(ctx context.Context, height *int64) (*SignedBlock, error) {
+	block, err := f.GetBlock(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+
+	// request the rest at the height of the block, as the latest height may have moved on
+	commit, valSet, err := f.GetBlockInfo(ctx, &block.Height)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignedBlock{
+		Block:        block,
+		Commit:       commit,
+		ValidatorSet: valSet,
+	}, nil
+}
+
+// GetVerifiedBlock is like GetSignedBlock, but also verifies the Commit finalizes the block and
+// is signed by the ValidatorSet. A failed verification is reported as ErrCommitVerification.
+// If VerifyLastResults is enabled, the block is also checked with VerifyLastResults.
+func (f *BlockFetcher) GetVerifiedBlock(ctx context.Context, height *int64) (*SignedBlock, error) {
+	sb, err := f.GetSignedBlock(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+	if err := sb.Verify(); err != nil {
+		return nil, err
+	}
+	if f.params.VerifyLastResults {
+		if err := f.VerifyLastResults(ctx, sb.Block); err != nil {
+			return nil, err
+		}
+	}
+	return sb, nil
 }
 
 func (f *BlockFetcher) GetBlockByHash(ctx context.Context, hash tmbytes.HexBytes) (*types.Block, error) {
@@ -78,23 +443,139 @@ func (f *BlockFetcher) GetBlockByHash(ctx context.Context, hash tmbytes.HexBytes
 }
 
 // Commit queries Core for a `Commit` from the block at
-// the given height.
+// the given height. A nil height requests the latest commit.
 func (f *BlockFetcher) Commit(ctx context.Context, height *int64) (*types.Commit, error) {
+	if err := validateHeight(height); err != nil {
+		return nil, err
+	}
+
 	res, err := f.client.Commit(ctx, height)
 	if err != nil {
 		return nil, err
 	}
 
 	if res != nil && res.Commit == nil {
-		return nil, fmt.Errorf("core/fetcher: commit not found at height %d", height)
+		return nil, fmt.Errorf("core/fetcher: commit not found at height %s", formatHeight(height))
 	}
 
 	return res.Commit, nil
 }
 
+// GetSignedHeader queries Core for the `SignedHeader`, i.e. the header and the Commit of the block
+// at the given height, without the block body, which is much smaller to transfer than the whole block.
+// A nil height requests the latest signed header.
+func (f *BlockFetcher) GetSignedHeader(ctx context.Context, height *int64) (*types.SignedHeader, error) {
+	if err := validateHeight(height); err != nil {
+		return nil, err
+	}
+
+	res, err := f.client.Commit(ctx, height)
+	if err != nil {
+		return nil, heightError(err)
+	}
+
+	if res != nil && (res.Header == nil || res.Commit == nil) {
+		return nil, fmt.Errorf("core/fetcher: signed header not found at height %s", formatHeight(height))
+	}
+	if height != nil && res.Header.Height != *height {
+		return nil, &ErrHeightMismatch{Requested: *height, Returned: res.Header.Height}
+	}
+
+	return &res.SignedHeader, nil
+}
+
+// GetHeader queries Core for the header of the block at the given height, without the Commit or
+// the block body, e.g. for monitoring the block times and proposers. It is the lightest fetch of
+// a block and, unlike GetSignedHeader, leaves the header unverified.
+// A nil height requests the latest header.
+func (f *BlockFetcher) GetHeader(ctx context.Context, height *int64) (*types.Header, error) {
+	meta, err := f.GetBlockMeta(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+	return &meta.Header, nil
+}
+
+// GetBlockMeta queries Core for the meta of the block at the given height, i.e. its header, ID,
+// size and number of txs, without the block body, e.g. for indexing. Like GetHeader, it leaves
+// the meta unverified. A nil height requests the latest meta.
+func (f *BlockFetcher) GetBlockMeta(ctx context.Context, height *int64) (*types.BlockMeta, error) {
+	if err := validateHeight(height); err != nil {
+		return nil, err
+	}
+
+	// zero heights request the latest metas, ordered from the highest
+	var minHeight, maxHeight int64
+	if height != nil {
+		minHeight, maxHeight = *height, *height
+	}
+	res, err := f.client.BlockchainInfo(ctx, minHeight, maxHeight)
+	if err != nil {
+		return nil, heightError(err)
+	}
+
+	if len(res.BlockMetas) == 0 || res.BlockMetas[0] == nil {
+		return nil, fmt.Errorf("core/fetcher: block meta not found at height %s", formatHeight(height))
+	}
+	meta := res.BlockMetas[0]
+	if height != nil && meta.Header.Height != *height {
+		return nil, &ErrHeightMismatch{Requested: *height, Returned: meta.Header.Height}
+	}
+	return meta, nil
+}
+
+// maxBlockMetasPerRequest is the largest number of block metas Core serves per BlockchainInfo
+// request.
+const maxBlockMetasPerRequest = 20
+
+// GetBlockMetas queries Core for the metas of the contiguous range of blocks [from:to] and returns
+// them ordered by height. The range is requested in chunks of as many metas as Core serves at once,
+// making it far cheaper than fetching the blocks when only their metadata is needed. Like
+// GetBlockMeta, it leaves the metas unverified.
+func (f *BlockFetcher) GetBlockMetas(ctx context.Context, from, to int64) ([]*types.BlockMeta, error) {
+	if err := validateHeight(&from); err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, fmt.Errorf("core/fetcher: invalid range: from %d is above to %d", from, to)
+	}
+
+	metas := make([]*types.BlockMeta, 0, to-from+1)
+	for minHeight := from; minHeight <= to; minHeight += maxBlockMetasPerRequest {
+		maxHeight := minHeight + maxBlockMetasPerRequest - 1
+		if maxHeight > to {
+			maxHeight = to
+		}
+		res, err := f.client.BlockchainInfo(ctx, minHeight, maxHeight)
+		if err != nil {
+			return nil, heightError(err)
+		}
+
+		// Core serves the metas ordered from the highest, and only those it has
+		byHeight := make(map[int64]*types.BlockMeta, len(res.BlockMetas))
+		for _, meta := range res.BlockMetas {
+			if meta != nil {
+				byHeight[meta.Header.Height] = meta
+			}
+		}
+		for height := minHeight; height <= maxHeight; height++ {
+			meta, ok := byHeight[height]
+			if !ok {
+				return nil, fmt.Errorf("core/fetcher: block meta not found at height %d", height)
+			}
+			metas = append(metas, meta)
+		}
+	}
+	return metas, nil
+}
+
 // ValidatorSet queries Core for the ValidatorSet from the
-// block at the given height.
+// block at the given height. A nil height requests the latest validator set.
 func (f *BlockFetcher) ValidatorSet(ctx context.Context, height *int64) (*types.ValidatorSet, error) {
+	if err := validateHeight(height); err != nil {
+		return nil, err
+	}
+
 	var perPage = 100
 
 	vals, total := make([]*types.Validator, 0), -1
@@ -117,12 +598,16 @@ func (f *BlockFetcher) ValidatorSet(ctx context.Context, height *int64) (*types.
 
 // SubscribeNewBlockEvent subscribes to new block events from Core, returning
 // a new block event channel on success.
+//
+// NOTE: The events are read under the WSReadLimit of the client. An event over the limit ends the
+// subscription, closing the channel with ErrWSReadLimit reported by SubscriptionErr, as the
+// block is missed.
 func (f *BlockFetcher) SubscribeNewBlockEvent(ctx context.Context) (<-chan *types.Block, error) {
 	// start the client if not started yet
 	if !f.client.IsRunning() {
 		return nil, fmt.Errorf("client not running")
 	}
-	eventChan, err := f.client.Subscribe(ctx, newBlockSubscriber, newBlockEventQuery)
+	eventChan, err := f.subscribe(ctx, newBlockSubscriber, newBlockEventQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -134,31 +619,334 @@ func (f *BlockFetcher) SubscribeNewBlockEvent(ctx context.Context) (<-chan *type
 
 	f.newBlockCh = make(chan *types.Block)
 	f.doneCh = make(chan struct{})
+	f.setSubscriptionErr(nil)
 
-	go func() {
-		for {
+	go func(newBlockCh chan *types.Block, doneCh chan struct{}) {
+		defer close(newBlockCh)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
 			select {
-			case <-f.doneCh:
-				return
-			case newEvent, ok := <-eventChan:
-				if !ok {
-					return
-				}
-				newBlock, ok := newEvent.Data.(types.EventDataNewBlock)
-				if !ok {
-					log.Warnf("unexpected event: %v", newEvent)
-					continue
+			case <-doneCh:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		if !f.params.CoalesceNewBlocks {
+			f.setSubscriptionErr(f.forwardNewBlocks(ctx, eventChan, newBlockCh))
+			return
+		}
+		forwardCh := make(chan *types.Block)
+		coalesced := make(chan struct{})
+		go func() {
+			defer close(coalesced)
+			coalesceBlocks(ctx, forwardCh, newBlockCh)
+		}()
+		f.setSubscriptionErr(f.forwardNewBlocks(ctx, eventChan, forwardCh))
+		close(forwardCh)
+		<-coalesced
+	}(f.newBlockCh, f.doneCh)
+
+	return f.newBlockCh, nil
+}
+
+// coalesceBlocks forwards the blocks from one channel to the other, keeping only the latest block
+// while the receiver is busy. A block not above the latest one is dropped, so that the receiver
+// never goes back to a stale tip. The latest block is flushed once the input channel is closed.
+func coalesceBlocks(ctx context.Context, in <-chan *types.Block, out chan<- *types.Block) {
+	var (
+		latest *types.Block
+		height int64
+	)
+	for in != nil || latest != nil {
+		var sendCh chan<- *types.Block
+		if latest != nil {
+			sendCh = out
+		}
+		select {
+		case block, ok := <-in:
+			switch {
+			case !ok:
+				in = nil
+			case block.Height > height:
+				if latest != nil {
+					log.Debugw("coalescing new block event", "dropped_height", latest.Height)
 				}
+				latest, height = block, block.Height
+			}
+		case sendCh <- latest:
+			latest = nil
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// prefetch adds the block carried by a new block event to the cache in the background, unless the
+// PrefetchWindow is full, so that the delivery of the new block events is never held up by
+// sizing the block. The block has to pass the checks of getBlock first.
+func (f *BlockFetcher) prefetch(block *types.Block) {
+	if f.prefetching == nil {
+		return
+	}
+	select {
+	case f.prefetching <- struct{}{}:
+	default:
+		log.Debugw("prefetch window full, skipping block", "height", block.Height)
+		return
+	}
+
+	go func() {
+		defer func() { <-f.prefetching }()
+		f.cache.Add(block)
+	}()
+}
+
+// ReceiveEvent describes a block received from the subscription to new block events, reported to
+// the ReceiveObserver.
+type ReceiveEvent struct {
+	// Height is the height of the received block.
+	Height int64
+	// Time is the time of the received block, as committed by Core.
+	Time time.Time
+	// ReceivedAt is the local time the block was received at.
+	ReceivedAt time.Time
+}
+
+// Latency returns how long it took the block to be received since it was committed.
+func (e ReceiveEvent) Latency() time.Duration {
+	return e.ReceivedAt.Sub(e.Time)
+}
+
+// ReconnectEvent describes an attempt to re-establish the lost subscription to new block events,
+// reported to the ReconnectObserver.
+type ReconnectEvent struct {
+	// Attempt is the number of the attempt, counted from 1 for every lost subscription.
+	Attempt int
+	// Endpoint is the address of Core reconnected to.
+	Endpoint string
+	// Duration is how long the attempt took, not counting the backoff before it.
+	Duration time.Duration
+	// Err is the error the attempt failed with, or nil if the subscription was re-established.
+	Err error
+}
+
+// forwardNewBlocks translates new block events into blocks and sends them to the given channel,
+// until the context is canceled or the subscription is lost irrecoverably.
+// If no event comes for the Heartbeat interval, Core is probed for its tip. In case the tip
+// has advanced, the subscription has silently stalled, so it is re-established and the missed
+// blocks are backfilled, retrying the failed ones until they are sent.
+func (f *BlockFetcher) forwardNewBlocks(
+	ctx context.Context,
+	eventChan <-chan ctypes.ResultEvent,
+	newBlockCh chan<- *types.Block,
+) error {
+	var (
+		heartbeat  <-chan time.Time
+		lastHeight int64
+		err        error
+	)
+	// the heartbeat is restarted by every event, so that it only fires once the events stop
+	resetHeartbeat := func() {}
+	if f.params.Heartbeat > 0 {
+		timer := f.params.Clock.NewTimer(f.params.Heartbeat)
+		defer timer.Stop()
+		heartbeat = timer.C()
+		resetHeartbeat = func() {
+			if !timer.Stop() {
 				select {
-				case f.newBlockCh <- newBlock.Block:
-				case <-f.doneCh:
-					return
+				case <-timer.C():
+				default:
 				}
 			}
+			timer.Reset(f.params.Heartbeat)
 		}
-	}()
+	}
 
-	return f.newBlockCh, nil
+	send := func(block *types.Block) bool {
+		select {
+		case newBlockCh <- block:
+			lastHeight = block.Height
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-heartbeat:
+			resetHeartbeat()
+			status, err := f.client.Status(ctx)
+			if err != nil {
+				log.Warnw("probing Core status on heartbeat", "err", err)
+				continue
+			}
+			tip := status.SyncInfo.LatestBlockHeight
+			if lastHeight == 0 {
+				// nothing was received yet, so start tracking from the current tip
+				lastHeight = tip
+				continue
+			}
+			if tip <= lastHeight {
+				continue
+			}
+
+			log.Warnw("new block event subscription stalled", "last_height", lastHeight, "tip", tip)
+			eventChan, err = f.resubscribe(ctx)
+			if eventChan == nil {
+				return err
+			}
+			for height := lastHeight + 1; height <= tip; height++ {
+				block := f.backfillBlock(ctx, height)
+				if block == nil || !send(block) {
+					return nil
+				}
+			}
+		case newEvent, ok := <-eventChan:
+			resetHeartbeat()
+			if !ok {
+				log.Warn("new block event subscription lost")
+				eventChan, err = f.resubscribe(ctx)
+				if eventChan == nil {
+					return err
+				}
+				continue
+			}
+			newBlock, ok := newEvent.Data.(types.EventDataNewBlock)
+			if !ok {
+				log.Warnf("unexpected event: %v", newEvent)
+				continue
+			}
+			if f.params.ReceiveObserver != nil {
+				f.params.ReceiveObserver(ReceiveEvent{
+					Height:     newBlock.Block.Height,
+					Time:       newBlock.Block.Time,
+					ReceivedAt: f.params.Clock.Now(),
+				})
+			}
+			if f.params.VerifyDataHash {
+				if err := verifyDataHash(newBlock.Block); err != nil {
+					log.Errorw("dropping new block", "height", newBlock.Block.Height, "err", err)
+					continue
+				}
+			}
+			f.prefetch(newBlock.Block)
+			if !send(newBlock.Block) {
+				return nil
+			}
+		}
+	}
+}
+
+// backfillBlock queries Core for the block at the given height missed by the subscription, backing
+// off between failed attempts until the context is done, so that no block is skipped. It returns
+// nil once the context is done.
+func (f *BlockFetcher) backfillBlock(ctx context.Context, height int64) *types.Block {
+	for attempt := 1; ; attempt++ {
+		block, err := f.GetBlock(ctx, &height)
+		if err == nil {
+			if attempt > 1 {
+				f.params.Backoff.Reset()
+			}
+			return block
+		}
+		log.Errorw("backfilling block missed by subscription", "height", height, "attempt", attempt, "err", err)
+
+		select {
+		case <-f.params.Clock.After(f.params.Backoff.Next(attempt)):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// resubscribe re-establishes the subscription to new block events, backing off between
+// failed attempts. It returns no channel and no error if the context is canceled in the
+// meantime, and an error if the subscription cannot be re-established at all.
+func (f *BlockFetcher) resubscribe(ctx context.Context) (<-chan ctypes.ResultEvent, error) {
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-f.params.Clock.After(f.params.Backoff.Next(attempt)):
+		case <-ctx.Done():
+			return nil, nil
+		}
+
+		if !f.client.IsRunning() {
+			return nil, ErrClientStopped
+		}
+		start := f.params.Clock.Now()
+		// the previous subscription is still tracked, so it has to be dropped first
+		_ = f.unsubscribe(ctx, newBlockSubscriber, newBlockEventQuery)
+		eventChan, err := f.subscribe(ctx, newBlockSubscriber, newBlockEventQuery)
+		if f.params.ReconnectObserver != nil {
+			f.params.ReconnectObserver(ReconnectEvent{
+				Attempt:  attempt,
+				Endpoint: f.client.Endpoint(),
+				Duration: f.params.Clock.Now().Sub(start),
+				Err:      err,
+			})
+		}
+		var errLimit *ErrWSReadLimit
+		if errors.As(err, &errLimit) {
+			return nil, err
+		}
+		if err != nil {
+			log.Errorw("re-subscribing to new block events", "attempt", attempt, "err", err)
+			continue
+		}
+
+		f.params.Backoff.Reset()
+		return eventChan, nil
+	}
+}
+
+// subscribeClient subscribes the client to the events of Core matching the query, failing if the
+// subscription is not established within SubscribeTimeout. The timeout only bounds establishing
+// the subscription. The common failures are reported as ErrInvalidQuery, ErrTooManySubscriptions
+// or ErrSubscriptionClosed.
+func (f *BlockFetcher) subscribeClient(
+	ctx context.Context,
+	subscriber, query string,
+) (<-chan ctypes.ResultEvent, error) {
+	if f.params.SubscribeTimeout == 0 {
+		eventChan, err := f.client.Subscribe(ctx, subscriber, query)
+		if err != nil {
+			return nil, subscriptionError(query, err)
+		}
+		return eventChan, nil
+	}
+
+	subCtx, cancel := context.WithTimeout(ctx, f.params.SubscribeTimeout)
+	defer cancel()
+	eventChan, err := f.client.Subscribe(subCtx, subscriber, query)
+	if err != nil && ctx.Err() == nil && errors.Is(subCtx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("core/fetcher: subscription not established within %v: %w",
+			f.params.SubscribeTimeout, err)
+	}
+	if err != nil {
+		return nil, subscriptionError(query, err)
+	}
+	return eventChan, nil
+}
+
+// SubscriptionErr reports why the new block event channel was closed: nil if the subscription
+// was stopped with UnsubscribeNewBlockEvent, and the fatal error that ended it otherwise.
+// It is only meaningful once the channel is closed.
+func (f *BlockFetcher) SubscriptionErr() error {
+	f.subErrLk.Lock()
+	defer f.subErrLk.Unlock()
+	return f.subErr
+}
+
+func (f *BlockFetcher) setSubscriptionErr(err error) {
+	f.subErrLk.Lock()
+	defer f.subErrLk.Unlock()
+	f.subErr = err
 }
 
 // UnsubscribeNewBlockEvent stops the subscription to new block events from Core.
@@ -170,16 +958,13 @@ func (f *BlockFetcher) UnsubscribeNewBlockEvent(ctx context.Context) error {
 		return fmt.Errorf("no stop signal chan found in fetcher")
 	}
 	defer func() {
-		// send stop signal
-		f.doneCh <- struct{}{}
-		// close out fetcher channels
-		close(f.newBlockCh)
+		// send stop signal, so that the new block event channel gets closed
 		close(f.doneCh)
 		f.newBlockCh = nil
 		f.doneCh = nil
 	}()
 
-	return f.client.Unsubscribe(ctx, newBlockSubscriber, newBlockEventQuery)
+	return f.unsubscribe(ctx, newBlockSubscriber, newBlockEventQuery)
 }
 
 // IsSyncing returns the sync status of the Core connection: true for
@@ -192,3 +977,152 @@ func (f *BlockFetcher) IsSyncing(ctx context.Context) (bool, error) {
 	}
 	return resp.SyncInfo.CatchingUp, nil
 }
+
+// Tip returns the height of the latest block known to Core. The height is cached for TipTTL,
+// extended by a random TipJitter, so that many pollers don't hit Core all at once.
+func (f *BlockFetcher) Tip(ctx context.Context) (int64, error) {
+	tip, _, err := f.tip(ctx)
+	return tip, err
+}
+
+// EarliestHeight returns the lowest height retained by Core, i.e. the height below which blocks
+// are pruned. Unlike Tip, it is not cached, as pruning moves it on its own.
+func (f *BlockFetcher) EarliestHeight(ctx context.Context) (int64, error) {
+	resp, err := f.client.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if resp.SyncInfo.EarliestBlockHeight < 1 {
+		return 1, nil
+	}
+	return resp.SyncInfo.EarliestBlockHeight, nil
+}
+
+// ResolveHeight resolves the given height relative to the Tip, so that, e.g., the last 100 blocks
+// can be fetched without querying the tip first:
+//   - a positive height is absolute and returned as is;
+//   - zero resolves to the Tip;
+//   - a negative height is an offset from the Tip, i.e. -5 resolves to Tip-5.
+//
+// ErrHeightPruned is returned if the resolved height is below the lowest height retained by Core.
+func (f *BlockFetcher) ResolveHeight(ctx context.Context, height int64) (int64, error) {
+	if height > 0 {
+		return height, nil
+	}
+
+	tip, lowest, err := f.tip(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if lowest < 1 {
+		lowest = 1
+	}
+	resolved := tip + height
+	if resolved < lowest {
+		return 0, &ErrHeightPruned{Height: resolved, Lowest: lowest}
+	}
+	return resolved, nil
+}
+
+// tip implements Tip, also returning the lowest height retained by Core.
+func (f *BlockFetcher) tip(ctx context.Context) (int64, int64, error) {
+	f.tipLk.Lock()
+	defer f.tipLk.Unlock()
+
+	if f.params.Clock.Now().Before(f.tipExpiry) {
+		return f.tipHeight, f.tipLowest, nil
+	}
+
+	resp, err := f.client.Status(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	f.tipHeight = resp.SyncInfo.LatestBlockHeight
+	f.tipLowest = resp.SyncInfo.EarliestBlockHeight
+
+	ttl := f.params.TipTTL
+	if ttl > 0 && f.params.TipJitter > 0 {
+		ttl += time.Duration(rand.Int63n(int64(f.params.TipJitter))) //nolint:gosec
+	}
+	f.tipExpiry = f.params.Clock.Now().Add(ttl)
+	return f.tipHeight, f.tipLowest, nil
+}
+
+// syncPollInterval defines how often WaitUntilSynced checks the sync status of Core.
+var syncPollInterval = time.Second
+
+// WaitUntilSynced blocks until Core is no longer catching up with the network or the context
+// is done. On success, it returns the height of the Core's tip at the moment it was synced.
+func WaitUntilSynced(ctx context.Context, client Client) (int64, error) {
+	return waitUntilSynced(ctx, client, RealClock())
+}
+
+// WaitUntilSynced is WaitUntilSynced polling Core on the Clock of the BlockFetcher.
+func (f *BlockFetcher) WaitUntilSynced(ctx context.Context) (int64, error) {
+	return waitUntilSynced(ctx, f.client, f.params.Clock)
+}
+
+func waitUntilSynced(ctx context.Context, client Client, clock Clock) (int64, error) {
+	ticker := clock.NewTicker(syncPollInterval)
+	defer ticker.Stop()
+
+	for {
+		resp, err := client.Status(ctx)
+		if err == nil && !resp.SyncInfo.CatchingUp {
+			return resp.SyncInfo.LatestBlockHeight, nil
+		}
+		if err != nil {
+			log.Debugw("checking Core sync status", "err", err)
+		}
+
+		select {
+		case <-ticker.C():
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// validateHeight ensures the given height is either nil, meaning the latest one, or positive.
+func validateHeight(height *int64) error {
+	if height != nil && *height <= 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidHeight, *height)
+	}
+	return nil
+}
+
+// formatHeight formats the given height for the messages, nil being the latest one.
+func formatHeight(height *int64) string {
+	if height == nil {
+		return "latest"
+	}
+	return strconv.FormatInt(*height, 10)
+}
+
+// heightError translates the errors Core responds with when the requested height is not available
+// into ErrHeightPruned or ErrHeightAhead. Other errors are returned unchanged.
+func heightError(err error) error {
+	var height, other int64
+	// Core only reports the heights as a part of the error message
+	idx := strings.Index(err.Error(), "height ")
+	if idx == -1 {
+		return err
+	}
+	msg := err.Error()[idx:]
+	if _, scanErr := fmt.Sscanf(msg, "height %d is not available, lowest height is %d", &height, &other); scanErr == nil {
+		return &ErrHeightPruned{Height: height, Lowest: other}
+	}
+	_, scanErr := fmt.Sscanf(msg, "height %d must be less than or equal to the current blockchain height %d",
+		&height, &other)
+	if scanErr == nil {
+		return &ErrHeightAhead{Height: height, Latest: other}
+	}
+	return err
+}
+
+// isDecodeError tells whether the error is the failure of the client to decode the result Core
+// responded with, rather than to get the response.
+func isDecodeError(err error) bool {
+	// the client only reports it as a part of the error message
+	return strings.Contains(err.Error(), "error unmarshalling result")
+}
@@ -0,0 +1,677 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tendermint/tendermint/types"
+	"golang.org/x/sync/errgroup"
+)
+
+// BlockResult is the outcome of fetching a single block of a batch.
+type BlockResult struct {
+	Block *types.Block
+	// Cached tells whether the block came from the cache rather than Core.
+	Cached bool
+	Err    error
+}
+
+// ErrBatchBudgetExhausted is reported for the heights of a batch that failed after the retries
+// or the time budget of the batch, as configured by BatchRetries and BatchTimeout, ran out.
+var ErrBatchBudgetExhausted = errors.New("core/fetcher: batch budget exhausted")
+
+// budgetExhaustedError is ErrBatchBudgetExhausted along with the error of the last attempt, so
+// that both can be matched with errors.Is and errors.As.
+type budgetExhaustedError struct {
+	err error
+}
+
+func (e *budgetExhaustedError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrBatchBudgetExhausted, e.err)
+}
+
+func (e *budgetExhaustedError) Is(target error) bool {
+	return target == ErrBatchBudgetExhausted
+}
+
+func (e *budgetExhaustedError) Unwrap() error {
+	return e.err
+}
+
+// batchRetries are the retries shared by the fetches of a batch, as configured by BatchRetries.
+type batchRetries struct {
+	left atomic.Int64
+	// the context of the batch before it is bounded by BatchTimeout, so that its own deadline is
+	// told apart from the one of the batch
+	parent context.Context
+}
+
+// newBatchRetries creates the batchRetries of the batch with the given context.
+func (f *BlockFetcher) newBatchRetries(ctx context.Context) *batchRetries {
+	retries := &batchRetries{parent: ctx}
+	retries.left.Store(int64(f.params.BatchRetries))
+	return retries
+}
+
+// GetBlocks queries Core for the blocks at the given, not necessarily contiguous, heights using
+// up to `concurrency` parallel requests. Results are keyed by height, and a failure to fetch
+// a block at one height is reported in its result without failing the whole batch.
+// Failed heights are retried as long as the budget of the batch lasts.
+func (f *BlockFetcher) GetBlocks(
+	ctx context.Context,
+	heights []int64,
+	concurrency int,
+) (map[int64]*BlockResult, error) {
+	return f.getBlocks(ctx, heights, concurrency, nil, nil)
+}
+
+// getBlocks is GetBlocks calling onFetched, if set, with the height of every completed fetch.
+// The calls are serialized. If the adaptive limit is set, it further bounds the fetches in flight
+// below the concurrency.
+func (f *BlockFetcher) getBlocks(
+	ctx context.Context,
+	heights []int64,
+	concurrency int,
+	adaptive *adaptiveLimit,
+	onFetched func(height int64),
+) (map[int64]*BlockResult, error) {
+	if concurrency <= 0 {
+		return nil, fmt.Errorf("core/fetcher: invalid concurrency: %d", concurrency)
+	}
+
+	retries := f.newBatchRetries(ctx)
+	if f.params.BatchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.params.BatchTimeout)
+		defer cancel()
+	}
+
+	var (
+		resultsLk sync.Mutex
+		results   = make(map[int64]*BlockResult, len(heights))
+		requested = make(map[int64]struct{}, len(heights))
+	)
+	errGroup := &errgroup.Group{}
+	errGroup.SetLimit(concurrency)
+	for _, height := range heights {
+		if _, ok := requested[height]; ok {
+			continue
+		}
+		requested[height] = struct{}{}
+
+		height := height
+		errGroup.Go(func() error {
+			res, release := f.getBlockInLimit(ctx, height, retries, adaptive)
+			defer release()
+
+			resultsLk.Lock()
+			defer resultsLk.Unlock()
+			results[height] = res
+			if onFetched != nil {
+				onFetched(height)
+			}
+			return nil
+		})
+	}
+
+	// results are only ever failed individually
+	_ = errGroup.Wait()
+	return results, nil
+}
+
+// GetBlocksOrdered is like GetBlocks, but returns the results in the order of the given heights,
+// one for every requested height, including the repeated ones.
+func (f *BlockFetcher) GetBlocksOrdered(
+	ctx context.Context,
+	heights []int64,
+	concurrency int,
+) ([]*BlockResult, error) {
+	results, err := f.GetBlocks(ctx, heights, concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := make([]*BlockResult, len(heights))
+	for i, height := range heights {
+		ordered[i] = results[height]
+	}
+	return ordered, nil
+}
+
+// getBlockWithRetries queries Core for the block at the given height, retrying a failure while
+// the retries shared by the batch last and the context of the batch is not done.
+func (f *BlockFetcher) getBlockWithRetries(
+	ctx context.Context,
+	height int64,
+	retries *batchRetries,
+) *BlockResult {
+	for attempt := 1; ; attempt++ {
+		block, cached, err := f.getObservedBlock(ctx, &height)
+		if err == nil {
+			return &BlockResult{Block: block, Cached: cached}
+		}
+		var (
+			errPruned *ErrHeightPruned
+			errDecode *ErrBlockDecode
+		)
+		if errors.Is(err, ErrInvalidHeight) || errors.As(err, &errPruned) || errors.As(err, &errDecode) {
+			// retrying won't help
+			return &BlockResult{Err: err}
+		}
+		if ctx.Err() == nil && retries.left.Add(-1) >= 0 {
+			select {
+			case <-f.params.Clock.After(f.params.Backoff.Next(attempt)):
+				continue
+			case <-ctx.Done():
+			}
+		}
+
+		switch {
+		case retries.parent.Err() != nil:
+			// canceled or past the deadline of the caller
+		case ctx.Err() != nil:
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				// past the BatchTimeout
+				return &BlockResult{Err: &budgetExhaustedError{err: err}}
+			}
+		case f.params.BatchRetries > 0:
+			// out of the retries
+			return &BlockResult{Err: &budgetExhaustedError{err: err}}
+		}
+		return &BlockResult{Err: err}
+	}
+}
+
+// getBlockInBudget is getBlockWithRetries holding the size of the block in the memory budget, if
+// set, from before the fetch until the returned release func is called.
+func (f *BlockFetcher) getBlockInBudget(
+	ctx context.Context,
+	height int64,
+	retries *batchRetries,
+) (*BlockResult, func()) {
+	if f.budget == nil {
+		return f.getBlockWithRetries(ctx, height, retries), func() {}
+	}
+
+	reserved, err := f.budget.reserve(ctx)
+	if err != nil {
+		return &BlockResult{Err: fmt.Errorf("core/fetcher: waiting for memory budget: %w", err)}, func() {}
+	}
+	res := f.getBlockWithRetries(ctx, height, retries)
+	return res, f.settleBudget(reserved, res)
+}
+
+// settleBudget settles the size reserved in the memory budget, if set, to the size of the fetched
+// block, returning the func releasing it.
+func (f *BlockFetcher) settleBudget(reserved int, res *BlockResult) func() {
+	if f.budget == nil {
+		return func() {}
+	}
+	var size int
+	if res.Block != nil {
+		size = res.Block.Size()
+	}
+	f.budget.settle(reserved, size)
+	return func() { f.budget.release(size) }
+}
+
+// getBlockInLimit is getBlockInBudget holding a slot of the adaptive limit, if set, for the fetch.
+func (f *BlockFetcher) getBlockInLimit(
+	ctx context.Context,
+	height int64,
+	retries *batchRetries,
+	adaptive *adaptiveLimit,
+) (*BlockResult, func()) {
+	if adaptive == nil {
+		return f.getBlockInBudget(ctx, height, retries)
+	}
+
+	complete, err := adaptive.acquire(ctx)
+	if err != nil {
+		return &BlockResult{Err: fmt.Errorf("core/fetcher: waiting for concurrency: %w", err)}, func() {}
+	}
+	res, release := f.getBlockInBudget(ctx, height, retries)
+	complete(res)
+	return res, release
+}
+
+// GetBlockRange queries Core for the contiguous range of blocks [from:to] using up to
+// `concurrency` parallel requests and returns them ordered by height.
+// On failure, the blocks preceding the first failed height are returned along with the error.
+// The ProgressObserver, if set, is notified of every fetched block. With ClampToAvailable, the
+// range is trimmed to the heights retained by Core first, as by ClampRange.
+func (f *BlockFetcher) GetBlockRange(ctx context.Context, from, to int64, concurrency int) ([]*types.Block, error) {
+	from, to, err := f.clampRange(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var onFetched func(height int64)
+	if f.params.ProgressObserver != nil {
+		event := ProgressEvent{Total: to - from + 1}
+		onFetched = func(height int64) {
+			event.Height = height
+			event.Done++
+			f.params.ProgressObserver(event)
+		}
+	}
+	return f.getBlockRange(ctx, from, to, concurrency, nil, onFetched)
+}
+
+// GetBlockRangeAdaptive is like GetBlockRange, but adapts the number of parallel requests to the
+// latency of Core within the [minConcurrency:maxConcurrency] bounds. Starting at the lower bound,
+// it adds a request every time a round of requests completes without slowing down, and halves
+// the requests once one fails or takes over twice as long as the fastest one, maximizing the
+// throughput without manual tuning. The ProgressObserver, if set, is also told the concurrency.
+func (f *BlockFetcher) GetBlockRangeAdaptive(
+	ctx context.Context,
+	from, to int64,
+	minConcurrency, maxConcurrency int,
+) ([]*types.Block, error) {
+	if minConcurrency <= 0 || minConcurrency > maxConcurrency {
+		return nil, fmt.Errorf("core/fetcher: invalid concurrency bounds: [%d:%d]", minConcurrency, maxConcurrency)
+	}
+	from, to, err := f.clampRange(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	adaptive := newAdaptiveLimit(minConcurrency, maxConcurrency, f.params.Clock)
+	var onFetched func(height int64)
+	if f.params.ProgressObserver != nil {
+		event := ProgressEvent{Total: to - from + 1}
+		onFetched = func(height int64) {
+			event.Height = height
+			event.Done++
+			event.Concurrency = adaptive.current()
+			f.params.ProgressObserver(event)
+		}
+	}
+	return f.getBlockRange(ctx, from, to, maxConcurrency, adaptive, onFetched)
+}
+
+// ClampRange trims the range [from:to] to the heights retained by Core, i.e. from its
+// EarliestHeight on, returning the effective range, so that a range partially pruned by Core can
+// be fetched rather than failing. ErrHeightPruned is returned if the whole range is pruned.
+func (f *BlockFetcher) ClampRange(ctx context.Context, from, to int64) (int64, int64, error) {
+	earliest, err := f.EarliestHeight(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("core/fetcher: getting earliest height: %w", err)
+	}
+	if to < earliest {
+		return 0, 0, &ErrHeightPruned{Height: to, Lowest: earliest}
+	}
+	if from < earliest {
+		log.Warnw("clamping range to available heights", "from", from, "to", to, "earliest", earliest)
+		from = earliest
+	}
+	return from, to, nil
+}
+
+// clampRange is ClampRange if ClampToAvailable is set, and a no-op otherwise.
+func (f *BlockFetcher) clampRange(ctx context.Context, from, to int64) (int64, int64, error) {
+	if !f.params.ClampToAvailable {
+		return from, to, nil
+	}
+	return f.ClampRange(ctx, from, to)
+}
+
+// getBlockRange is GetBlockRange calling onFetched, if set, and limited by the adaptive limit, if
+// set, as getBlocks does.
+func (f *BlockFetcher) getBlockRange(
+	ctx context.Context,
+	from, to int64,
+	concurrency int,
+	adaptive *adaptiveLimit,
+	onFetched func(height int64),
+) ([]*types.Block, error) {
+	if err := validateHeight(&from); err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, fmt.Errorf("core/fetcher: invalid range: from %d is above to %d", from, to)
+	}
+
+	heights := make([]int64, 0, to-from+1)
+	for height := from; height <= to; height++ {
+		heights = append(heights, height)
+	}
+	results, err := f.getBlocks(ctx, heights, concurrency, adaptive, onFetched)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := make([]*types.Block, 0, len(heights))
+	for _, height := range heights {
+		res := results[height]
+		if res.Err != nil {
+			return blocks, fmt.Errorf("core/fetcher: getting block at height %d: %w", height, res.Err)
+		}
+		if len(blocks) > 0 {
+			if err := f.checkTime(blocks[len(blocks)-1], res.Block); err != nil {
+				return blocks, err
+			}
+		}
+		blocks = append(blocks, res.Block)
+	}
+	return blocks, nil
+}
+
+// GetSignedHeaderRange queries Core for the signed headers, i.e. the headers and the Commits
+// without the block bodies, of the contiguous range [from:to] using up to `concurrency` parallel
+// requests and returns them ordered by height, e.g. for light syncing. Every header is verified
+// to link to its predecessor and to be committed by its ValidatorSet, which is only re-fetched
+// once the validators change.
+func (f *BlockFetcher) GetSignedHeaderRange(
+	ctx context.Context,
+	from, to int64,
+	concurrency int,
+) ([]*types.SignedHeader, error) {
+	if err := validateHeight(&from); err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, fmt.Errorf("core/fetcher: invalid range: from %d is above to %d", from, to)
+	}
+	if concurrency <= 0 {
+		return nil, fmt.Errorf("core/fetcher: invalid concurrency: %d", concurrency)
+	}
+
+	headers := make([]*types.SignedHeader, to-from+1)
+	errGroup, fetchCtx := errgroup.WithContext(ctx)
+	errGroup.SetLimit(concurrency)
+	for i := range headers {
+		i, height := i, from+int64(i)
+		errGroup.Go(func() (err error) {
+			headers[i], err = f.GetSignedHeader(fetchCtx, &height)
+			return err
+		})
+	}
+	if err := errGroup.Wait(); err != nil {
+		return nil, err
+	}
+
+	// verify in order, so that the cached ValidatorSet is reused across the heights
+	for i, sh := range headers {
+		if i > 0 && !bytes.Equal(sh.LastBlockID.Hash, headers[i-1].Commit.BlockID.Hash) {
+			return nil, fmt.Errorf("core/fetcher: header at height %d does not link to the previous header: "+
+				"last block %X, previous block %X", sh.Height, sh.LastBlockID.Hash, headers[i-1].Commit.BlockID.Hash)
+		}
+		if err := f.verifySignedHeader(ctx, sh); err != nil {
+			return nil, err
+		}
+	}
+	return headers, nil
+}
+
+// CommitResult is the outcome of fetching the Commit at a single height of a batch.
+type CommitResult struct {
+	Commit *types.Commit
+	Err    error
+}
+
+// GetCommits queries Core for the Commits at the given, not necessarily contiguous, heights using
+// up to `concurrency` parallel requests, e.g. to verify a batch of previously fetched headers.
+// Results are keyed by height, and a failure at one height is reported in its result without
+// failing the whole batch. If `verify` is set, every Commit is also verified to be signed by
+// the ValidatorSet of its height, which is only re-fetched once the validators change.
+func (f *BlockFetcher) GetCommits(
+	ctx context.Context,
+	heights []int64,
+	concurrency int,
+	verify bool,
+) (map[int64]*CommitResult, error) {
+	if concurrency <= 0 {
+		return nil, fmt.Errorf("core/fetcher: invalid concurrency: %d", concurrency)
+	}
+
+	var (
+		headersLk sync.Mutex
+		headers   = make(map[int64]*types.SignedHeader, len(heights))
+		results   = make(map[int64]*CommitResult, len(heights))
+	)
+	errGroup := &errgroup.Group{}
+	errGroup.SetLimit(concurrency)
+	for _, height := range heights {
+		if _, ok := results[height]; ok {
+			continue
+		}
+		res := &CommitResult{}
+		results[height] = res
+
+		height := height
+		errGroup.Go(func() error {
+			sh, err := f.GetSignedHeader(ctx, &height)
+			if err != nil {
+				res.Err = fmt.Errorf("core/fetcher: getting commit at height %d: %w", height, err)
+				return nil
+			}
+			res.Commit = sh.Commit
+
+			headersLk.Lock()
+			defer headersLk.Unlock()
+			headers[height] = sh
+			return nil
+		})
+	}
+	// results are only ever failed individually
+	_ = errGroup.Wait()
+	if !verify {
+		return results, nil
+	}
+
+	// verify in order, so that the cached ValidatorSet is reused across the heights
+	verified := make([]int64, 0, len(headers))
+	for height := range headers {
+		verified = append(verified, height)
+	}
+	sort.Slice(verified, func(i, j int) bool { return verified[i] < verified[j] })
+	for _, height := range verified {
+		if err := f.verifySignedHeader(ctx, headers[height]); err != nil {
+			results[height].Commit, results[height].Err = nil, err
+		}
+	}
+	return results, nil
+}
+
+// verifySignedHeader ensures the Commit of the signed header is signed by more than 2/3 of its
+// ValidatorSet.
+func (f *BlockFetcher) verifySignedHeader(ctx context.Context, sh *types.SignedHeader) error {
+	valSet, err := f.validatorSetByHash(ctx, sh.Height, sh.ValidatorsHash)
+	if err != nil {
+		return fmt.Errorf("core/fetcher: getting validator set at height %d: %w", sh.Height, err)
+	}
+	if err := sh.ValidateBasic(sh.ChainID); err != nil {
+		return fmt.Errorf("core/fetcher: invalid signed header at height %d: %w", sh.Height, err)
+	}
+	if err := valSet.VerifyCommit(sh.ChainID, sh.Commit.BlockID, sh.Height, sh.Commit); err != nil {
+		return fmt.Errorf("core/fetcher: verifying commit at height %d: %w", sh.Height, err)
+	}
+	return nil
+}
+
+// StreamBlockRange is like GetBlockRange, but streams the blocks ordered by height over the
+// returned channel as they arrive, with up to `concurrency` blocks fetched ahead of the consumer.
+// The channel is closed after the last block or the first failed result, once no fetches are
+// outstanding. Calling the returned cancel func stops the stream early, aborting the outstanding
+// fetches, and must be called once the stream is no longer consumed. The blocks are held in the
+// MaxInFlightBytes budget, if set, until the consumer receives them.
+func (f *BlockFetcher) StreamBlockRange(
+	ctx context.Context,
+	from, to int64,
+	concurrency int,
+) (<-chan *BlockResult, context.CancelFunc, error) {
+	if err := validateHeight(&from); err != nil {
+		return nil, nil, err
+	}
+	if from > to {
+		return nil, nil, fmt.Errorf("core/fetcher: invalid range: from %d is above to %d", from, to)
+	}
+	if concurrency <= 0 {
+		return nil, nil, fmt.Errorf("core/fetcher: invalid concurrency: %d", concurrency)
+	}
+
+	retries := f.newBatchRetries(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+
+	// the fetches in flight in height order, together with the one awaited by the consumer
+	// bounded by the concurrency
+	pending := make(chan chan streamedBlock, concurrency-1)
+	// tracks the producer and the fetches, so that the stream only closes once they're done
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(pending)
+		for height := from; height <= to; height++ {
+			// reserved in height order, so that the block awaited by the consumer never waits for
+			// the budget held by the blocks following it
+			var reserved int
+			if f.budget != nil {
+				var err error
+				if reserved, err = f.budget.reserve(ctx); err != nil {
+					return
+				}
+			}
+			resCh := make(chan streamedBlock, 1)
+			select {
+			case pending <- resCh:
+			case <-ctx.Done():
+				if f.budget != nil {
+					f.budget.release(reserved)
+				}
+				return
+			}
+
+			height := height
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				res := f.getBlockWithRetries(ctx, height, retries)
+				release := f.settleBudget(reserved, res)
+				if res.Err != nil {
+					res.Err = fmt.Errorf("core/fetcher: getting block at height %d: %w", height, res.Err)
+				}
+				resCh <- streamedBlock{res: res, release: release}
+			}()
+		}
+	}()
+
+	out := make(chan *BlockResult)
+	go func() {
+		// the fetch awaited by the consumer, if any
+		var awaited chan streamedBlock
+		defer func() {
+			cancel()
+			wg.Wait()
+			// release the blocks never received by the consumer
+			if awaited != nil {
+				(<-awaited).release()
+			}
+			for resCh := range pending {
+				(<-resCh).release()
+			}
+			close(out)
+		}()
+
+		var prev *types.Block
+		for awaited = range pending {
+			var block streamedBlock
+			select {
+			case block = <-awaited:
+				awaited = nil
+			case <-ctx.Done():
+				return
+			}
+			res := block.res
+			if res.Err == nil && prev != nil {
+				if err := f.checkTime(prev, res.Block); err != nil {
+					res = &BlockResult{Err: err}
+				}
+			}
+
+			select {
+			case out <- res:
+				block.release()
+			case <-ctx.Done():
+				block.release()
+				return
+			}
+			if res.Err != nil {
+				return
+			}
+			prev = res.Block
+		}
+	}()
+	return out, cancel, nil
+}
+
+// streamedBlock is the result of a fetch of StreamBlockRange, along with the func releasing the
+// block from the memory budget.
+type streamedBlock struct {
+	res     *BlockResult
+	release func()
+}
+
+// ProgressEvent reports the progress of GetBlockRange, GetBlockRangeAdaptive or ExportRange to
+// the ProgressObserver.
+type ProgressEvent struct {
+	// Height is the height of the block fetched or exported last. The heights of GetBlockRange
+	// are fetched in parallel, so they may be reported out of order.
+	Height int64
+	// Done is the number of the blocks of the range fetched or exported so far, including those
+	// exported by the previous, interrupted ExportRange calls.
+	Done int64
+	// Total is the number of the blocks in the range.
+	Total int64
+	// Concurrency is the number of parallel requests GetBlockRangeAdaptive settled on by the
+	// time the block was fetched. It is zero for the other calls.
+	Concurrency int
+}
+
+// Fraction returns the fraction of the range completed, from 0 to 1.
+func (e ProgressEvent) Fraction() float64 {
+	if e.Total <= 0 {
+		return 0
+	}
+	return float64(e.Done) / float64(e.Total)
+}
+
+// checkTime ensures the time of the block is after the time of its predecessor,
+// as configured by the TimeCheck parameter.
+func (f *BlockFetcher) checkTime(prev, block *types.Block) error {
+	if f.params.TimeCheck == TimeCheckOff || block.Time.After(prev.Time) {
+		return nil
+	}
+
+	err := &ErrNonMonotonicTime{
+		Height:   block.Height,
+		Time:     block.Time,
+		PrevTime: prev.Time,
+	}
+	if f.params.TimeCheck == TimeCheckFail {
+		return err
+	}
+	log.Warnw("fetched block goes back in time", "height", block.Height,
+		"time", block.Time, "prev_time", prev.Time)
+	return nil
+}
+
+// ErrNonMonotonicTime is returned when a fetched block's time is not after
+// the time of the preceding block.
+type ErrNonMonotonicTime struct {
+	Height   int64
+	Time     time.Time
+	PrevTime time.Time
+}
+
+func (e *ErrNonMonotonicTime) Error() string {
+	return fmt.Sprintf("core/fetcher: block at height %d has time %s, which is not after previous block time %s",
+		e.Height, e.Time, e.PrevTime)
+}
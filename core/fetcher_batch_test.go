@@ -0,0 +1,666 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	rpctypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestBlockFetcher_GetBlocks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	const lowest = 100
+	client := &mockClient{
+		block: func(_ context.Context, height *int64) (*ctypes.ResultBlock, error) {
+			if *height < lowest {
+				return nil, &rpctypes.RPCError{
+					Code:    -32603,
+					Message: "Internal error",
+					Data:    fmt.Sprintf("height %d is not available, lowest height is %d", *height, lowest),
+				}
+			}
+			return &ctypes.ResultBlock{Block: newHeightBlock(*height)}, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	heights := []int64{3000, 1, 1000, 2000, 1000}
+	results, err := fetcher.GetBlocks(ctx, heights, 2)
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	for _, height := range []int64{1000, 2000, 3000} {
+		require.NoError(t, results[height].Err)
+		assert.Equal(t, height, results[height].Block.Height)
+	}
+
+	var errPruned *ErrHeightPruned
+	require.ErrorAs(t, results[1].Err, &errPruned)
+	assert.EqualValues(t, 1, errPruned.Height)
+	assert.EqualValues(t, lowest, errPruned.Lowest)
+	assert.Nil(t, results[1].Block)
+}
+
+func TestBlockFetcher_GetBlocks_Cached(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	var calls atomic.Int32
+	client := &mockClient{
+		block: func(_ context.Context, height *int64) (*ctypes.ResultBlock, error) {
+			calls.Add(1)
+			return &ctypes.ResultBlock{Block: newHeightBlock(*height)}, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client, WithCacheSize[FetcherParameters](128))
+	require.NoError(t, err)
+
+	results, err := fetcher.GetBlocks(ctx, []int64{1, 2}, 2)
+	require.NoError(t, err)
+	for _, res := range results {
+		require.NoError(t, res.Err)
+		assert.False(t, res.Cached)
+	}
+
+	// the second fetch of the same height is served from the cache
+	results, err = fetcher.GetBlocks(ctx, []int64{2, 3}, 2)
+	require.NoError(t, err)
+	assert.True(t, results[2].Cached)
+	assert.False(t, results[3].Cached)
+	assert.EqualValues(t, 3, calls.Load())
+}
+
+func TestBlockFetcher_GetBlockRange_TimeCheck(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	// the block at height 3 goes back in time
+	genesis := time.Now()
+	times := map[int64]time.Time{
+		1: genesis,
+		2: genesis.Add(time.Second * 2),
+		3: genesis.Add(time.Second),
+		4: genesis.Add(time.Second * 3),
+	}
+	client := &mockClient{
+		block: func(_ context.Context, height *int64) (*ctypes.ResultBlock, error) {
+			header := types.Header{Height: *height, Time: times[*height], DataHash: emptyDataHash()}
+			return &ctypes.ResultBlock{Block: &types.Block{Header: header}}, nil
+		},
+	}
+
+	t.Run("warn", func(t *testing.T) {
+		fetcher, err := NewBlockFetcher(client, WithTimeCheck(TimeCheckWarn))
+		require.NoError(t, err)
+
+		blocks, err := fetcher.GetBlockRange(ctx, 1, 4, 2)
+		require.NoError(t, err)
+		require.Len(t, blocks, 4)
+		for i, block := range blocks {
+			assert.EqualValues(t, i+1, block.Height)
+		}
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		fetcher, err := NewBlockFetcher(client, WithTimeCheck(TimeCheckFail))
+		require.NoError(t, err)
+
+		blocks, err := fetcher.GetBlockRange(ctx, 1, 4, 2)
+		var errTime *ErrNonMonotonicTime
+		require.ErrorAs(t, err, &errTime)
+		assert.EqualValues(t, 3, errTime.Height)
+		assert.Len(t, blocks, 2)
+	})
+}
+
+func TestBlockFetcher_GetBlockRange_Progress(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	client := &mockClient{
+		block: func(_ context.Context, height *int64) (*ctypes.ResultBlock, error) {
+			return &ctypes.ResultBlock{Block: newHeightBlock(*height)}, nil
+		},
+	}
+	var events []ProgressEvent
+	fetcher, err := NewBlockFetcher(client, WithProgressObserver(func(event ProgressEvent) {
+		events = append(events, event)
+	}))
+	require.NoError(t, err)
+
+	_, err = fetcher.GetBlockRange(ctx, 11, 30, 4)
+	require.NoError(t, err)
+
+	require.Len(t, events, 20)
+	for i := 1; i < len(events); i++ {
+		assert.Greater(t, events[i].Fraction(), events[i-1].Fraction())
+	}
+	for _, event := range events {
+		assert.EqualValues(t, 20, event.Total)
+		assert.True(t, event.Height >= 11 && event.Height <= 30)
+	}
+	assert.Equal(t, 1.0, events[len(events)-1].Fraction())
+}
+
+func TestBlockFetcher_GetBlockRange_ClampToAvailable(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	const lowest = 10
+	client := &mockClient{
+		block: func(_ context.Context, height *int64) (*ctypes.ResultBlock, error) {
+			if *height < lowest {
+				return nil, &rpctypes.RPCError{
+					Code:    -32603,
+					Message: "Internal error",
+					Data:    fmt.Sprintf("height %d is not available, lowest height is %d", *height, lowest),
+				}
+			}
+			return &ctypes.ResultBlock{Block: newHeightBlock(*height)}, nil
+		},
+		status: func(context.Context) (*ctypes.ResultStatus, error) {
+			return &ctypes.ResultStatus{SyncInfo: ctypes.SyncInfo{EarliestBlockHeight: lowest}}, nil
+		},
+	}
+
+	// the pruned heights fail the range by default
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+	_, err = fetcher.GetBlockRange(ctx, 5, 15, 2)
+	var errPruned *ErrHeightPruned
+	require.ErrorAs(t, err, &errPruned)
+
+	var events []ProgressEvent
+	fetcher, err = NewBlockFetcher(client,
+		WithClampToAvailable[FetcherParameters](true),
+		WithProgressObserver(func(event ProgressEvent) {
+			events = append(events, event)
+		}),
+	)
+	require.NoError(t, err)
+	blocks, err := fetcher.GetBlockRange(ctx, 5, 15, 2)
+	require.NoError(t, err)
+	require.Len(t, blocks, 6)
+	for i, block := range blocks {
+		assert.EqualValues(t, lowest+i, block.Height)
+	}
+	require.Len(t, events, 6)
+	assert.EqualValues(t, 6, events[0].Total)
+
+	from, to, err := fetcher.ClampRange(ctx, 5, 15)
+	require.NoError(t, err)
+	assert.EqualValues(t, lowest, from)
+	assert.EqualValues(t, 15, to)
+	// the range within the available heights is kept
+	from, to, err = fetcher.ClampRange(ctx, 12, 15)
+	require.NoError(t, err)
+	assert.EqualValues(t, 12, from)
+	assert.EqualValues(t, 15, to)
+	// the range pruned as a whole
+	_, err = fetcher.GetBlockRange(ctx, 1, 9, 2)
+	require.ErrorAs(t, err, &errPruned)
+	assert.EqualValues(t, lowest, errPruned.Lowest)
+}
+
+func TestBlockFetcher_GetBlockRange_MaxInFlightBytes(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	const txSize = 256 << 10
+	// the transactions of the blocks from their fetch until they are stored, below the sizes held
+	// in the budget
+	var inFlight, inFlightBytes peakGauge
+	client := &mockClient{
+		block: func(_ context.Context, height *int64) (*ctypes.ResultBlock, error) {
+			inFlight.add(1)
+			defer inFlight.add(-1)
+			inFlightBytes.add(txSize)
+			time.Sleep(time.Millisecond * 5)
+
+			block := newHeightBlock(*height)
+			block.Txs = types.Txs{make(types.Tx, txSize)}
+			return &ctypes.ResultBlock{Block: block}, nil
+		},
+	}
+
+	const budget = 4*txSize + 4<<10
+	fetcher, err := NewBlockFetcher(client,
+		WithDataHashVerification(false),
+		WithCacheSize(0),
+		WithMaxInFlightBytes(budget),
+		WithProgressObserver(func(ProgressEvent) {
+			inFlightBytes.add(-txSize)
+		}),
+	)
+	require.NoError(t, err)
+
+	blocks, err := fetcher.GetBlockRange(ctx, 1, 40, 16)
+	require.NoError(t, err)
+	require.Len(t, blocks, 40)
+
+	assert.LessOrEqual(t, inFlightBytes.peak.Load(), int64(budget))
+	assert.Greater(t, inFlightBytes.peak.Load(), int64(3*txSize))
+	assert.LessOrEqual(t, inFlight.peak.Load(), int64(4))
+	assert.Zero(t, fetcher.budget.used)
+}
+
+func TestBlockFetcher_StreamBlockRange_MaxInFlightBytes(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	const txSize = 256 << 10
+	var fetched atomic.Int64
+	// the transactions of the blocks from their fetch until they are received, below the sizes
+	// held in the budget
+	var inFlightBytes peakGauge
+	client := &mockClient{
+		block: func(_ context.Context, height *int64) (*ctypes.ResultBlock, error) {
+			defer fetched.Add(1)
+			inFlightBytes.add(txSize)
+			block := newHeightBlock(*height)
+			block.Txs = types.Txs{make(types.Tx, txSize)}
+			return &ctypes.ResultBlock{Block: block}, nil
+		},
+	}
+
+	const budget = 4*txSize + 4<<10
+	fetcher, err := NewBlockFetcher(client, WithDataHashVerification(false), WithMaxInFlightBytes(budget))
+	require.NoError(t, err)
+
+	blocks, stop, err := fetcher.StreamBlockRange(ctx, 1, 40, 16)
+	require.NoError(t, err)
+	for height := int64(1); height <= 20; height++ {
+		res := <-blocks
+		inFlightBytes.add(-txSize)
+		require.NoError(t, res.Err)
+		require.Equal(t, height, res.Block.Height)
+		// the slow consumer holds the fetches back, as the blocks it hasn't received count
+		time.Sleep(time.Millisecond * 5)
+		assert.LessOrEqual(t, fetched.Load()-height, int64(4))
+	}
+	stop()
+	for range blocks {
+		// drain until the channel is closed
+	}
+
+	assert.LessOrEqual(t, inFlightBytes.peak.Load(), int64(budget))
+	// the blocks never received are released as well
+	assert.Zero(t, fetcher.budget.used)
+}
+
+func TestBlockFetcher_GetBlockRangeAdaptive(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	t.Cleanup(cancel)
+
+	// Core slows down past the first heights, as if overloaded
+	const slowFrom = 61
+	var inFlight, maxInFlight atomic.Int32
+	client := &mockClient{
+		block: func(_ context.Context, height *int64) (*ctypes.ResultBlock, error) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				max := maxInFlight.Load()
+				if n <= max || maxInFlight.CompareAndSwap(max, n) {
+					break
+				}
+			}
+			if *height < slowFrom {
+				time.Sleep(time.Millisecond * 2)
+			} else {
+				time.Sleep(time.Millisecond * 40)
+			}
+			return &ctypes.ResultBlock{Block: newHeightBlock(*height)}, nil
+		},
+	}
+	var (
+		eventsLk sync.Mutex
+		events   []ProgressEvent
+	)
+	fetcher, err := NewBlockFetcher(client, WithCacheSize(0), WithProgressObserver(func(event ProgressEvent) {
+		eventsLk.Lock()
+		defer eventsLk.Unlock()
+		events = append(events, event)
+	}))
+	require.NoError(t, err)
+
+	const minConcurrency, maxConcurrency = 1, 8
+	blocks, err := fetcher.GetBlockRangeAdaptive(ctx, 1, 80, minConcurrency, maxConcurrency)
+	require.NoError(t, err)
+	require.Len(t, blocks, 80)
+
+	eventsLk.Lock()
+	defer eventsLk.Unlock()
+	require.Len(t, events, 80)
+	var peak int
+	for _, event := range events {
+		assert.GreaterOrEqual(t, event.Concurrency, minConcurrency)
+		assert.LessOrEqual(t, event.Concurrency, maxConcurrency)
+		if event.Concurrency > peak {
+			peak = event.Concurrency
+		}
+	}
+	// ramped up while fast and backed off once slow
+	assert.Greater(t, peak, minConcurrency)
+	assert.Equal(t, minConcurrency, events[len(events)-1].Concurrency)
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(maxConcurrency))
+
+	_, err = fetcher.GetBlockRangeAdaptive(ctx, 1, 10, 4, 2)
+	require.Error(t, err)
+}
+
+func TestBlockFetcher_GetSignedHeaderRange(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	valSet, vals := RandValidatorSet(3, 1)
+	chain, err := MakeSignedBlockChain(1, 30, valSet, vals)
+	require.NoError(t, err)
+	client := newSignedChainClient(chain)
+	var blockCalls, validatorsCalls atomic.Int32
+	block, validators := client.block, client.validators
+	client.block = func(ctx context.Context, height *int64) (*ctypes.ResultBlock, error) {
+		blockCalls.Add(1)
+		return block(ctx, height)
+	}
+	client.validators = func(ctx context.Context, height *int64, page, perPage *int) (*ctypes.ResultValidators, error) {
+		validatorsCalls.Add(1)
+		return validators(ctx, height, page, perPage)
+	}
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	headers, err := fetcher.GetSignedHeaderRange(ctx, 5, 30, 4)
+	require.NoError(t, err)
+	require.Len(t, headers, 26)
+	for i, sh := range headers {
+		assert.EqualValues(t, 5+i, sh.Height)
+		require.NoError(t, valSet.VerifyCommit(sh.ChainID, sh.Commit.BlockID, sh.Height, sh.Commit))
+		if i > 0 {
+			assert.Equal(t, headers[i-1].Commit.BlockID, sh.LastBlockID)
+		}
+	}
+	// the block bodies are never transferred and the set is fetched once
+	assert.Zero(t, blockCalls.Load())
+	assert.EqualValues(t, 1, validatorsCalls.Load())
+
+	// a header not linking to its predecessor breaks the chain
+	forked, err := MakeSignedBlockChain(1, 30, valSet, vals)
+	require.NoError(t, err)
+	chain[19] = forked[19]
+	_, err = fetcher.GetSignedHeaderRange(ctx, 5, 30, 4)
+	assert.ErrorContains(t, err, "does not link to the previous header")
+}
+
+func TestBlockFetcher_GetCommits(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	valSet, vals := RandValidatorSet(3, 1)
+	chain, err := MakeSignedBlockChain(1, 30, valSet, vals)
+	require.NoError(t, err)
+	// the commit at height 20 is not signed for its block
+	broken := *chain[19]
+	broken.Commit = chain[18].Commit
+	chain[19] = &broken
+
+	client := newSignedChainClient(chain)
+	var validatorsCalls atomic.Int32
+	validators := client.validators
+	client.validators = func(ctx context.Context, height *int64, page, perPage *int) (*ctypes.ResultValidators, error) {
+		validatorsCalls.Add(1)
+		return validators(ctx, height, page, perPage)
+	}
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	heights := []int64{25, 3, 20, 12, 3}
+	results, err := fetcher.GetCommits(ctx, heights, 2, true)
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	for _, height := range []int64{3, 12, 25} {
+		res := results[height]
+		require.NoError(t, res.Err)
+		assert.EqualValues(t, height, res.Commit.Height)
+		assert.NoError(t, valSet.VerifyCommit(signedBlockChainID, res.Commit.BlockID, height, res.Commit))
+	}
+	assert.Error(t, results[20].Err)
+	assert.Nil(t, results[20].Commit)
+	// the set is fetched once
+	assert.EqualValues(t, 1, validatorsCalls.Load())
+
+	// without the verification, the commits are taken as served
+	results, err = fetcher.GetCommits(ctx, heights, 2, false)
+	require.NoError(t, err)
+	require.NoError(t, results[20].Err)
+	assert.Equal(t, chain[18].Commit, results[20].Commit)
+}
+
+func TestBlockFetcher_GetBlocks_Budget(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	// odd heights never succeed
+	var calls atomic.Int32
+	errFlaky := errors.New("flaky")
+	client := &mockClient{
+		block: func(_ context.Context, height *int64) (*ctypes.ResultBlock, error) {
+			calls.Add(1)
+			if *height%2 == 1 {
+				return nil, errFlaky
+			}
+			return &ctypes.ResultBlock{Block: newHeightBlock(*height)}, nil
+		},
+	}
+
+	heights := make([]int64, 0, 20)
+	for height := int64(1); height <= 20; height++ {
+		heights = append(heights, height)
+	}
+
+	checkResults := func(t *testing.T, results map[int64]*BlockResult) {
+		require.Len(t, results, len(heights))
+		for height, res := range results {
+			if height%2 == 1 {
+				assert.ErrorIs(t, res.Err, ErrBatchBudgetExhausted)
+				// along with the cause
+				assert.ErrorIs(t, res.Err, errFlaky)
+				continue
+			}
+			require.NoError(t, res.Err)
+			assert.Equal(t, height, res.Block.Height)
+		}
+	}
+
+	t.Run("retries", func(t *testing.T) {
+		calls.Store(0)
+		fetcher, err := NewBlockFetcher(client,
+			WithBackoff[FetcherParameters](&fixedBackoff{interval: time.Millisecond}),
+			WithBatchRetries(5),
+		)
+		require.NoError(t, err)
+
+		results, err := fetcher.GetBlocks(ctx, heights, 4)
+		require.NoError(t, err)
+		checkResults(t, results)
+		// every height once plus the shared retries
+		assert.EqualValues(t, len(heights)+5, calls.Load())
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		fetcher, err := NewBlockFetcher(client,
+			WithBackoff[FetcherParameters](&fixedBackoff{interval: time.Millisecond * 50}),
+			WithBatchRetries(1000),
+			WithBatchTimeout(time.Millisecond*200),
+		)
+		require.NoError(t, err)
+
+		start := time.Now()
+		results, err := fetcher.GetBlocks(ctx, heights, 4)
+		require.NoError(t, err)
+		assert.Less(t, time.Since(start), time.Millisecond*500)
+		checkResults(t, results)
+	})
+
+	t.Run("caller deadline", func(t *testing.T) {
+		fetcher, err := NewBlockFetcher(client,
+			WithBackoff[FetcherParameters](&fixedBackoff{interval: time.Millisecond * 50}),
+			WithBatchRetries(1000),
+			WithBatchTimeout(time.Minute),
+		)
+		require.NoError(t, err)
+
+		// the deadline of the caller passing is not the budget of the batch running out
+		callerCtx, callerCancel := context.WithTimeout(ctx, time.Millisecond*200)
+		defer callerCancel()
+		results, err := fetcher.GetBlocks(callerCtx, heights, 4)
+		require.NoError(t, err)
+		for height, res := range results {
+			if height%2 == 1 {
+				assert.NotErrorIs(t, res.Err, ErrBatchBudgetExhausted)
+				assert.ErrorIs(t, res.Err, errFlaky)
+			}
+		}
+	})
+}
+
+func TestBlockFetcher_GetBlocksOrdered(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	client := &mockClient{
+		block: func(_ context.Context, height *int64) (*ctypes.ResultBlock, error) {
+			if *height == 13 {
+				return nil, errors.New("unlucky")
+			}
+			return &ctypes.ResultBlock{Block: newHeightBlock(*height)}, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	heights := []int64{42, 7, 13, 100, 7, 1}
+	results, err := fetcher.GetBlocksOrdered(ctx, heights, 3)
+	require.NoError(t, err)
+	require.Len(t, results, len(heights))
+
+	for i, height := range heights {
+		if height == 13 {
+			assert.Error(t, results[i].Err)
+			continue
+		}
+		require.NoError(t, results[i].Err)
+		assert.Equal(t, height, results[i].Block.Height)
+	}
+}
+
+func TestBlockFetcher_StreamBlockRange(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	const concurrency = 4
+	var calls atomic.Int32
+	client := &mockClient{
+		block: func(ctx context.Context, height *int64) (*ctypes.ResultBlock, error) {
+			calls.Add(1)
+			select {
+			case <-time.After(time.Millisecond):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			return &ctypes.ResultBlock{Block: newHeightBlock(*height)}, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	blocks, stop, err := fetcher.StreamBlockRange(ctx, 1, 100, concurrency)
+	require.NoError(t, err)
+	for height := int64(1); height <= 50; height++ {
+		res := <-blocks
+		require.NoError(t, res.Err)
+		assert.Equal(t, height, res.Block.Height)
+	}
+	stop()
+
+	// the stream ends without delivering the rest of the range, while the fetches in flight may
+	// fail with the canceled context
+	for res := range blocks {
+		if res.Err != nil {
+			continue
+		}
+		assert.LessOrEqual(t, res.Block.Height, int64(50+concurrency))
+	}
+	// no fetches are left once the stream is closed
+	stopped := calls.Load()
+	assert.LessOrEqual(t, stopped, int32(50+concurrency+1))
+	time.Sleep(time.Millisecond * 50)
+	assert.Equal(t, stopped, calls.Load())
+}
+
+func BenchmarkBlockFetcher_GetBlockRange_ConnPool(b *testing.B) {
+	const concurrency = 32
+	// every request to the endpoint takes a while, so throughput depends on parallel connections
+	srv := httptest.NewServer(newRPCHTTPHandler(func(_ string, params json.RawMessage) (any, error) {
+		var req struct {
+			Height int64 `json:"height,string"`
+		}
+		if err := json.Unmarshal(params, &req); err != nil {
+			return nil, err
+		}
+		time.Sleep(time.Millisecond * 5)
+		return &ctypes.ResultBlock{Block: types.MakeBlock(req.Height, types.Data{}, &types.Commit{})}, nil
+	}))
+	b.Cleanup(srv.Close)
+	u, err := url.Parse(srv.URL)
+	require.NoError(b, err)
+	ip, port, err := net.SplitHostPort(u.Host)
+	require.NoError(b, err)
+
+	for _, conns := range []int{1, 4, concurrency} {
+		b.Run(fmt.Sprintf("conns=%d", conns), func(b *testing.B) {
+			client, err := NewRemoteWithOptions(ip, port, WithConnPool(conns, conns))
+			require.NoError(b, err)
+			fetcher, err := NewBlockFetcher(client, WithCacheSize(0))
+			require.NoError(b, err)
+
+			ctx := context.Background()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := fetcher.GetBlockRange(ctx, 1, concurrency*2, concurrency)
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+// peakGauge is a gauge tracking the peak value it reached.
+type peakGauge struct {
+	value, peak atomic.Int64
+}
+
+func (g *peakGauge) add(delta int64) {
+	value := g.value.Add(delta)
+	for {
+		peak := g.peak.Load()
+		if value <= peak || g.peak.CompareAndSwap(peak, value) {
+			return
+		}
+	}
+}
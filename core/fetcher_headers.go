@@ -0,0 +1,77 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+const signedHeadersSubscriber = "SignedHeaders/Events"
+
+// SubscribeSignedHeaders subscribes to new block headers from Core, returning a channel of the
+// signed headers, i.e. the headers along with their Commits, e.g. for light nodes following the
+// chain. Unlike SubscribeNewBlockEvent, the block bodies are never transferred: the header events
+// carry no data and only the Commit of every header is fetched. The channel is closed once the
+// context is done or the subscription ends.
+func (f *BlockFetcher) SubscribeSignedHeaders(ctx context.Context) (<-chan *types.SignedHeader, error) {
+	eventChan, err := f.subscribe(ctx, signedHeadersSubscriber, newBlockHeaderQuery)
+	if err != nil {
+		return nil, fmt.Errorf("core/fetcher: subscribing to new block headers: %w", err)
+	}
+
+	headersCh := make(chan *types.SignedHeader)
+	go f.forwardSignedHeaders(ctx, eventChan, headersCh)
+	return headersCh, nil
+}
+
+// forwardSignedHeaders forwards the signed headers of the new block header events until the event
+// channel is closed or the context is done. The headers whose Commit cannot be fetched are skipped.
+func (f *BlockFetcher) forwardSignedHeaders(
+	ctx context.Context,
+	eventChan <-chan ctypes.ResultEvent,
+	headersCh chan<- *types.SignedHeader,
+) {
+	defer close(headersCh)
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+		if err := f.unsubscribe(ctx, signedHeadersSubscriber, newBlockHeaderQuery); err != nil {
+			log.Warnw("unsubscribing from new block headers", "err", err)
+		}
+	}()
+
+	for {
+		select {
+		case event, ok := <-eventChan:
+			if !ok {
+				return
+			}
+			data, ok := event.Data.(types.EventDataNewBlockHeader)
+			if !ok {
+				continue
+			}
+			height := data.Header.Height
+			sh, err := f.GetSignedHeader(ctx, &height)
+			if err != nil {
+				log.Errorw("getting signed header", "height", height, "err", err)
+				continue
+			}
+			if !bytes.Equal(sh.Hash(), data.Header.Hash()) {
+				log.Errorw("signed header does not match the header of the event", "height", height)
+				continue
+			}
+
+			select {
+			case headersCh <- sh:
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
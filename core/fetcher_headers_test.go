@@ -0,0 +1,39 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockFetcher_SubscribeSignedHeaders(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	t.Cleanup(cancel)
+
+	_, base := StartTestCoreWithApp(t)
+	client := NewCountingClient(base)
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	headers, err := fetcher.SubscribeSignedHeaders(ctx)
+	require.NoError(t, err)
+
+	var prevHeight int64
+	for i := 0; i < 3; i++ {
+		select {
+		case sh := <-headers:
+			require.NoError(t, sh.ValidateBasic(sh.ChainID))
+			assert.Equal(t, sh.Hash(), sh.Commit.BlockID.Hash)
+			assert.Greater(t, sh.Height, prevHeight)
+			prevHeight = sh.Height
+		case <-ctx.Done():
+			require.NoError(t, ctx.Err())
+		}
+	}
+	// only the commits are fetched, never the block bodies
+	assert.Zero(t, client.CallCount("Block"))
+	assert.GreaterOrEqual(t, client.CallCount("Commit"), 3)
+}
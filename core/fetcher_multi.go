@@ -0,0 +1,98 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+// multiSubscriberPrefix prefixes the subscriber of every SubscribeMulti call, which is unique, so
+// that the calls don't clash over the same queries.
+const multiSubscriberPrefix = "Multi/Events"
+
+// multiSubscriberCount numbers the SubscribeMulti calls.
+var multiSubscriberCount atomic.Uint64
+
+// TaggedEvent is an event received by SubscribeMulti, tagged with the query it matched.
+type TaggedEvent struct {
+	Query string
+	Event ctypes.ResultEvent
+}
+
+// SubscribeMulti subscribes to the events of Core matching any of the given queries, e.g. new
+// blocks, txs and validator set updates, returning a single channel of the events tagged with
+// the query they matched. The subscriptions are multiplexed over the one websocket connection of
+// the client. The channel is closed once the context is done or any of the subscriptions ends,
+// and the rest of the subscriptions are then unsubscribed.
+func (f *BlockFetcher) SubscribeMulti(ctx context.Context, queries []string) (<-chan TaggedEvent, error) {
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("core/fetcher: no queries to subscribe to")
+	}
+	seen := make(map[string]struct{}, len(queries))
+	for _, query := range queries {
+		if _, ok := seen[query]; ok {
+			return nil, fmt.Errorf("core/fetcher: duplicate query %q", query)
+		}
+		seen[query] = struct{}{}
+	}
+
+	subscriber := fmt.Sprintf("%s/%d", multiSubscriberPrefix, multiSubscriberCount.Add(1))
+	eventChans := make([]<-chan ctypes.ResultEvent, 0, len(queries))
+	for _, query := range queries {
+		eventChan, err := f.subscribe(ctx, subscriber, query)
+		if err != nil {
+			f.unsubscribeMulti(subscriber, queries[:len(eventChans)])
+			return nil, fmt.Errorf("core/fetcher: subscribing to %q: %w", query, err)
+		}
+		eventChans = append(eventChans, eventChan)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	taggedCh := make(chan TaggedEvent)
+	var wg sync.WaitGroup
+	for i, eventChan := range eventChans {
+		wg.Add(1)
+		go func(query string, eventChan <-chan ctypes.ResultEvent) {
+			defer wg.Done()
+			// one subscription ending ends them all
+			defer cancel()
+			for {
+				select {
+				case event, ok := <-eventChan:
+					if !ok {
+						return
+					}
+					select {
+					case taggedCh <- TaggedEvent{Query: query, Event: event}:
+					case <-ctx.Done():
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(queries[i], eventChan)
+	}
+	go func() {
+		defer cancel()
+		wg.Wait()
+		f.unsubscribeMulti(subscriber, queries)
+		close(taggedCh)
+	}()
+	return taggedCh, nil
+}
+
+// unsubscribeMulti unsubscribes the subscriber of a SubscribeMulti call from the given queries.
+func (f *BlockFetcher) unsubscribeMulti(subscriber string, queries []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	for _, query := range queries {
+		if err := f.unsubscribe(ctx, subscriber, query); err != nil {
+			log.Warnw("unsubscribing", "query", query, "err", err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestBlockFetcher_SubscribeMulti(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	t.Cleanup(cancel)
+
+	_, client := StartTestCoreWithApp(t)
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	blockQuery := types.QueryForEvent(types.EventNewBlock).String()
+	headerQuery := types.QueryForEvent(types.EventNewBlockHeader).String()
+	subCtx, subCancel := context.WithCancel(ctx)
+	events, err := fetcher.SubscribeMulti(subCtx, []string{blockQuery, headerQuery})
+	require.NoError(t, err)
+
+	// every block produces an event for both of the queries
+	received := make(map[string]int)
+	for received[blockQuery] < 2 || received[headerQuery] < 2 {
+		select {
+		case event := <-events:
+			received[event.Query]++
+			switch event.Query {
+			case blockQuery:
+				assert.IsType(t, types.EventDataNewBlock{}, event.Event.Data)
+			case headerQuery:
+				assert.IsType(t, types.EventDataNewBlockHeader{}, event.Event.Data)
+			default:
+				t.Fatalf("unexpected query %q", event.Query)
+			}
+		case <-ctx.Done():
+			t.Fatal("events did not arrive")
+		}
+	}
+
+	subCancel()
+	for range events {
+		// drain until the channel is closed
+	}
+
+	_, err = fetcher.SubscribeMulti(ctx, []string{blockQuery, blockQuery})
+	assert.Error(t, err)
+}
+
+func TestBlockFetcher_SubscribeMulti_SharedQuery(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	t.Cleanup(cancel)
+
+	_, client := StartTestCoreWithApp(t)
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	blocks, err := fetcher.SubscribeNewBlockEvent(ctx)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, fetcher.UnsubscribeNewBlockEvent(ctx))
+	})
+	// the query of the new block events is shared with the subscription above
+	subCtx, subCancel := context.WithCancel(ctx)
+	events, err := fetcher.SubscribeMulti(subCtx, []string{newBlockEventQuery})
+	require.NoError(t, err)
+
+	select {
+	case <-events:
+	case <-ctx.Done():
+		t.Fatal("multi subscription event did not arrive")
+	}
+	select {
+	case <-blocks:
+	case <-ctx.Done():
+		t.Fatal("new block did not arrive")
+	}
+
+	// ending one subscription leaves the other intact
+	subCancel()
+	for range events {
+		// drain until the channel is closed
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case _, ok := <-blocks:
+			require.True(t, ok)
+		case <-ctx.Done():
+			t.Fatal("new block did not arrive after the multi subscription ended")
+		}
+	}
+}
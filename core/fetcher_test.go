@@ -2,11 +2,23 @@ package core
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	tmrand "github.com/tendermint/tendermint/libs/rand"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	rpctypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
 	"github.com/tendermint/tendermint/types"
 
 	"github.com/tendermint/tendermint/libs/bytes"
@@ -17,7 +29,8 @@ func TestBlockFetcher_GetBlock_and_SubscribeNewBlockEvent(t *testing.T) {
 	t.Cleanup(cancel)
 
 	_, client := StartTestCoreWithApp(t)
-	fetcher := NewBlockFetcher(client)
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
 
 	// generate some blocks
 	newBlockChan, err := fetcher.SubscribeNewBlockEvent(ctx)
@@ -45,7 +58,8 @@ func TestBlockFetcherHeaderValues(t *testing.T) {
 	t.Cleanup(cancel)
 
 	_, client := StartTestCoreWithApp(t)
-	fetcher := NewBlockFetcher(client)
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
 
 	// generate some blocks
 	newBlockChan, err := fetcher.SubscribeNewBlockEvent(ctx)
@@ -82,3 +96,1238 @@ func TestBlockFetcherHeaderValues(t *testing.T) {
 	assert.Equal(t, nextBlock.ValidatorsHash, hexBytes)
 	require.NoError(t, fetcher.UnsubscribeNewBlockEvent(ctx))
 }
+
+func TestBlockFetcher_SubscriptionErr(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	events := make(chan ctypes.ResultEvent)
+	client := &mockClient{
+		subscribe: func(context.Context, string, string) (<-chan ctypes.ResultEvent, error) {
+			return events, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client, WithBackoff[FetcherParameters](&fixedBackoff{}))
+	require.NoError(t, err)
+
+	t.Run("clean stop", func(t *testing.T) {
+		sub, err := fetcher.SubscribeNewBlockEvent(ctx)
+		require.NoError(t, err)
+		require.NoError(t, fetcher.UnsubscribeNewBlockEvent(ctx))
+
+		waitClosed(ctx, t, sub)
+		assert.NoError(t, fetcher.SubscriptionErr())
+	})
+
+	t.Run("client stopped", func(t *testing.T) {
+		sub, err := fetcher.SubscribeNewBlockEvent(ctx)
+		require.NoError(t, err)
+
+		// stop the client and lose the subscription
+		client.stopped.Store(true)
+		close(events)
+
+		waitClosed(ctx, t, sub)
+		assert.ErrorIs(t, fetcher.SubscriptionErr(), ErrClientStopped)
+	})
+}
+
+func waitClosed(ctx context.Context, t *testing.T, sub <-chan *types.Block) {
+	for {
+		select {
+		case _, ok := <-sub:
+			if !ok {
+				return
+			}
+		case <-ctx.Done():
+			require.NoError(t, ctx.Err())
+		}
+	}
+}
+
+func TestBlockFetcher_SubscriptionHeartbeat(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	var (
+		subscriptions atomic.Int32
+		tip           atomic.Int64
+		failed        atomic.Bool
+	)
+	client := &mockClient{
+		subscribe: func(context.Context, string, string) (<-chan ctypes.ResultEvent, error) {
+			subscriptions.Add(1)
+			// the subscription delivers the first block and silently stalls afterwards
+			events := make(chan ctypes.ResultEvent, 1)
+			if subscriptions.Load() == 1 {
+				events <- ctypes.ResultEvent{Data: types.EventDataNewBlock{Block: newHeightBlock(1)}}
+			}
+			return events, nil
+		},
+		status: func(context.Context) (*ctypes.ResultStatus, error) {
+			return &ctypes.ResultStatus{SyncInfo: ctypes.SyncInfo{LatestBlockHeight: tip.Load()}}, nil
+		},
+		block: func(_ context.Context, height *int64) (*ctypes.ResultBlock, error) {
+			// the first block backfilled fails once
+			if *height == 2 && !failed.Swap(true) {
+				return nil, errors.New("unavailable")
+			}
+			return &ctypes.ResultBlock{Block: newHeightBlock(*height)}, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client,
+		WithBackoff[FetcherParameters](&fixedBackoff{}),
+		WithHeartbeat(time.Millisecond*50),
+	)
+	require.NoError(t, err)
+
+	sub, err := fetcher.SubscribeNewBlockEvent(ctx)
+	require.NoError(t, err)
+
+	tip.Store(1)
+	block := <-sub
+	require.EqualValues(t, 1, block.Height)
+	// the chain advances, while no events come through
+	tip.Store(3)
+
+	// none is skipped, even when failing
+	for height := int64(2); height <= 3; height++ {
+		select {
+		case block := <-sub:
+			assert.Equal(t, height, block.Height)
+		case <-ctx.Done():
+			require.NoError(t, ctx.Err())
+		}
+	}
+	assert.True(t, failed.Load())
+	assert.EqualValues(t, 2, subscriptions.Load())
+	require.NoError(t, fetcher.UnsubscribeNewBlockEvent(ctx))
+}
+
+func TestBlockFetcher_SubscriptionHeartbeat_Events(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	var probes atomic.Int32
+	events := make(chan ctypes.ResultEvent)
+	client := &mockClient{
+		subscribe: func(context.Context, string, string) (<-chan ctypes.ResultEvent, error) {
+			return events, nil
+		},
+		status: func(context.Context) (*ctypes.ResultStatus, error) {
+			probes.Add(1)
+			return &ctypes.ResultStatus{}, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client, WithHeartbeat(time.Millisecond*50))
+	require.NoError(t, err)
+
+	sub, err := fetcher.SubscribeNewBlockEvent(ctx)
+	require.NoError(t, err)
+
+	// the events come more often than the heartbeat, so Core is never probed
+	for height := int64(1); height <= 20; height++ {
+		events <- ctypes.ResultEvent{Data: types.EventDataNewBlock{Block: newHeightBlock(height)}}
+		block := <-sub
+		require.Equal(t, height, block.Height)
+		time.Sleep(time.Millisecond * 10)
+	}
+	assert.Zero(t, probes.Load())
+	require.NoError(t, fetcher.UnsubscribeNewBlockEvent(ctx))
+}
+
+// newHeightBlock creates an empty block at the given height.
+func newHeightBlock(height int64) *types.Block {
+	return &types.Block{Header: types.Header{Height: height, DataHash: emptyDataHash()}}
+}
+
+func TestBlockFetcher_InvalidHeight(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	client := &mockClient{
+		block: func(_ context.Context, height *int64) (*ctypes.ResultBlock, error) {
+			if height == nil {
+				return &ctypes.ResultBlock{Block: newHeightBlock(10)}, nil
+			}
+			return &ctypes.ResultBlock{Block: newHeightBlock(*height)}, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	height := func(h int64) *int64 { return &h }
+	tests := []struct {
+		name   string
+		height *int64
+		err    error
+	}{
+		{name: "zero", height: height(0), err: ErrInvalidHeight},
+		{name: "negative", height: height(-1), err: ErrInvalidHeight},
+		{name: "valid", height: height(1)},
+		{name: "latest", height: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := fetcher.GetBlock(ctx, tt.height)
+			require.ErrorIs(t, err, tt.err)
+
+			if tt.err != nil {
+				_, err = fetcher.Commit(ctx, tt.height)
+				require.ErrorIs(t, err, tt.err)
+				_, err = fetcher.ValidatorSet(ctx, tt.height)
+				require.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+
+	_, err = fetcher.GetBlockRange(ctx, 0, 1, 1)
+	require.ErrorIs(t, err, ErrInvalidHeight)
+}
+
+func TestWaitUntilSynced(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	interval := syncPollInterval
+	syncPollInterval = time.Millisecond * 10
+	t.Cleanup(func() {
+		syncPollInterval = interval
+	})
+
+	var (
+		synced atomic.Bool
+		tip    atomic.Int64
+	)
+	client := &mockClient{
+		status: func(context.Context) (*ctypes.ResultStatus, error) {
+			tip.Add(1)
+			return &ctypes.ResultStatus{SyncInfo: ctypes.SyncInfo{
+				LatestBlockHeight: tip.Load(),
+				CatchingUp:        !synced.Load(),
+			}}, nil
+		},
+	}
+
+	go func() {
+		time.Sleep(time.Millisecond * 100)
+		synced.Store(true)
+	}()
+
+	start := time.Now()
+	height, err := WaitUntilSynced(ctx, client)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, tip.Load(), height)
+
+	// an already synced Core is reported right away
+	height, err = WaitUntilSynced(ctx, client)
+	require.NoError(t, err)
+	assert.Equal(t, tip.Load(), height)
+}
+
+func TestBlockFetcher_GetSignedHeader(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	const chainID = "private"
+	valSet, vals := RandValidatorSet(3, 1)
+	block := newTestBlock(chainID, 1, valSet)
+	voteSet := types.NewVoteSet(chainID, block.Height, 0, tmproto.PrecommitType, valSet)
+	sb, err := MakeSignedBlock(block, voteSet, valSet, vals, time.Now())
+	require.NoError(t, err)
+
+	var blockCalls atomic.Int32
+	client := &mockClient{
+		block: func(context.Context, *int64) (*ctypes.ResultBlock, error) {
+			blockCalls.Add(1)
+			return &ctypes.ResultBlock{Block: sb.Block}, nil
+		},
+		commit: func(context.Context, *int64) (*ctypes.ResultCommit, error) {
+			return &ctypes.ResultCommit{SignedHeader: types.SignedHeader{Header: &sb.Header, Commit: sb.Commit}}, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	sh, err := fetcher.GetSignedHeader(ctx, &block.Height)
+	require.NoError(t, err)
+	require.NoError(t, sh.ValidateBasic(chainID))
+	require.NoError(t, valSet.VerifyCommit(chainID, sh.Commit.BlockID, sh.Height, sh.Commit))
+	// the block body is never transferred
+	assert.Zero(t, blockCalls.Load())
+
+	// the header is missing
+	client.commit = func(context.Context, *int64) (*ctypes.ResultCommit, error) {
+		return &ctypes.ResultCommit{SignedHeader: types.SignedHeader{Commit: sb.Commit}}, nil
+	}
+	_, err = fetcher.GetSignedHeader(ctx, &block.Height)
+	assert.EqualError(t, err, "core/fetcher: signed header not found at height 1")
+	_, err = fetcher.GetSignedHeader(ctx, nil)
+	assert.EqualError(t, err, "core/fetcher: signed header not found at height latest")
+}
+
+func TestBlockFetcher_GetHeader(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	_, client := StartTestCoreWithApp(t)
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	sub, err := fetcher.SubscribeNewBlockEvent(ctx)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, fetcher.UnsubscribeNewBlockEvent(ctx))
+	})
+	var height int64
+	select {
+	case block := <-sub:
+		height = block.Height
+	case <-ctx.Done():
+		require.NoError(t, ctx.Err())
+	}
+
+	block, err := fetcher.GetBlock(ctx, &height)
+	require.NoError(t, err)
+	header, err := fetcher.GetHeader(ctx, &height)
+	require.NoError(t, err)
+	assert.Equal(t, block.Hash(), header.Hash())
+
+	latest, err := fetcher.GetHeader(ctx, nil)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, latest.Height, height)
+}
+
+func TestBlockFetcher_GetHeader_NotFound(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	client := &mockClient{
+		blockchainInfo: func(context.Context, int64, int64) (*ctypes.ResultBlockchainInfo, error) {
+			return &ctypes.ResultBlockchainInfo{}, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	height := int64(5)
+	_, err = fetcher.GetHeader(ctx, &height)
+	assert.EqualError(t, err, "core/fetcher: block meta not found at height 5")
+}
+
+func TestBlockFetcher_GetBlockMeta(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	_, client := StartTestCoreWithApp(t)
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	sub, err := fetcher.SubscribeNewBlockEvent(ctx)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, fetcher.UnsubscribeNewBlockEvent(ctx))
+	})
+	var height int64
+	select {
+	case block := <-sub:
+		height = block.Height
+	case <-ctx.Done():
+		require.NoError(t, ctx.Err())
+	}
+
+	block, err := fetcher.GetBlock(ctx, &height)
+	require.NoError(t, err)
+	meta, err := fetcher.GetBlockMeta(ctx, &height)
+	require.NoError(t, err)
+	assert.Equal(t, block.Hash(), meta.BlockID.Hash)
+	assert.Equal(t, block.Hash(), meta.Header.Hash())
+	// Core sizes the block with its data hash cached, which the fetched block only caches once
+	// computed
+	block.Data.Hash()
+	assert.Equal(t, block.Size(), meta.BlockSize)
+	assert.Equal(t, len(block.Txs), meta.NumTxs)
+
+	height += 1000
+	_, err = fetcher.GetBlockMeta(ctx, &height)
+	assert.Error(t, err)
+}
+
+func TestBlockFetcher_GetBlockMeta_NotFound(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	client := &mockClient{
+		blockchainInfo: func(context.Context, int64, int64) (*ctypes.ResultBlockchainInfo, error) {
+			return &ctypes.ResultBlockchainInfo{BlockMetas: []*types.BlockMeta{nil}}, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	height := int64(5)
+	_, err = fetcher.GetBlockMeta(ctx, &height)
+	assert.EqualError(t, err, "core/fetcher: block meta not found at height 5")
+	_, err = fetcher.GetBlockMeta(ctx, nil)
+	assert.EqualError(t, err, "core/fetcher: block meta not found at height latest")
+}
+
+func TestBlockFetcher_GetBlockMetas(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	valSet, vals := RandValidatorSet(3, 1)
+	chain, err := MakeSignedBlockChain(1, 45, valSet, vals)
+	require.NoError(t, err)
+	client := NewCountingClient(newSignedChainClient(chain))
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	metas, err := fetcher.GetBlockMetas(ctx, 3, 44)
+	require.NoError(t, err)
+	require.Len(t, metas, 42)
+	for i, meta := range metas {
+		sb := chain[i+2]
+		assert.Equal(t, sb.Height, meta.Header.Height)
+		assert.Equal(t, sb.Hash(), meta.BlockID.Hash)
+	}
+	// in chunks of 20 metas
+	assert.Equal(t, 3, client.CallCount("BlockchainInfo"))
+
+	// the heights past the tip are missing
+	_, err = fetcher.GetBlockMetas(ctx, 40, 50)
+	assert.Error(t, err)
+	_, err = fetcher.GetBlockMetas(ctx, 5, 4)
+	assert.Error(t, err)
+}
+
+func TestBlockFetcher_CoalesceNewBlocks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	const blocks = 100
+	events := make(chan ctypes.ResultEvent)
+	client := &mockClient{
+		subscribe: func(context.Context, string, string) (<-chan ctypes.ResultEvent, error) {
+			return events, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client, WithCoalesceNewBlocks[FetcherParameters](true))
+	require.NoError(t, err)
+	sub, err := fetcher.SubscribeNewBlockEvent(ctx)
+	require.NoError(t, err)
+
+	// a producer much faster than the consumer
+	go func() {
+		for height := int64(1); height <= blocks; height++ {
+			select {
+			case events <- ctypes.ResultEvent{Data: types.EventDataNewBlock{Block: newHeightBlock(height)}}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var received []int64
+	for len(received) == 0 || received[len(received)-1] < blocks {
+		select {
+		case b := <-sub:
+			if len(received) > 0 {
+				assert.Greater(t, b.Height, received[len(received)-1], "stale tip")
+			}
+			received = append(received, b.Height)
+			time.Sleep(time.Millisecond * 10)
+		case <-ctx.Done():
+			t.Fatalf("latest block not received, got %v", received)
+		}
+	}
+	assert.Less(t, len(received), blocks)
+	require.NoError(t, fetcher.UnsubscribeNewBlockEvent(ctx))
+	waitClosed(ctx, t, sub)
+}
+
+func TestBlockFetcher_PrefetchWindow(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	var fetches atomic.Int32
+	events := make(chan ctypes.ResultEvent)
+	client := &mockClient{
+		subscribe: func(context.Context, string, string) (<-chan ctypes.ResultEvent, error) {
+			return events, nil
+		},
+		block: func(_ context.Context, height *int64) (*ctypes.ResultBlock, error) {
+			fetches.Add(1)
+			return &ctypes.ResultBlock{Block: newHeightBlock(*height)}, nil
+		},
+	}
+	var cached atomic.Bool
+	fetcher, err := NewBlockFetcher(client,
+		WithCacheSize[FetcherParameters](128),
+		WithPrefetchWindow[FetcherParameters](1),
+		WithObserver(func(e FetchEvent) { cached.Store(e.Cached) }),
+	)
+	require.NoError(t, err)
+	sub, err := fetcher.SubscribeNewBlockEvent(ctx)
+	require.NoError(t, err)
+
+	newBlockEvent := func(height int64) {
+		events <- ctypes.ResultEvent{Data: types.EventDataNewBlock{Block: newHeightBlock(height)}}
+		<-sub
+	}
+
+	for height := int64(1); height <= 3; height++ {
+		newBlockEvent(height)
+		require.Eventually(t, func() bool {
+			_, ok := fetcher.cache.Get(height)
+			return ok
+		}, time.Second, time.Millisecond*10)
+		_, err = fetcher.GetBlock(ctx, &height)
+		require.NoError(t, err)
+		assert.True(t, cached.Load())
+	}
+	// the blocks of the events are cached as they are, without fetching them again
+	assert.Zero(t, fetches.Load())
+
+	require.NoError(t, fetcher.UnsubscribeNewBlockEvent(ctx))
+
+	_, err = NewBlockFetcher(client, WithPrefetchWindow[FetcherParameters](1), WithCacheSize[FetcherParameters](0))
+	assert.Error(t, err)
+}
+
+func TestBlockFetcher_SubscribeTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	// the endpoint accepts the subscription request, but never completes it
+	client := &mockClient{
+		subscribe: func(ctx context.Context, _, _ string) (<-chan ctypes.ResultEvent, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+	fetcher, err := NewBlockFetcher(client, WithSubscribeTimeout(time.Millisecond*50))
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = fetcher.SubscribeNewBlockEvent(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "subscription not established")
+	assert.Less(t, time.Since(start), time.Millisecond*500)
+	require.NoError(t, ctx.Err())
+}
+
+func TestBlockFetcher_SubscribeTimeout_Remote(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	// the endpoint accepts the websocket, but never answers the subscription request
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	client := newTestRemote(t, srv.URL)
+	require.NoError(t, client.Start())
+	t.Cleanup(func() {
+		require.NoError(t, client.Stop())
+	})
+
+	fetcher, err := NewBlockFetcher(client, WithSubscribeTimeout(time.Millisecond*50))
+	require.NoError(t, err)
+	start := time.Now()
+	_, err = fetcher.SubscribeNewBlockEvent(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "subscription not established")
+	assert.Less(t, time.Since(start), time.Millisecond*500)
+}
+
+func TestBlockFetcher_EarliestHeight(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	earliest := int64(0)
+	client := &mockClient{
+		status: func(context.Context) (*ctypes.ResultStatus, error) {
+			return &ctypes.ResultStatus{SyncInfo: ctypes.SyncInfo{EarliestBlockHeight: earliest}}, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	// unknown to Core before the first block
+	height, err := fetcher.EarliestHeight(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, height)
+
+	// pruning is reflected right away
+	earliest = 90
+	height, err = fetcher.EarliestHeight(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 90, height)
+}
+
+func TestBlockFetcher_ResolveHeight(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	client := &mockClient{
+		status: func(context.Context) (*ctypes.ResultStatus, error) {
+			return &ctypes.ResultStatus{SyncInfo: ctypes.SyncInfo{
+				LatestBlockHeight:   100,
+				EarliestBlockHeight: 90,
+			}}, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	height, err := fetcher.ResolveHeight(ctx, -5)
+	require.NoError(t, err)
+	assert.EqualValues(t, 95, height)
+
+	height, err = fetcher.ResolveHeight(ctx, 0)
+	require.NoError(t, err)
+	assert.EqualValues(t, 100, height)
+
+	height, err = fetcher.ResolveHeight(ctx, 42)
+	require.NoError(t, err)
+	assert.EqualValues(t, 42, height)
+
+	_, err = fetcher.ResolveHeight(ctx, -11)
+	var errPruned *ErrHeightPruned
+	require.ErrorAs(t, err, &errPruned)
+	assert.EqualValues(t, 89, errPruned.Height)
+	assert.EqualValues(t, 90, errPruned.Lowest)
+}
+
+func TestBlockFetcher_VerifyDataHash(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	valSet, vals := RandValidatorSet(1, 1)
+	chain, err := MakeSignedBlockChain(1, 1, valSet, vals)
+	require.NoError(t, err)
+	corrupted, err := CorruptBlock(chain[0].Block, DefectDataHash)
+	require.NoError(t, err)
+
+	client := &mockClient{
+		block: func(context.Context, *int64) (*ctypes.ResultBlock, error) {
+			return &ctypes.ResultBlock{Block: corrupted}, nil
+		},
+	}
+	height := corrupted.Height
+
+	// verified by default
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+	_, err = fetcher.GetBlock(ctx, &height)
+	var errMismatch *ErrDataHashMismatch
+	require.ErrorAs(t, err, &errMismatch)
+	assert.Equal(t, height, errMismatch.Height)
+	assert.Equal(t, corrupted.DataHash, errMismatch.Expected)
+	assert.Equal(t, chain[0].DataHash, errMismatch.Computed)
+
+	// unless opted out
+	fetcher, err = NewBlockFetcher(client, WithDataHashVerification(false))
+	require.NoError(t, err)
+	block, err := fetcher.GetBlock(ctx, &height)
+	require.NoError(t, err)
+	assert.Equal(t, corrupted, block)
+}
+
+func TestBlockFetcher_VerifyDataHash_Subscription(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	valSet, vals := RandValidatorSet(1, 1)
+	chain, err := MakeSignedBlockChain(1, 2, valSet, vals)
+	require.NoError(t, err)
+	corrupted, err := CorruptBlock(chain[0].Block, DefectDataHash)
+	require.NoError(t, err)
+
+	events := make(chan ctypes.ResultEvent, 2)
+	events <- ctypes.ResultEvent{Data: types.EventDataNewBlock{Block: corrupted}}
+	events <- ctypes.ResultEvent{Data: types.EventDataNewBlock{Block: chain[1].Block}}
+	client := &mockClient{
+		subscribe: func(context.Context, string, string) (<-chan ctypes.ResultEvent, error) {
+			return events, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client, WithDataHashVerification(true))
+	require.NoError(t, err)
+	sub, err := fetcher.SubscribeNewBlockEvent(ctx)
+	require.NoError(t, err)
+
+	// the corrupted block is dropped
+	select {
+	case b := <-sub:
+		assert.Equal(t, chain[1].Block, b)
+	case <-ctx.Done():
+		t.Fatal("new block not received")
+	}
+	require.NoError(t, fetcher.UnsubscribeNewBlockEvent(ctx))
+}
+
+func TestBlockFetcher_Tip_FakeClock(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	var calls atomic.Int32
+	client := &mockClient{
+		status: func(context.Context) (*ctypes.ResultStatus, error) {
+			calls.Add(1)
+			return &ctypes.ResultStatus{SyncInfo: ctypes.SyncInfo{LatestBlockHeight: 10}}, nil
+		},
+	}
+	clock := NewFakeClock(time.Now())
+	fetcher, err := NewBlockFetcher(client,
+		WithClock(clock),
+		WithTipTTL(time.Minute),
+		WithTipJitter(0),
+	)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = fetcher.Tip(ctx)
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 1, calls.Load())
+
+	// the cached tip is still fresh right before the TTL elapses
+	clock.Advance(time.Minute - time.Nanosecond)
+	_, err = fetcher.Tip(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, calls.Load())
+
+	clock.Advance(time.Nanosecond)
+	_, err = fetcher.Tip(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+func TestBlockFetcher_SubscriptionHeartbeat_FakeClock(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	probes := make(chan struct{}, 1)
+	events := make(chan ctypes.ResultEvent)
+	client := &mockClient{
+		subscribe: func(context.Context, string, string) (<-chan ctypes.ResultEvent, error) {
+			return events, nil
+		},
+		status: func(context.Context) (*ctypes.ResultStatus, error) {
+			probes <- struct{}{}
+			return &ctypes.ResultStatus{}, nil
+		},
+	}
+	clock := NewFakeClock(time.Now())
+	fetcher, err := NewBlockFetcher(client, WithClock(clock), WithHeartbeat(time.Minute))
+	require.NoError(t, err)
+	sub, err := fetcher.SubscribeNewBlockEvent(ctx)
+	require.NoError(t, err)
+
+	// the event restarts the heartbeat
+	clock.BlockUntil(1)
+	clock.Advance(time.Second * 30)
+	events <- ctypes.ResultEvent{Data: types.EventDataNewBlock{Block: newHeightBlock(1)}}
+	<-sub
+	clock.Advance(time.Second * 30)
+	select {
+	case <-probes:
+		t.Fatal("Core probed before the heartbeat")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	clock.Advance(time.Second * 30)
+	select {
+	case <-probes:
+	case <-ctx.Done():
+		t.Fatal("Core not probed on the heartbeat")
+	}
+	require.NoError(t, fetcher.UnsubscribeNewBlockEvent(ctx))
+}
+
+func TestBlockFetcher_WaitUntilSynced_FakeClock(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	var polls atomic.Int32
+	client := &mockClient{
+		status: func(context.Context) (*ctypes.ResultStatus, error) {
+			// Core catches up on the third poll
+			return &ctypes.ResultStatus{SyncInfo: ctypes.SyncInfo{
+				LatestBlockHeight: 10,
+				CatchingUp:        polls.Add(1) < 3,
+			}}, nil
+		},
+	}
+	clock := NewFakeClock(time.Now())
+	fetcher, err := NewBlockFetcher(client, WithClock(clock))
+	require.NoError(t, err)
+
+	done := make(chan int64)
+	go func() {
+		height, err := fetcher.WaitUntilSynced(ctx)
+		assert.NoError(t, err)
+		done <- height
+	}()
+
+	clock.BlockUntil(1)
+	for {
+		select {
+		case height := <-done:
+			assert.EqualValues(t, 10, height)
+			assert.EqualValues(t, 3, polls.Load())
+			return
+		case <-time.After(time.Millisecond * 10):
+			// a tick is dropped, as by time.Ticker, if the previous one wasn't received yet
+			clock.Advance(syncPollInterval)
+		case <-ctx.Done():
+			t.Fatal("not synced")
+		}
+	}
+}
+
+func TestBlockFetcher_Tip_Jitter(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	// every poller refreshes its tip at once and keeps polling until it refreshes again
+	const pollers = 10
+	refreshes := make([]time.Time, pollers)
+	var wg sync.WaitGroup
+	for i := 0; i < pollers; i++ {
+		i := i
+		var calls int
+		client := &mockClient{
+			status: func(context.Context) (*ctypes.ResultStatus, error) {
+				calls++
+				if calls == 2 {
+					refreshes[i] = time.Now()
+				}
+				return &ctypes.ResultStatus{SyncInfo: ctypes.SyncInfo{LatestBlockHeight: 10}}, nil
+			},
+		}
+		fetcher, err := NewBlockFetcher(client,
+			WithTipTTL(time.Millisecond*100),
+			WithTipJitter(time.Millisecond*200),
+		)
+		require.NoError(t, err)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for calls < 2 {
+				tip, err := fetcher.Tip(ctx)
+				assert.NoError(t, err)
+				assert.EqualValues(t, 10, tip)
+				time.Sleep(time.Millisecond)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// without the jitter, all the refreshes would land within a few milliseconds
+	earliest, latest := refreshes[0], refreshes[0]
+	for _, refresh := range refreshes {
+		if refresh.Before(earliest) {
+			earliest = refresh
+		}
+		if refresh.After(latest) {
+			latest = refresh
+		}
+	}
+	assert.Greater(t, latest.Sub(earliest), time.Millisecond*20)
+}
+
+func TestBlockFetcher_GetBlockInfo_ValidatorSetCache(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	// the validator set changes at height 21
+	const changeHeight = 21
+	valSets := make([]*types.ValidatorSet, 2)
+	valSets[0], _ = RandValidatorSet(3, 1)
+	valSets[1], _ = RandValidatorSet(4, 1)
+	valSetAt := func(height int64) *types.ValidatorSet {
+		if height < changeHeight {
+			return valSets[0]
+		}
+		return valSets[1]
+	}
+
+	var valSetCalls atomic.Int32
+	client := &mockClient{
+		commit: func(_ context.Context, height *int64) (*ctypes.ResultCommit, error) {
+			header := &types.Header{Height: *height, ValidatorsHash: valSetAt(*height).Hash()}
+			return &ctypes.ResultCommit{SignedHeader: types.SignedHeader{
+				Header: header,
+				Commit: &types.Commit{Height: *height},
+			}}, nil
+		},
+		validators: func(_ context.Context, height *int64, _, _ *int) (*ctypes.ResultValidators, error) {
+			valSetCalls.Add(1)
+			valSet := valSetAt(*height)
+			return &ctypes.ResultValidators{Validators: valSet.Validators, Total: valSet.Size()}, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	for height := int64(1); height < changeHeight; height++ {
+		_, valSet, err := fetcher.GetBlockInfo(ctx, &height)
+		require.NoError(t, err)
+		assert.Equal(t, valSets[0].Hash(), valSet.Hash())
+	}
+	assert.EqualValues(t, 1, valSetCalls.Load())
+
+	for height := int64(changeHeight); height < changeHeight+10; height++ {
+		_, valSet, err := fetcher.GetBlockInfo(ctx, &height)
+		require.NoError(t, err)
+		assert.Equal(t, valSets[1].Hash(), valSet.Hash())
+	}
+	assert.EqualValues(t, 2, valSetCalls.Load())
+}
+
+func TestBlockFetcher_GetLastCommitForHeight(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	const chainID = "private"
+	valSet, vals := RandValidatorSet(3, 1)
+	block := newTestBlock(chainID, 1, valSet)
+	voteSet := types.NewVoteSet(chainID, block.Height, 0, tmproto.PrecommitType, valSet)
+	sb, err := MakeSignedBlock(block, voteSet, valSet, vals, time.Now())
+	require.NoError(t, err)
+
+	// the block at height 2 is the latest one and carries the commit for the block at height 1
+	next := types.MakeBlock(2, types.Data{}, sb.Commit)
+	next.DataHash = emptyDataHash()
+	client := &mockClient{
+		block: func(_ context.Context, height *int64) (*ctypes.ResultBlock, error) {
+			if *height > next.Height {
+				return nil, &rpctypes.RPCError{
+					Code:    -32603,
+					Message: "Internal error",
+					Data: fmt.Sprintf("height %d must be less than or equal to the current blockchain height %d",
+						*height, next.Height),
+				}
+			}
+			return &ctypes.ResultBlock{Block: next}, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	lastCommit, err := fetcher.GetLastCommitForHeight(ctx, block.Height)
+	require.NoError(t, err)
+	assert.Equal(t, block.Hash(), lastCommit.BlockID.Hash)
+	require.NoError(t, valSet.VerifyCommit(chainID, lastCommit.BlockID, block.Height, lastCommit))
+
+	_, err = fetcher.GetLastCommitForHeight(ctx, next.Height)
+	var errNoNext *ErrNoNextBlock
+	require.ErrorAs(t, err, &errNoNext)
+	assert.Equal(t, next.Height, errNoNext.Height)
+}
+
+func TestBlockFetcher_GetBlock_HeightMismatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	// a stale proxy responds with the block it has cached
+	client := &mockClient{
+		block: func(context.Context, *int64) (*ctypes.ResultBlock, error) {
+			return &ctypes.ResultBlock{Block: newHeightBlock(7)}, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	height := int64(8)
+	_, err = fetcher.GetBlock(ctx, &height)
+	var errMismatch *ErrHeightMismatch
+	require.ErrorAs(t, err, &errMismatch)
+	assert.EqualValues(t, 8, errMismatch.Requested)
+	assert.EqualValues(t, 7, errMismatch.Returned)
+}
+
+func TestBlockFetcher_GetBlock_Decode(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	// Core of a skewed version serves the height in an unexpected format
+	srv := newRPCServer(t, func(method string, _ json.RawMessage) (any, error) {
+		if method != "block" {
+			return nil, fmt.Errorf("unexpected method %s", method)
+		}
+		type header struct {
+			Height string `json:"height"`
+		}
+		type block struct {
+			Header header `json:"header"`
+		}
+		return struct {
+			Block block `json:"block"`
+		}{block{header{Height: "ten"}}}, nil
+	})
+	fetcher, err := NewBlockFetcher(newTestRemote(t, srv.URL))
+	require.NoError(t, err)
+
+	height := int64(10)
+	_, err = fetcher.GetBlock(ctx, &height)
+	var errDecode *ErrBlockDecode
+	require.ErrorAs(t, err, &errDecode)
+	assert.EqualValues(t, 10, errDecode.Height)
+
+	// not retried, as it won't help
+	fetcher.params.BatchRetries = 3
+	results, err := fetcher.GetBlocks(ctx, []int64{10}, 1)
+	require.NoError(t, err)
+	require.ErrorAs(t, results[10].Err, &errDecode)
+
+	// the failures of the requests are not mistaken for it
+	_, err = fetcher.Commit(ctx, &height)
+	require.Error(t, err)
+	assert.False(t, errors.As(err, &errDecode))
+}
+
+func TestBlockFetcher_ReconnectObserver(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	var subscriptions int
+	events := make(chan ctypes.ResultEvent, 1)
+	lost := make(chan ctypes.ResultEvent)
+	close(lost)
+	client := &mockClient{
+		subscribe: func(context.Context, string, string) (<-chan ctypes.ResultEvent, error) {
+			subscriptions++
+			switch subscriptions {
+			case 1, 4:
+				return lost, nil
+			case 2, 3:
+				return nil, errors.New("connection refused")
+			default:
+				return events, nil
+			}
+		},
+	}
+
+	metrics, err := ReconnectMetrics()
+	require.NoError(t, err)
+	var reconnects []ReconnectEvent
+	fetcher, err := NewBlockFetcher(client,
+		WithBackoff[FetcherParameters](&fixedBackoff{interval: time.Millisecond}),
+		WithDataHashVerification(false),
+		WithReconnectObserver(func(event ReconnectEvent) {
+			reconnects = append(reconnects, event)
+			metrics(event)
+		}),
+	)
+	require.NoError(t, err)
+
+	sub, err := fetcher.SubscribeNewBlockEvent(ctx)
+	require.NoError(t, err)
+	events <- ctypes.ResultEvent{Data: types.EventDataNewBlock{Block: &types.Block{}}}
+	select {
+	case <-sub:
+	case <-ctx.Done():
+		require.NoError(t, ctx.Err())
+	}
+	require.NoError(t, fetcher.UnsubscribeNewBlockEvent(ctx))
+
+	// the first loss takes three attempts, while the second one is recovered from right away
+	require.Len(t, reconnects, 4)
+	for i, attempt := range []int{1, 2, 3, 1} {
+		assert.Equal(t, attempt, reconnects[i].Attempt)
+		assert.Equal(t, "mock://core", reconnects[i].Endpoint)
+	}
+	assert.Error(t, reconnects[0].Err)
+	assert.Error(t, reconnects[1].Err)
+	assert.NoError(t, reconnects[2].Err)
+	assert.NoError(t, reconnects[3].Err)
+}
+
+func TestBlockFetcher_ReceiveObserver(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	_, client := StartTestCoreWithApp(t)
+	events := make(chan ReceiveEvent, 10)
+	fetcher, err := NewBlockFetcher(client, WithReceiveObserver(func(event ReceiveEvent) {
+		events <- event
+	}))
+	require.NoError(t, err)
+
+	sub, err := fetcher.SubscribeNewBlockEvent(ctx)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, fetcher.UnsubscribeNewBlockEvent(ctx))
+	})
+
+	var prev ReceiveEvent
+	for i := 0; i < 3; i++ {
+		var block *types.Block
+		select {
+		case block = <-sub:
+		case <-ctx.Done():
+			require.NoError(t, ctx.Err())
+		}
+
+		// the event is reported before the block is emitted
+		event := <-events
+		assert.Equal(t, block.Height, event.Height)
+		assert.Equal(t, block.Time, event.Time)
+		assert.False(t, event.ReceivedAt.IsZero())
+		assert.Positive(t, event.Latency())
+		assert.False(t, event.ReceivedAt.Before(prev.ReceivedAt))
+		prev = event
+	}
+}
+
+func TestBlockFetcher_Observer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	errUnavailable := errors.New("unavailable")
+	client := &mockClient{
+		block: func(_ context.Context, height *int64) (*ctypes.ResultBlock, error) {
+			if *height == 2 {
+				return nil, errUnavailable
+			}
+			return &ctypes.ResultBlock{Block: newSizedBlock(*height, 1<<10)}, nil
+		},
+	}
+	var events []FetchEvent
+	fetcher, err := NewBlockFetcher(client,
+		WithCacheSize[FetcherParameters](128),
+		WithDataHashVerification(false),
+		WithObserver(func(event FetchEvent) {
+			events = append(events, event)
+		}),
+	)
+	require.NoError(t, err)
+
+	for _, height := range []int64{1, 2, 1} {
+		height := height
+		_, _ = fetcher.GetBlock(ctx, &height)
+	}
+	require.Len(t, events, 3)
+
+	assert.EqualValues(t, 1, events[0].Height)
+	assert.NoError(t, events[0].Err)
+	assert.Greater(t, events[0].Bytes, 1<<10)
+	assert.Positive(t, events[0].Duration)
+	assert.False(t, events[0].Cached)
+
+	assert.EqualValues(t, 2, events[1].Height)
+	assert.ErrorIs(t, events[1].Err, errUnavailable)
+	assert.Zero(t, events[1].Bytes)
+
+	// the same block is served from the cache the second time
+	assert.EqualValues(t, 1, events[2].Height)
+	assert.True(t, events[2].Cached)
+	assert.Equal(t, events[0].Bytes, events[2].Bytes)
+}
+
+func TestBlockFetcher_GetRawBlock(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	_, client := StartTestCoreWithApp(t)
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	sub, err := fetcher.SubscribeNewBlockEvent(ctx)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, fetcher.UnsubscribeNewBlockEvent(ctx))
+	})
+	var height int64
+	select {
+	case block := <-sub:
+		height = block.Height
+	case <-ctx.Done():
+		require.NoError(t, ctx.Err())
+	}
+
+	block, raw, err := fetcher.GetRawBlock(ctx, &height)
+	require.NoError(t, err)
+	assert.Equal(t, height, block.Height)
+
+	pb := &tmproto.Block{}
+	require.NoError(t, pb.Unmarshal(raw))
+	decoded, err := types.BlockFromProto(pb)
+	require.NoError(t, err)
+	assert.Equal(t, block.Hash(), decoded.Hash())
+
+	// and it is the hash the block is committed with
+	commit, err := fetcher.Commit(ctx, &height)
+	require.NoError(t, err)
+	assert.Equal(t, commit.BlockID.Hash, decoded.Hash())
+}
+
+func TestBlockFetcher_GetRawBlock_BlockID(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	valSet, vals := RandValidatorSet(1, 1)
+	chain, err := MakeSignedBlockChain(1, 1, valSet, vals)
+	require.NoError(t, err)
+	block := chain[0].Block
+	blockID := types.BlockID{Hash: block.Hash()}
+	client := &mockClient{
+		block: func(context.Context, *int64) (*ctypes.ResultBlock, error) {
+			return &ctypes.ResultBlock{BlockID: blockID, Block: block}, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	height := int64(1)
+	_, _, err = fetcher.GetRawBlock(ctx, &height)
+	require.NoError(t, err)
+
+	// the block doesn't match the block ID Core served it with
+	blockID.Hash = tmrand.Bytes(32)
+	_, _, err = fetcher.GetRawBlock(ctx, &height)
+	assert.ErrorContains(t, err, "hashes to")
+}
+
+func TestBlockFetcher_GetLatestBlock(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	_, client := StartTestCoreWithApp(t)
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	newBlockChan, err := fetcher.SubscribeNewBlockEvent(ctx)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, fetcher.UnsubscribeNewBlockEvent(ctx))
+	})
+
+	for i := 0; i < 2; i++ {
+		select {
+		case newBlock := <-newBlockChan:
+			block, height, err := fetcher.GetLatestBlock(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, block.Height, height)
+			assert.GreaterOrEqual(t, height, newBlock.Height)
+		case <-ctx.Done():
+			require.NoError(t, ctx.Err())
+		}
+	}
+}
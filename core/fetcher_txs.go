@@ -0,0 +1,120 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+const txResultsSubscriber = "TxResults/Events"
+
+// TxResult is the result of executing a committed transaction, or the failure to get the results
+// of a block.
+type TxResult struct {
+	Height int64
+	// Index is the index of the transaction in its block.
+	Index uint32
+	Hash  tmbytes.HexBytes
+	Tx    types.Tx
+	// Result holds the Code, the Events and the gas of the execution.
+	Result *abci.ResponseDeliverTx
+	// Err is the error getting the results of the block at Height failed with, if any, in which
+	// case the rest of the fields are unset.
+	Err error
+}
+
+// SubscribeTxResults subscribes to new block headers from Core, returning a channel of the results
+// of every transaction committed in the new blocks, in order, e.g. for live indexers. The results
+// of every block with transactions are fetched from its block results, along with the block for
+// the transactions themselves. A block whose results can't be fetched is reported by a TxResult
+// carrying the error, so that the consumer can fetch them later. The channel is closed once the
+// context is done or the subscription ends.
+func (f *BlockFetcher) SubscribeTxResults(ctx context.Context) (<-chan *TxResult, error) {
+	eventChan, err := f.subscribe(ctx, txResultsSubscriber, newBlockHeaderQuery)
+	if err != nil {
+		return nil, fmt.Errorf("core/fetcher: subscribing to new block headers: %w", err)
+	}
+
+	resultsCh := make(chan *TxResult)
+	go f.forwardTxResults(ctx, eventChan, resultsCh)
+	return resultsCh, nil
+}
+
+// forwardTxResults forwards the results of the transactions of the blocks of the new block header
+// events until the event channel is closed or the context is done.
+func (f *BlockFetcher) forwardTxResults(
+	ctx context.Context,
+	eventChan <-chan ctypes.ResultEvent,
+	resultsCh chan<- *TxResult,
+) {
+	defer close(resultsCh)
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+		if err := f.unsubscribe(ctx, txResultsSubscriber, newBlockHeaderQuery); err != nil {
+			log.Warnw("unsubscribing from new block headers", "err", err)
+		}
+	}()
+
+	for {
+		select {
+		case event, ok := <-eventChan:
+			if !ok {
+				return
+			}
+			data, ok := event.Data.(types.EventDataNewBlockHeader)
+			if !ok || data.NumTxs == 0 {
+				continue
+			}
+			height := data.Header.Height
+			results, err := f.getTxResults(ctx, height)
+			if err != nil {
+				log.Errorw("getting tx results", "height", height, "err", err)
+				results = []*TxResult{{Height: height, Err: err}}
+			}
+
+			for _, res := range results {
+				select {
+				case resultsCh <- res:
+				case <-ctx.Done():
+					return
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// getTxResults pairs the transactions of the block at the given height with their results.
+func (f *BlockFetcher) getTxResults(ctx context.Context, height int64) ([]*TxResult, error) {
+	block, err := f.GetBlock(ctx, &height)
+	if err != nil {
+		return nil, err
+	}
+	res, err := f.GetBlockResults(ctx, &height)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.TxsResults) != len(block.Txs) {
+		return nil, fmt.Errorf("core/fetcher: got %d tx results for %d txs at height %d",
+			len(res.TxsResults), len(block.Txs), height)
+	}
+
+	results := make([]*TxResult, len(block.Txs))
+	for i, tx := range block.Txs {
+		results[i] = &TxResult{
+			Height: height,
+			Index:  uint32(i),
+			Hash:   tx.Hash(),
+			Tx:     tx,
+			Result: res.TxsResults[i],
+		}
+	}
+	return results, nil
+}
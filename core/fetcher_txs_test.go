@@ -0,0 +1,123 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+
+	"github.com/celestiaorg/celestia-app/app"
+	paytypes "github.com/celestiaorg/celestia-app/x/payment/types"
+)
+
+func TestBlockFetcher_SubscribeTxResults(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	t.Cleanup(cancel)
+
+	_, client, cctx, accounts := StartTestCoreWithAccounts(t)
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+	// the funded accounts are queryable once the genesis state is committed
+	for {
+		tip, err := fetcher.Tip(ctx)
+		require.NoError(t, err)
+		if tip > 1 {
+			break
+		}
+		time.Sleep(time.Millisecond * 50)
+	}
+
+	results, err := fetcher.SubscribeTxResults(ctx)
+	require.NoError(t, err)
+
+	// a transfer from each of a few accounts
+	to, err := paytypes.NewKeyringSigner(cctx.Keyring, accounts[len(accounts)-1], cctx.ChainID).
+		GetSignerInfo().GetAddress()
+	require.NoError(t, err)
+	submitted := make(map[string]bool)
+	for _, account := range accounts[:3] {
+		signer := paytypes.NewKeyringSigner(cctx.Keyring, account, cctx.ChainID)
+		require.NoError(t, signer.UpdateAccountFromClient(cctx.Context))
+		from, err := signer.GetSignerInfo().GetAddress()
+		require.NoError(t, err)
+		msg := banktypes.NewMsgSend(from, to, sdk.NewCoins(sdk.NewInt64Coin(app.BondDenom, 10)))
+		tx, err := signer.BuildSignedTx(signer.NewTxBuilder(paytypes.SetGasLimit(1000000)), msg)
+		require.NoError(t, err)
+		rawTx, err := signer.EncodeTx(tx)
+		require.NoError(t, err)
+
+		res, err := client.BroadcastTxSync(ctx, rawTx)
+		require.NoError(t, err)
+		require.Zero(t, res.Code, res.Log)
+		submitted[tmbytes.HexBytes(types.Tx(rawTx).Hash()).String()] = true
+	}
+
+	for len(submitted) > 0 {
+		select {
+		case res, ok := <-results:
+			require.True(t, ok, "tx results subscription ended")
+			if !submitted[res.Hash.String()] {
+				continue
+			}
+			delete(submitted, res.Hash.String())
+
+			assert.Zero(t, res.Result.Code, res.Result.Log)
+			assert.NotEmpty(t, res.Result.Events)
+			block, err := fetcher.GetBlock(ctx, &res.Height)
+			require.NoError(t, err)
+			assert.Equal(t, res.Tx, block.Txs[res.Index])
+		case <-ctx.Done():
+			t.Fatalf("%d tx results not streamed", len(submitted))
+		}
+	}
+}
+
+func TestBlockFetcher_SubscribeTxResults_Errors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	var subscribed atomic.Int32
+	events := make(chan ctypes.ResultEvent, 1)
+	client := &mockClient{
+		subscribe: func(context.Context, string, string) (<-chan ctypes.ResultEvent, error) {
+			subscribed.Add(1)
+			return events, nil
+		},
+		block: func(context.Context, *int64) (*ctypes.ResultBlock, error) {
+			return nil, errors.New("unavailable")
+		},
+		commit: func(context.Context, *int64) (*ctypes.ResultCommit, error) {
+			return nil, errors.New("unavailable")
+		},
+	}
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	results, err := fetcher.SubscribeTxResults(ctx)
+	require.NoError(t, err)
+	// the new block headers are shared with the signed headers
+	_, err = fetcher.SubscribeSignedHeaders(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, subscribed.Load())
+
+	// the block whose results can't be fetched is reported rather than skipped
+	header := types.Header{Height: 5}
+	events <- ctypes.ResultEvent{Data: types.EventDataNewBlockHeader{Header: header, NumTxs: 1}}
+	select {
+	case res := <-results:
+		assert.EqualValues(t, 5, res.Height)
+		assert.ErrorContains(t, res.Err, "unavailable")
+		assert.Nil(t, res.Tx)
+	case <-ctx.Done():
+		t.Fatal("tx results error not streamed")
+	}
+}
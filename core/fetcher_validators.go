@@ -0,0 +1,246 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+	"golang.org/x/sync/errgroup"
+)
+
+const validatorUpdatesSubscriber = "ValidatorSetUpdates/Events"
+
+var (
+	validatorUpdatesQuery = types.QueryForEvent(types.EventValidatorSetUpdates).String()
+	newBlockHeaderQuery   = types.QueryForEvent(types.EventNewBlockHeader).String()
+)
+
+// GetValidatorSets queries Core for the validator sets of the contiguous range of heights
+// [from:to] using up to `concurrency` parallel requests and returns them keyed by height.
+// The validators hashes of the range are fetched first, so that a set is fetched only once for
+// consecutive heights with the same validators, and these heights share the same set.
+func (f *BlockFetcher) GetValidatorSets(
+	ctx context.Context,
+	from, to int64,
+	concurrency int,
+) (map[int64]*types.ValidatorSet, error) {
+	if concurrency <= 0 {
+		return nil, fmt.Errorf("core/fetcher: invalid concurrency: %d", concurrency)
+	}
+
+	metas, err := f.GetBlockMetas(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make(map[int64]tmbytes.HexBytes, len(metas))
+	// the heights the validators change at, each starting a run of heights sharing the set
+	var changes []int64
+	for i, meta := range metas {
+		height := meta.Header.Height
+		hashes[height] = meta.Header.ValidatorsHash
+		if i == 0 || !bytes.Equal(meta.Header.ValidatorsHash, metas[i-1].Header.ValidatorsHash) {
+			changes = append(changes, height)
+		}
+	}
+
+	var (
+		setsLk sync.Mutex
+		sets   = make(map[int64]*types.ValidatorSet, len(changes))
+	)
+	errGroup, ctx := errgroup.WithContext(ctx)
+	errGroup.SetLimit(concurrency)
+	for _, height := range changes {
+		height := height
+		errGroup.Go(func() error {
+			valSet, err := f.ValidatorSet(ctx, &height)
+			if err != nil {
+				return fmt.Errorf("core/fetcher: getting validator set at height %d: %w", height, err)
+			}
+			if !bytes.Equal(valSet.Hash(), hashes[height]) {
+				return fmt.Errorf("core/fetcher: validator set hash %X does not match validators hash %X "+
+					"of the block at height %d", valSet.Hash(), hashes[height], height)
+			}
+
+			setsLk.Lock()
+			defer setsLk.Unlock()
+			sets[height] = valSet
+			return nil
+		})
+	}
+	if err := errGroup.Wait(); err != nil {
+		return nil, err
+	}
+
+	valSets := make(map[int64]*types.ValidatorSet, len(hashes))
+	var valSet *types.ValidatorSet
+	for height := from; height <= to; height++ {
+		if changed, ok := sets[height]; ok {
+			valSet = changed
+		}
+		valSets[height] = valSet
+	}
+	return valSets, nil
+}
+
+// SubscribeValidatorSetUpdates subscribes to updates of the validator set from Core, returning
+// a channel of the updated validators: the ones joining the set or changing their voting power,
+// and the ones leaving it with zero voting power. The channel is closed once the context is done.
+//
+// If the endpoint does not serve the validator set update events, the updates are detected by
+// comparing the validator sets of new blocks instead.
+func (f *BlockFetcher) SubscribeValidatorSetUpdates(ctx context.Context) (<-chan []*types.Validator, error) {
+	updatesCh := make(chan []*types.Validator)
+
+	eventChan, err := f.subscribe(ctx, validatorUpdatesSubscriber, validatorUpdatesQuery)
+	if err == nil {
+		go f.forwardValidatorUpdates(ctx, eventChan, updatesCh, validatorUpdatesQuery,
+			func(_ context.Context, data any) ([]*types.Validator, error) {
+				updates, ok := data.(types.EventDataValidatorSetUpdates)
+				if !ok {
+					return nil, nil
+				}
+				return updates.ValidatorUpdates, nil
+			})
+		return updatesCh, nil
+	}
+
+	log.Warnw("validator set update events unavailable, comparing validator sets of new blocks instead",
+		"err", err)
+	eventChan, err = f.subscribe(ctx, validatorUpdatesSubscriber, newBlockHeaderQuery)
+	if err != nil {
+		return nil, fmt.Errorf("core/fetcher: subscribing to validator set updates: %w", err)
+	}
+
+	var prev *types.ValidatorSet
+	go f.forwardValidatorUpdates(ctx, eventChan, updatesCh, newBlockHeaderQuery,
+		func(ctx context.Context, data any) ([]*types.Validator, error) {
+			header, ok := data.(types.EventDataNewBlockHeader)
+			if !ok {
+				return nil, nil
+			}
+			valSet, err := f.validatorSetByHash(ctx, header.Header.Height, header.Header.ValidatorsHash)
+			if err != nil {
+				return nil, err
+			}
+
+			updates := diffValidators(prev, valSet)
+			prev = valSet
+			return updates, nil
+		})
+	return updatesCh, nil
+}
+
+// forwardValidatorUpdates forwards the validator updates extracted from the events by the given
+// function until the event channel is closed or the context is done.
+func (f *BlockFetcher) forwardValidatorUpdates(
+	ctx context.Context,
+	eventChan <-chan ctypes.ResultEvent,
+	updatesCh chan<- []*types.Validator,
+	query string,
+	extract func(context.Context, any) ([]*types.Validator, error),
+) {
+	defer close(updatesCh)
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+		if err := f.unsubscribe(ctx, validatorUpdatesSubscriber, query); err != nil {
+			log.Warnw("unsubscribing from validator set updates", "err", err)
+		}
+	}()
+
+	for {
+		select {
+		case event, ok := <-eventChan:
+			if !ok {
+				return
+			}
+			updates, err := extract(ctx, event.Data)
+			if err != nil {
+				log.Errorw("extracting validator set updates", "err", err)
+				continue
+			}
+			if len(updates) == 0 {
+				continue
+			}
+
+			select {
+			case updatesCh <- updates:
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// diffValidators returns the validators of the next set joining it or changing their voting power
+// compared to the previous set, and the validators of the previous set leaving it with zero voting
+// power. No updates are returned without the previous set.
+func diffValidators(prev, next *types.ValidatorSet) []*types.Validator {
+	if prev == nil || prev == next {
+		return nil
+	}
+
+	var updates []*types.Validator
+	for _, val := range next.Validators {
+		_, prevVal := prev.GetByAddress(val.Address)
+		if prevVal == nil || prevVal.VotingPower != val.VotingPower {
+			updates = append(updates, val.Copy())
+		}
+	}
+	for _, val := range prev.Validators {
+		if !next.HasAddress(val.Address) {
+			removed := val.Copy()
+			removed.VotingPower = 0
+			updates = append(updates, removed)
+		}
+	}
+	return updates
+}
+
+// GenesisValidatorSet queries Core for the ValidatorSet the chain started with at its initial
+// height, e.g. to seed the trust of a light client. The set is verified against the validators
+// of the genesis doc or, if the genesis doc leaves them to the application, against the header
+// at the initial height.
+func (f *BlockFetcher) GenesisValidatorSet(ctx context.Context) (*types.ValidatorSet, error) {
+	res, err := f.client.Genesis(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("core/fetcher: getting genesis: %w", err)
+	}
+	genesis := res.Genesis
+	height := genesis.InitialHeight
+	if height == 0 {
+		height = 1
+	}
+
+	valSet, err := f.ValidatorSet(ctx, &height)
+	if err != nil {
+		return nil, fmt.Errorf("core/fetcher: getting validator set at height %d: %w", height, err)
+	}
+
+	var expected tmbytes.HexBytes
+	if len(genesis.Validators) > 0 {
+		vals := make([]*types.Validator, 0, len(genesis.Validators))
+		for _, val := range genesis.Validators {
+			vals = append(vals, types.NewValidator(val.PubKey, val.Power))
+		}
+		expected = types.NewValidatorSet(vals).Hash()
+	} else {
+		header, err := f.GetHeader(ctx, &height)
+		if err != nil {
+			return nil, fmt.Errorf("core/fetcher: getting header at height %d: %w", height, err)
+		}
+		expected = header.ValidatorsHash
+	}
+	if !bytes.Equal(valSet.Hash(), expected) {
+		return nil, fmt.Errorf("core/fetcher: validator set hash %X does not match genesis validators hash %X",
+			valSet.Hash(), expected)
+	}
+	return valSet, nil
+}
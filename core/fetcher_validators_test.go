@@ -0,0 +1,284 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/privval"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestBlockFetcher_SubscribeValidatorSetUpdates(t *testing.T) {
+	// the set changes as a new validator joins
+	prevSet, _ := RandValidatorSet(3, 1)
+	newVal, _ := RandValidator(false, 1)
+	nextSet := types.NewValidatorSet(append(prevSet.Copy().Validators, newVal))
+
+	t.Run("events", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		t.Cleanup(cancel)
+
+		events := make(chan ctypes.ResultEvent, 1)
+		client := &mockClient{
+			subscribe: func(_ context.Context, _, query string) (<-chan ctypes.ResultEvent, error) {
+				require.Equal(t, validatorUpdatesQuery, query)
+				return events, nil
+			},
+		}
+		fetcher, err := NewBlockFetcher(client)
+		require.NoError(t, err)
+
+		updatesCh, err := fetcher.SubscribeValidatorSetUpdates(ctx)
+		require.NoError(t, err)
+
+		events <- ctypes.ResultEvent{Data: types.EventDataValidatorSetUpdates{ValidatorUpdates: []*types.Validator{newVal}}}
+		select {
+		case updates := <-updatesCh:
+			require.Len(t, updates, 1)
+			assert.Equal(t, newVal.Address, updates[0].Address)
+		case <-ctx.Done():
+			t.Fatal("no validator set updates")
+		}
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		t.Cleanup(cancel)
+
+		valSetAt := func(height int64) *types.ValidatorSet {
+			if height < 3 {
+				return prevSet
+			}
+			return nextSet
+		}
+		events := make(chan ctypes.ResultEvent, 3)
+		client := &mockClient{
+			subscribe: func(_ context.Context, _, query string) (<-chan ctypes.ResultEvent, error) {
+				if query == validatorUpdatesQuery {
+					return nil, errors.New("unknown event")
+				}
+				return events, nil
+			},
+			validators: func(_ context.Context, height *int64, _, _ *int) (*ctypes.ResultValidators, error) {
+				valSet := valSetAt(*height)
+				return &ctypes.ResultValidators{Validators: valSet.Validators, Total: valSet.Size()}, nil
+			},
+		}
+		fetcher, err := NewBlockFetcher(client)
+		require.NoError(t, err)
+
+		updatesCh, err := fetcher.SubscribeValidatorSetUpdates(ctx)
+		require.NoError(t, err)
+
+		for height := int64(1); height <= 3; height++ {
+			header := types.Header{Height: height, ValidatorsHash: valSetAt(height).Hash()}
+			events <- ctypes.ResultEvent{Data: types.EventDataNewBlockHeader{Header: header}}
+		}
+		select {
+		case updates := <-updatesCh:
+			require.Len(t, updates, 1)
+			assert.Equal(t, newVal.Address, updates[0].Address)
+			assert.Equal(t, newVal.VotingPower, updates[0].VotingPower)
+		case <-ctx.Done():
+			t.Fatal("no validator set updates")
+		}
+
+		// the channel is closed once the context is done
+		cancel()
+		for range updatesCh {
+		}
+	})
+
+	t.Run("fallback shared with signed headers", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		t.Cleanup(cancel)
+
+		valSetAt := func(height int64) *types.ValidatorSet {
+			if height < 3 {
+				return prevSet
+			}
+			return nextSet
+		}
+		var subscribed, unsubscribed atomic.Int32
+		events := make(chan ctypes.ResultEvent, 3)
+		client := &mockClient{
+			subscribe: func(_ context.Context, _, query string) (<-chan ctypes.ResultEvent, error) {
+				if query == validatorUpdatesQuery {
+					return nil, errors.New("unknown event")
+				}
+				subscribed.Add(1)
+				return events, nil
+			},
+			unsubscribe: func(context.Context, string, string) error {
+				unsubscribed.Add(1)
+				return nil
+			},
+			validators: func(_ context.Context, height *int64, _, _ *int) (*ctypes.ResultValidators, error) {
+				valSet := valSetAt(*height)
+				return &ctypes.ResultValidators{Validators: valSet.Validators, Total: valSet.Size()}, nil
+			},
+		}
+		fetcher, err := NewBlockFetcher(client)
+		require.NoError(t, err)
+
+		updatesCh, err := fetcher.SubscribeValidatorSetUpdates(ctx)
+		require.NoError(t, err)
+		// both subscribe to the new block headers
+		headersCtx, headersCancel := context.WithCancel(ctx)
+		headers, err := fetcher.SubscribeSignedHeaders(headersCtx)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, subscribed.Load())
+
+		// ending the signed headers leaves the validator set updates intact
+		headersCancel()
+		for range headers {
+		}
+		assert.Zero(t, unsubscribed.Load())
+
+		for height := int64(1); height <= 3; height++ {
+			header := types.Header{Height: height, ValidatorsHash: valSetAt(height).Hash()}
+			events <- ctypes.ResultEvent{Data: types.EventDataNewBlockHeader{Header: header}}
+		}
+		select {
+		case updates := <-updatesCh:
+			require.Len(t, updates, 1)
+			assert.Equal(t, newVal.Address, updates[0].Address)
+		case <-ctx.Done():
+			t.Fatal("no validator set updates")
+		}
+
+		cancel()
+		for range updatesCh {
+		}
+		assert.EqualValues(t, 1, unsubscribed.Load())
+	})
+}
+
+func TestBlockFetcher_GetValidatorSets(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	// the set changes at height 10 as a new validator joins
+	const change = 10
+	prevSet, _ := RandValidatorSet(3, 1)
+	newVal, _ := RandValidator(false, 1)
+	nextSet := types.NewValidatorSet(append(prevSet.Copy().Validators, newVal))
+	valSetAt := func(height int64) *types.ValidatorSet {
+		if height < change {
+			return prevSet
+		}
+		return nextSet
+	}
+
+	var infoCalls, valSetCalls atomic.Int32
+	client := &mockClient{
+		blockchainInfo: func(_ context.Context, minHeight, maxHeight int64) (*ctypes.ResultBlockchainInfo, error) {
+			infoCalls.Add(1)
+			res := &ctypes.ResultBlockchainInfo{LastHeight: 100}
+			// served from the highest height down
+			for height := maxHeight; height >= minHeight; height-- {
+				res.BlockMetas = append(res.BlockMetas, &types.BlockMeta{Header: types.Header{
+					Height:         height,
+					ValidatorsHash: valSetAt(height).Hash(),
+				}})
+			}
+			return res, nil
+		},
+		validators: func(_ context.Context, height *int64, _, _ *int) (*ctypes.ResultValidators, error) {
+			valSetCalls.Add(1)
+			valSet := valSetAt(*height)
+			return &ctypes.ResultValidators{Validators: valSet.Validators, Total: valSet.Size()}, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	valSets, err := fetcher.GetValidatorSets(ctx, 1, 30, 4)
+	require.NoError(t, err)
+	require.Len(t, valSets, 30)
+	for height, valSet := range valSets {
+		assert.Equal(t, valSetAt(height).Hash(), valSet.Hash(), height)
+	}
+
+	// one request per 20 headers, and one per distinct set
+	assert.EqualValues(t, 2, infoCalls.Load())
+	assert.EqualValues(t, 2, valSetCalls.Load())
+
+	_, err = fetcher.GetValidatorSets(ctx, 5, 4, 1)
+	assert.Error(t, err)
+
+	// a nil meta served in place of a height fails instead of panicking
+	infoCalls.Store(0)
+	valSetCalls.Store(0)
+	blockchainInfo := client.blockchainInfo
+	client.blockchainInfo = func(ctx context.Context, minHeight, maxHeight int64) (*ctypes.ResultBlockchainInfo, error) {
+		res, err := blockchainInfo(ctx, minHeight, maxHeight)
+		res.BlockMetas[0] = nil
+		return res, err
+	}
+	_, err = fetcher.GetValidatorSets(ctx, 1, 30, 4)
+	assert.ErrorContains(t, err, "block meta not found at height 20")
+	assert.Zero(t, valSetCalls.Load())
+}
+
+func TestBlockFetcher_GenesisValidatorSet(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	t.Cleanup(cancel)
+
+	t.Run("core", func(t *testing.T) {
+		_, _, cfg := StartTestKVApp(ctx, t)
+		endpoint, err := GetEndpoint(cfg)
+		require.NoError(t, err)
+		ip, port, err := net.SplitHostPort(endpoint)
+		require.NoError(t, err)
+		client, err := NewRemote(ip, port)
+		require.NoError(t, err)
+		fetcher, err := NewBlockFetcher(client)
+		require.NoError(t, err)
+
+		valSet, err := fetcher.GenesisValidatorSet(ctx)
+		require.NoError(t, err)
+
+		// the test node is run by its single configured validator
+		pv := privval.LoadFilePV(cfg.PrivValidatorKeyFile(), cfg.PrivValidatorStateFile())
+		require.Len(t, valSet.Validators, 1)
+		assert.Equal(t, pv.GetAddress(), valSet.Validators[0].Address)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		genesisSet, _ := RandValidatorSet(3, 1)
+		servedSet, _ := RandValidatorSet(3, 1)
+		genesis := &types.GenesisDoc{InitialHeight: 1}
+		for _, val := range genesisSet.Validators {
+			genesis.Validators = append(genesis.Validators, types.GenesisValidator{
+				Address: val.Address,
+				PubKey:  val.PubKey,
+				Power:   val.VotingPower,
+			})
+		}
+		client := &mockClient{
+			genesis: func(context.Context) (*ctypes.ResultGenesis, error) {
+				return &ctypes.ResultGenesis{Genesis: genesis}, nil
+			},
+			validators: func(context.Context, *int64, *int, *int) (*ctypes.ResultValidators, error) {
+				return &ctypes.ResultValidators{
+					Validators: servedSet.Validators,
+					Count:      len(servedSet.Validators),
+					Total:      len(servedSet.Validators),
+				}, nil
+			},
+		}
+		fetcher, err := NewBlockFetcher(client)
+		require.NoError(t, err)
+
+		_, err = fetcher.GenesisValidatorSet(ctx)
+		assert.ErrorContains(t, err, "does not match genesis validators hash")
+	})
+}
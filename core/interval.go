@@ -0,0 +1,91 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/tendermint/tendermint/types"
+)
+
+// BlockInterval is the time Core took to commit a block since its predecessor, i.e. the difference
+// between their timestamps.
+type BlockInterval struct {
+	Height int64
+	// Time is the timestamp of the block.
+	Time time.Time
+	// Interval is the time since the timestamp of the preceding block. It is zero for the block
+	// without a known predecessor, i.e. the initial block of the chain, the lowest block retained
+	// by Core or the first block received by BlockIntervals.
+	Interval time.Duration
+}
+
+// GetBlockIntervals queries Core for the BlockIntervals of the contiguous range of blocks
+// [from:to], e.g. for chain-health dashboards. The timestamps are read from the block metas, along
+// with the one of the block preceding the range, if any and retained by Core.
+func (f *BlockFetcher) GetBlockIntervals(ctx context.Context, from, to int64) ([]BlockInterval, error) {
+	first := from
+	if first > 1 {
+		_, lowest, err := f.tip(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if first > lowest {
+			// the predecessor of the first block of the range
+			first--
+		}
+	}
+	metas, err := f.GetBlockMetas(ctx, first, to)
+	if err != nil {
+		return nil, err
+	}
+
+	intervals := make([]BlockInterval, 0, len(metas))
+	var prev *types.Header
+	for _, meta := range metas {
+		meta := meta
+		if meta.Header.Height >= from {
+			intervals = append(intervals, blockInterval(&meta.Header, prev))
+		}
+		prev = &meta.Header
+	}
+	return intervals, nil
+}
+
+// BlockIntervals reports the BlockInterval of every block received from the given channel, e.g. the
+// subscription to new block events, until the channel is closed or the context is done. The
+// interval is only reported against the directly preceding block, so it is zero for the first
+// block and after a gap.
+func BlockIntervals(ctx context.Context, blocks <-chan *types.Block) <-chan BlockInterval {
+	intervals := make(chan BlockInterval)
+	go func() {
+		defer close(intervals)
+		var prev *types.Header
+		for {
+			select {
+			case block, ok := <-blocks:
+				if !ok {
+					return
+				}
+				select {
+				case intervals <- blockInterval(&block.Header, prev):
+				case <-ctx.Done():
+					return
+				}
+				prev = &block.Header
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return intervals
+}
+
+// blockInterval returns the BlockInterval of the given header since the given preceding one, if
+// it directly precedes it.
+func blockInterval(header, prev *types.Header) BlockInterval {
+	interval := BlockInterval{Height: header.Height, Time: header.Time}
+	if prev != nil && prev.Height == header.Height-1 {
+		interval.Interval = header.Time.Sub(prev.Time)
+	}
+	return interval
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+// newTimedBlock returns a block whose timestamp is the square of its height in seconds, so that
+// the interval since its predecessor is 2*height-1 seconds.
+func newTimedBlock(height int64) *types.Block {
+	block := newHeightBlock(height)
+	block.Time = time.Unix(height*height, 0).UTC()
+	return block
+}
+
+func TestBlockFetcher_GetBlockIntervals(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	var base atomic.Int64
+	client := &mockClient{
+		blockchainInfo: func(_ context.Context, minHeight, maxHeight int64) (*ctypes.ResultBlockchainInfo, error) {
+			res := &ctypes.ResultBlockchainInfo{LastHeight: maxHeight}
+			// Core serves the metas from its base height on
+			if minHeight < base.Load() {
+				minHeight = base.Load()
+			}
+			for height := maxHeight; height >= minHeight; height-- {
+				res.BlockMetas = append(res.BlockMetas, types.NewBlockMeta(newTimedBlock(height), nil))
+			}
+			return res, nil
+		},
+		status: func(context.Context) (*ctypes.ResultStatus, error) {
+			syncInfo := ctypes.SyncInfo{LatestBlockHeight: 100, EarliestBlockHeight: base.Load()}
+			return &ctypes.ResultStatus{SyncInfo: syncInfo}, nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client, WithTipTTL(0))
+	require.NoError(t, err)
+
+	intervals, err := fetcher.GetBlockIntervals(ctx, 1, 25)
+	require.NoError(t, err)
+	require.Len(t, intervals, 25)
+	// the initial block has no predecessor
+	assert.Equal(t, BlockInterval{Height: 1, Time: time.Unix(1, 0).UTC()}, intervals[0])
+	for _, interval := range intervals[1:] {
+		assert.Equal(t, time.Duration(2*interval.Height-1)*time.Second, interval.Interval, interval.Height)
+	}
+
+	// the predecessor of the range is fetched along
+	intervals, err = fetcher.GetBlockIntervals(ctx, 10, 12)
+	require.NoError(t, err)
+	require.Len(t, intervals, 3)
+	assert.EqualValues(t, 10, intervals[0].Height)
+	assert.Equal(t, 19*time.Second, intervals[0].Interval)
+
+	// the predecessor pruned by Core is unknown
+	base.Store(10)
+	intervals, err = fetcher.GetBlockIntervals(ctx, 10, 12)
+	require.NoError(t, err)
+	require.Len(t, intervals, 3)
+	assert.Zero(t, intervals[0].Interval)
+	assert.Equal(t, 21*time.Second, intervals[1].Interval)
+}
+
+func TestBlockIntervals(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	blocks := make(chan *types.Block)
+	intervals := BlockIntervals(ctx, blocks)
+	go func() {
+		defer close(blocks)
+		for _, height := range []int64{3, 4, 5, 8, 9} {
+			blocks <- newTimedBlock(height)
+		}
+	}()
+
+	expected := map[int64]time.Duration{
+		// no predecessor received
+		3: 0,
+		4: 7 * time.Second,
+		5: 9 * time.Second,
+		// after a gap
+		8: 0,
+		9: 17 * time.Second,
+	}
+	var received int
+	for interval := range intervals {
+		assert.Equal(t, expected[interval.Height], interval.Interval, interval.Height)
+		received++
+	}
+	assert.Equal(t, len(expected), received)
+}
@@ -0,0 +1,37 @@
+package core
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/instrument"
+)
+
+var meter = global.MeterProvider().Meter("core")
+
+// ReconnectMetrics returns a ReconnectObserver recording the attempts to reconnect to Core with
+// Otel metrics, counting them by endpoint and outcome along with their duration.
+func ReconnectMetrics() (func(ReconnectEvent), error) {
+	attempts, err := meter.SyncInt64().Counter("core_reconnect_attempts_counter",
+		instrument.WithDescription("attempts to re-establish the subscription to Core"))
+	if err != nil {
+		return nil, err
+	}
+
+	reconnectTime, err := meter.SyncFloat64().Histogram("core_reconnect_time_hist",
+		instrument.WithDescription("duration of an attempt to re-establish the subscription to Core"))
+	if err != nil {
+		return nil, err
+	}
+
+	return func(event ReconnectEvent) {
+		ctx := context.Background()
+		attrs := []attribute.KeyValue{
+			attribute.String("endpoint", event.Endpoint),
+			attribute.Bool("failed", event.Err != nil),
+		}
+		attempts.Add(ctx, 1, attrs...)
+		reconnectTime.Record(ctx, event.Duration.Seconds(), attrs...)
+	}, nil
+}
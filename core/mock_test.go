@@ -0,0 +1,270 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	tmjson "github.com/tendermint/tendermint/libs/json"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	rpctypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+)
+
+// mockClient is a Client serving requests through the functions set on it.
+// Calling a method without a function set panics.
+type mockClient struct {
+	Client
+	stopped atomic.Bool
+
+	subscribe func(ctx context.Context, subscriber, query string) (<-chan ctypes.ResultEvent, error)
+	// optional, unsubscribing always succeeds without it
+	unsubscribe func(ctx context.Context, subscriber, query string) error
+	block       func(ctx context.Context, height *int64) (*ctypes.ResultBlock, error)
+	commit      func(ctx context.Context, height *int64) (*ctypes.ResultCommit, error)
+	validators  func(ctx context.Context, height *int64, page, perPage *int) (*ctypes.ResultValidators, error)
+	status      func(ctx context.Context) (*ctypes.ResultStatus, error)
+	genesis     func(ctx context.Context) (*ctypes.ResultGenesis, error)
+
+	blockchainInfo func(ctx context.Context, minHeight, maxHeight int64) (*ctypes.ResultBlockchainInfo, error)
+}
+
+func (m *mockClient) Endpoint() string {
+	return "mock://core"
+}
+
+func (m *mockClient) IsRunning() bool {
+	return !m.stopped.Load()
+}
+
+func (m *mockClient) Subscribe(
+	ctx context.Context,
+	subscriber, query string,
+	_ ...int,
+) (<-chan ctypes.ResultEvent, error) {
+	return m.subscribe(ctx, subscriber, query)
+}
+
+func (m *mockClient) Unsubscribe(ctx context.Context, subscriber, query string) error {
+	if m.unsubscribe == nil {
+		return nil
+	}
+	return m.unsubscribe(ctx, subscriber, query)
+}
+
+func (m *mockClient) Block(ctx context.Context, height *int64) (*ctypes.ResultBlock, error) {
+	return m.block(ctx, height)
+}
+
+func (m *mockClient) Commit(ctx context.Context, height *int64) (*ctypes.ResultCommit, error) {
+	return m.commit(ctx, height)
+}
+
+func (m *mockClient) Validators(
+	ctx context.Context,
+	height *int64,
+	page, perPage *int,
+) (*ctypes.ResultValidators, error) {
+	return m.validators(ctx, height, page, perPage)
+}
+
+func (m *mockClient) Status(ctx context.Context) (*ctypes.ResultStatus, error) {
+	return m.status(ctx)
+}
+
+func (m *mockClient) Genesis(ctx context.Context) (*ctypes.ResultGenesis, error) {
+	return m.genesis(ctx)
+}
+
+func (m *mockClient) BlockchainInfo(
+	ctx context.Context,
+	minHeight, maxHeight int64,
+) (*ctypes.ResultBlockchainInfo, error) {
+	return m.blockchainInfo(ctx, minHeight, maxHeight)
+}
+
+// rpcHandler handles a JSON-RPC call to a stub Core endpoint, returning the result of the call.
+type rpcHandler func(method string, params json.RawMessage) (any, error)
+
+// newRPCServer starts a stub Core endpoint serving JSON-RPC calls over HTTP with the given handler.
+func newRPCServer(t *testing.T, handler rpcHandler) *httptest.Server {
+	srv := httptest.NewServer(newRPCHTTPHandler(handler))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newRPCHTTPHandler serves JSON-RPC calls over HTTP with the given handler.
+func newRPCHTTPHandler(handler rpcHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		out, status := serveRPC(handler, body)
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write(out)
+	})
+}
+
+// newRPCWSHandler serves JSON-RPC calls over the websocket with the given handler, until the
+// returned func is called to drop the connections and refuse new ones.
+func newRPCWSHandler(handler rpcHandler) (http.Handler, func()) {
+	var (
+		lk    sync.Mutex
+		down  bool
+		conns []*websocket.Conn
+	)
+	upgrader := websocket.Upgrader{}
+	serve := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lk.Lock()
+		if down {
+			lk.Unlock()
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			lk.Unlock()
+			return
+		}
+		conns = append(conns, conn)
+		lk.Unlock()
+
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			out, status := serveRPC(handler, msg)
+			if status != http.StatusOK {
+				continue
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, out); err != nil {
+				return
+			}
+		}
+	})
+	stop := func() {
+		lk.Lock()
+		defer lk.Unlock()
+		down = true
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}
+	return serve, stop
+}
+
+// newEventsWSHandler serves JSON-RPC calls over the websocket with the given handler, delivering
+// the given events over every subscription the handler confirms.
+func newEventsWSHandler(handler rpcHandler, events <-chan ctypes.ResultEvent) http.Handler {
+	upgrader := websocket.Upgrader{}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		done := make(chan struct{})
+		defer close(done)
+
+		var writeLk sync.Mutex
+		write := func(msg []byte) error {
+			writeLk.Lock()
+			defer writeLk.Unlock()
+			return conn.WriteMessage(websocket.TextMessage, msg)
+		}
+		deliver := func(query string) {
+			for {
+				select {
+				case event := <-events:
+					event.Query = query
+					result, err := tmjson.Marshal(event)
+					if err != nil {
+						return
+					}
+					out, err := json.Marshal(rpctypes.RPCResponse{
+						JSONRPC: "2.0",
+						ID:      rpctypes.JSONRPCStringID("event"),
+						Result:  result,
+					})
+					if err != nil || write(out) != nil {
+						return
+					}
+				case <-done:
+					return
+				}
+			}
+		}
+
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var (
+				subscribed bool
+				sub        struct {
+					Query string `json:"query"`
+				}
+			)
+			out, status := serveRPC(func(method string, params json.RawMessage) (any, error) {
+				result, err := handler(method, params)
+				if method == "subscribe" && err == nil {
+					subscribed = json.Unmarshal(params, &sub) == nil
+				}
+				return result, err
+			}, msg)
+			if status != http.StatusOK {
+				continue
+			}
+			if err := write(out); err != nil {
+				return
+			}
+			if subscribed {
+				go deliver(sub.Query)
+			}
+		}
+	})
+}
+
+// serveRPC serves the given JSON-RPC request with the given handler, returning the response and
+// the HTTP status of the call.
+func serveRPC(handler rpcHandler, body []byte) ([]byte, int) {
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, http.StatusBadRequest
+	}
+
+	resp := rpctypes.RPCResponse{JSONRPC: "2.0", ID: rpctypes.JSONRPCIntID(0)}
+	result, err := handler(req.Method, req.Params)
+	if err != nil {
+		resp.Error = &rpctypes.RPCError{Code: -32603, Message: "Internal error", Data: err.Error()}
+	} else {
+		resp.Result, err = tmjson.Marshal(result)
+		if err != nil {
+			return nil, http.StatusInternalServerError
+		}
+	}
+
+	out, err := json.Marshal(resp)
+	if err != nil {
+		return nil, http.StatusInternalServerError
+	}
+	// echo the request ID back, as the client expects
+	return bytes.Replace(out, []byte(`"id":0`), append([]byte(`"id":`), req.ID...), 1), http.StatusOK
+}
@@ -0,0 +1,876 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// parameters is an interface that encompasses all params needed for
+// client and fetcher parameters to protect `optional functions` from this package.
+type parameters interface {
+	ClientParameters | FetcherParameters
+}
+
+// Option is the functional option that is applied to the Core client or fetcher
+// to configure parameters.
+type Option[T parameters] func(*T)
+
+// ClientParameters is the set of parameters that must be configured for the Core client.
+type ClientParameters struct {
+	// Backoff defines the delay between retries of a failed request.
+	Backoff Backoff
+	// MaxRetries bounds the retries of a request failed with a transient error, independently of
+	// the Backoff. Zero disables the retries, while -1 retries until the request times out or
+	// its context is done.
+	MaxRetries int
+	// RequestTimeout bounds the duration of a request, including its retries.
+	// Zero means no timeout.
+	RequestTimeout time.Duration
+	// MethodTimeouts overrides RequestTimeout for particular RPC methods, e.g. "block" or "status".
+	MethodTimeouts map[string]time.Duration
+	// UserAgent defines the User-Agent header the client identifies itself with to Core.
+	UserAgent string
+	// HTTPClient is the HTTP client to send requests with instead of the default one.
+	// NOTE: Transport level settings, like TLS, proxies or the client's own timeout, then come
+	// from the supplied client and failed requests are not retried, as it is up to the client.
+	HTTPClient *http.Client
+	// TLS enables HTTPS for requests to Core, as well as secure websockets for the subscriptions, with
+	// the given config. Nil means plain HTTP.
+	TLS *tls.Config
+	// ClientCertFile and ClientKeyFile are the paths to the PEM encoded certificate and key
+	// the client authenticates itself to Core with over mutual TLS. Both require TLS.
+	ClientCertFile string
+	ClientKeyFile  string
+	// ChainID is the chain ID Core is expected to serve, checked once the client starts.
+	// Empty disables the check.
+	ChainID string
+	// EagerConnect probes the status of Core while constructing the client, failing the
+	// construction right away if Core is unreachable or serves a chain other than ChainID, so
+	// that a misconfigured endpoint is caught at the earliest point.
+	EagerConnect bool
+	// MaxIdleConnsPerHost bounds the idle connections to Core kept for reuse. Raise it along
+	// with the concurrency of fetching, so that concurrent requests don't reopen connections.
+	// Zero keeps the default of the transport.
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost bounds the connections to Core. Zero means no limit.
+	MaxConnsPerHost int
+	// KeepAlive defines how often the started client pings Core to keep the connection warm
+	// while idle. Zero disables the keepalive.
+	KeepAlive time.Duration
+	// RateLimit bounds the requests to Core per second, up to the RateBurst at once. Requests
+	// made with PriorityHigh context bypass the limit. Zero disables the limit.
+	RateLimit float64
+	// RateBurst defines how many requests can be made at once under the RateLimit.
+	// Zero means one.
+	RateBurst int
+	// DialContext dials the connections to Core, e.g. to resolve its hostname through a custom
+	// resolver. Nil dials with the system resolver.
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+	// ProxyDialer, if set, tunnels the connections to Core through a proxy, e.g. the SOCKS5 one
+	// of proxy.SOCKS5 for nodes in restricted networks. It dials instead of DialContext, leaving
+	// the hostname of Core to be resolved by the proxy.
+	ProxyDialer proxy.Dialer
+	// TCPNoDelay disables Nagle's algorithm on the connections to Core, sending the requests
+	// without delay. Enabled by default, as by Go.
+	TCPNoDelay bool
+	// TCPKeepAlive is the interval of the TCP keepalive probes on the connections to Core.
+	// Zero keeps the Go default, while a negative value disables the probes.
+	// NOTE: It is unrelated to KeepAlive, which pings Core on the RPC level.
+	TCPKeepAlive time.Duration
+	// Codec translates the requests to Core and its responses from and into the Tendermint
+	// encoding, e.g. for a Core variant with a slightly different encoding. TendermintCodec is
+	// the default.
+	Codec Codec
+	// RequestIDGenerator, if set, generates the IDs of the JSON-RPC requests to Core in place of
+	// the IDs of the Tendermint client, e.g. for gateways requiring a specific scheme. The responses
+	// are matched to the requests as before. See SequentialRequestIDs.
+	// NOTE: The reads served over the websocket with PreferWebsocket keep the IDs of the connection.
+	RequestIDGenerator RequestIDGenerator
+	// PreferWebsocket routes the reads over a persistent websocket connection to Core instead of
+	// HTTP, falling back to HTTP while the websocket is unavailable. The writes always use HTTP.
+	PreferWebsocket bool
+	// WSReadLimit bounds the size of the messages read over the websocket connections to Core,
+	// i.e. the events of the subscriptions and the reads with PreferWebsocket. A subscription
+	// receiving an event over the limit ends, failing the next subscription once with
+	// ErrWSReadLimit, while the reads fall back to HTTP. The default fits the events of the
+	// largest blocks.
+	WSReadLimit int64
+	// ConnStateObserver, if set, is called on every transition of the ConnState of the client,
+	// e.g. for a supervisor to react to Core going down. It is called synchronously, so it should
+	// be quick.
+	ConnStateObserver func(ConnState)
+	// WireObserver, if set, is called with the raw JSON-RPC request and response of every call to
+	// Core, e.g. to log them while debugging a misbehaving endpoint. Each retry of a call is
+	// reported on its own. The credentials are redacted from the reported headers. It is called
+	// synchronously, so it should be quick.
+	// NOTE: The reads served over the websocket with PreferWebsocket are not reported.
+	WireObserver func(WireEvent)
+
+	// httpClientSet tracks whether HTTPClient was set explicitly, so that nil can be rejected.
+	httpClientSet bool
+	// proxyDialerSet tracks whether ProxyDialer was set explicitly, so that nil can be rejected.
+	proxyDialerSet bool
+	// maxRetriesSet tracks whether MaxRetries was set explicitly, so that it can be rejected along
+	// with HTTPClient.
+	maxRetriesSet bool
+}
+
+// defaultMaxRetries is the default of the MaxRetries parameter.
+const defaultMaxRetries = 2
+
+// defaultWSReadLimit is the default of the WSReadLimit parameter.
+const defaultWSReadLimit = 64 << 20
+
+// DefaultClientParameters returns the default params to configure the Core client.
+func DefaultClientParameters() *ClientParameters {
+	return &ClientParameters{
+		Backoff:     DefaultBackoff(),
+		MaxRetries:  defaultMaxRetries,
+		UserAgent:   defaultUserAgent(),
+		TCPNoDelay:  true,
+		Codec:       TendermintCodec{},
+		WSReadLimit: defaultWSReadLimit,
+	}
+}
+
+func (p *ClientParameters) Validate() error {
+	if p.Backoff == nil {
+		return fmt.Errorf("invalid Backoff: should not be nil")
+	}
+	if p.MaxRetries < -1 {
+		return fmt.Errorf("invalid MaxRetries: should not be below -1. Provided value: %d", p.MaxRetries)
+	}
+	if p.RequestTimeout < 0 {
+		return fmt.Errorf("invalid RequestTimeout: should not be negative. Provided value: %v", p.RequestTimeout)
+	}
+	for method, timeout := range p.MethodTimeouts {
+		if timeout <= 0 {
+			return fmt.Errorf("invalid MethodTimeouts: timeout for %s should be positive. Provided value: %v",
+				method, timeout)
+		}
+	}
+	if p.UserAgent == "" {
+		return fmt.Errorf("invalid UserAgent: should not be empty")
+	}
+	if p.KeepAlive < 0 {
+		return fmt.Errorf("invalid KeepAlive: should not be negative. Provided value: %v", p.KeepAlive)
+	}
+	if p.RateLimit < 0 {
+		return fmt.Errorf("invalid RateLimit: should not be negative. Provided value: %v", p.RateLimit)
+	}
+	if p.RateBurst < 0 {
+		return fmt.Errorf("invalid RateBurst: should not be negative. Provided value: %d", p.RateBurst)
+	}
+	if p.MaxIdleConnsPerHost < 0 {
+		return fmt.Errorf("invalid MaxIdleConnsPerHost: should not be negative. Provided value: %d",
+			p.MaxIdleConnsPerHost)
+	}
+	if p.MaxConnsPerHost < 0 {
+		return fmt.Errorf("invalid MaxConnsPerHost: should not be negative. Provided value: %d", p.MaxConnsPerHost)
+	}
+	if p.HTTPClient != nil && (p.MaxIdleConnsPerHost != 0 || p.MaxConnsPerHost != 0) {
+		return fmt.Errorf("invalid MaxIdleConnsPerHost and MaxConnsPerHost: " +
+			"should be configured on the supplied HTTPClient")
+	}
+	if p.HTTPClient != nil && p.maxRetriesSet {
+		return fmt.Errorf("invalid MaxRetries: should be configured on the supplied HTTPClient")
+	}
+	if p.HTTPClient != nil && p.DialContext != nil {
+		return fmt.Errorf("invalid DialContext: should be configured on the supplied HTTPClient")
+	}
+	if p.proxyDialerSet && p.ProxyDialer == nil {
+		return fmt.Errorf("invalid ProxyDialer: should not be nil")
+	}
+	if p.HTTPClient != nil && p.ProxyDialer != nil {
+		return fmt.Errorf("invalid ProxyDialer: should be configured on the supplied HTTPClient")
+	}
+	if p.DialContext != nil && p.ProxyDialer != nil {
+		return fmt.Errorf("invalid ProxyDialer: can't be combined with DialContext")
+	}
+	if p.HTTPClient != nil && (!p.TCPNoDelay || p.TCPKeepAlive != 0) {
+		return fmt.Errorf("invalid TCPNoDelay and TCPKeepAlive: should be configured on the supplied HTTPClient")
+	}
+	if p.Codec == nil {
+		return fmt.Errorf("invalid Codec: should not be nil")
+	}
+	if _, ok := p.Codec.(TendermintCodec); !ok && p.PreferWebsocket {
+		return fmt.Errorf("invalid Codec: can't be combined with PreferWebsocket")
+	}
+	if p.HTTPClient != nil && p.PreferWebsocket {
+		return fmt.Errorf("invalid PreferWebsocket: can't be combined with the supplied HTTPClient")
+	}
+	if p.WSReadLimit <= 0 {
+		return fmt.Errorf("invalid WSReadLimit: should be positive. Provided value: %d", p.WSReadLimit)
+	}
+	if p.httpClientSet && p.HTTPClient == nil {
+		return fmt.Errorf("invalid HTTPClient: should not be nil")
+	}
+	if (p.ClientCertFile == "") != (p.ClientKeyFile == "") {
+		return fmt.Errorf("invalid ClientCertFile and ClientKeyFile: should be both set or both empty")
+	}
+	if p.ClientCertFile != "" && p.TLS == nil {
+		return fmt.Errorf("invalid ClientCertFile: requires TLS")
+	}
+	if p.HTTPClient != nil && p.TLS != nil {
+		return fmt.Errorf("invalid TLS: should be configured on the supplied HTTPClient")
+	}
+	return nil
+}
+
+// TimeCheck defines how the BlockFetcher handles a block whose time is not after the time of
+// the preceding block when fetching a range of blocks.
+type TimeCheck int
+
+const (
+	// TimeCheckOff disables the check.
+	TimeCheckOff TimeCheck = iota
+	// TimeCheckWarn logs a warning and keeps the block.
+	TimeCheckWarn
+	// TimeCheckFail fails the fetch with ErrNonMonotonicTime.
+	TimeCheckFail
+)
+
+// FetcherParameters is the set of parameters that must be configured for the BlockFetcher.
+type FetcherParameters struct {
+	// Backoff defines the delay between attempts to re-subscribe to new block events
+	// once the subscription is lost, as well as between retries of a failed height in a batch.
+	Backoff Backoff
+	// Clock provides the time to the Tip cache, the Backoff waits, the Heartbeat, the sync polling of
+	// WaitUntilSynced and the observed events, e.g. so that tests can control it.
+	Clock Clock
+	// TimeCheck defines how blocks going back in time are handled when fetching a range.
+	TimeCheck TimeCheck
+	// Heartbeat defines how long to wait for a new block event before probing Core for
+	// a silently stalled subscription. It should be a few times longer than the expected
+	// block time. Zero disables the probing.
+	Heartbeat time.Duration
+	// SubscribeTimeout bounds establishing a subscription to events of Core, i.e. dialing the
+	// websocket and waiting for Core to confirm the subscription, so that subscribing to an
+	// endpoint that never responds fails instead of hanging. Zero means no timeout.
+	SubscribeTimeout time.Duration
+	// CoalesceNewBlocks makes the subscription to new block events keep only the latest block while
+	// the consumer is busy, dropping the intermediate ones, for consumers following only the tip.
+	CoalesceNewBlocks bool
+	// BatchRetries caps the retries of failed heights shared by all the heights of a batch
+	// fetched with GetBlocks or GetBlockRange. Zero disables the retries.
+	BatchRetries int
+	// BatchTimeout bounds the duration of fetching a whole batch, including the retries.
+	// Zero means no timeout.
+	BatchTimeout time.Duration
+	// ClampToAvailable makes GetBlockRange and GetBlockRangeAdaptive trim the requested range to
+	// the heights retained by Core instead of failing on the pruned ones, so that the effective
+	// range is the one of the returned blocks. See ClampRange.
+	ClampToAvailable bool
+	// CacheSize defines the number of fetched blocks kept in memory by height. The cached blocks
+	// are shared by all the callers fetching them, so they must not be modified. Zero, the
+	// default, disables the cache.
+	CacheSize int
+	// CacheMaxBytes bounds the total size of the cached blocks in bytes, evicting the least
+	// recently used blocks once exceeded. Zero means no bound on the size.
+	CacheMaxBytes int
+	// MaxInFlightBytes bounds the total size in bytes of the blocks fetched by GetBlocks,
+	// GetBlockRange and StreamBlockRange, but not handed to the caller yet, pausing the workers once
+	// exceeded regardless of the concurrency, so that backfilling large blocks doesn't spike the
+	// memory. GetBlocks and GetBlockRange hand all the blocks over at once, so for them it only
+	// bounds the blocks being fetched, while StreamBlockRange holds every block in the budget until
+	// the consumer receives it. Zero means no bound.
+	MaxInFlightBytes int
+	// PrefetchWindow defines how many blocks received from the subscription to new block events are
+	// added to the cache at once, as soon as their events arrive, so that the consumers requesting
+	// the block right after the event hit the cache without fetching it again. The blocks arriving
+	// while the window is full are not cached. It requires the cache. Zero disables the
+	// prefetching.
+	PrefetchWindow int
+	// TipTTL defines how long the height returned by Tip is cached for. Zero disables the cache.
+	TipTTL time.Duration
+	// TipJitter bounds the random duration added to TipTTL on every refresh of the height,
+	// so that the caches of many pollers expire at different times.
+	TipJitter time.Duration
+	// Observer, if set, is called on completion of every GetBlock call, e.g. to collect custom
+	// metrics. It is called synchronously, so it should be quick.
+	Observer func(FetchEvent)
+	// ReceiveObserver, if set, is called with every block received from the subscription to new
+	// block events, e.g. to analyze the delivery latency. It is called synchronously before the
+	// block is emitted, so it should be quick.
+	ReceiveObserver func(ReceiveEvent)
+	// ReconnectObserver, if set, is called on every attempt to re-establish the lost subscription
+	// to new block events, e.g. to track flaky connectivity with ReconnectMetrics. It is called
+	// synchronously, so it should be quick.
+	ReconnectObserver func(ReconnectEvent)
+	// ProgressObserver, if set, is called as the blocks of GetBlockRange and GetBlockRangeAdaptive
+	// are fetched and as the blocks of ExportRange are exported, e.g. to drive a progress bar.
+	// It is called synchronously, so it should be quick.
+	ProgressObserver func(ProgressEvent)
+	// VerifyDataHash enables recomputing the data square root of every block fetched from Core
+	// or received from the new block subscription, and rejecting the block with
+	// ErrDataHashMismatch if it doesn't match the DataHash of its header. It is on by default, so
+	// that unverified data is never trusted, e.g. by bridge nodes, and can be turned off by
+	// read-only tooling for performance, as recomputing the square doubles the cost of every
+	// block.
+	VerifyDataHash bool
+	// VerifyLastResults enables checking the LastResultsHash of every block fetched with
+	// GetVerifiedBlock against the results of the previous block. It costs an extra request
+	// per block, so it is off by default.
+	VerifyLastResults bool
+}
+
+// DefaultFetcherParameters returns the default params to configure the BlockFetcher.
+func DefaultFetcherParameters() *FetcherParameters {
+	return &FetcherParameters{
+		Backoff:   DefaultBackoff(),
+		Clock:     RealClock(),
+		TimeCheck: TimeCheckWarn,
+		Heartbeat: time.Minute,
+		// as long as the chain ID check of the client
+		SubscribeTimeout: time.Second * 30,
+		TipTTL:           time.Second,
+		TipJitter:        time.Millisecond * 250,
+		VerifyDataHash:   true,
+	}
+}
+
+func (p *FetcherParameters) Validate() error {
+	if p.Backoff == nil {
+		return fmt.Errorf("invalid Backoff: should not be nil")
+	}
+	if p.Clock == nil {
+		return fmt.Errorf("invalid Clock: should not be nil")
+	}
+	if p.TimeCheck < TimeCheckOff || p.TimeCheck > TimeCheckFail {
+		return fmt.Errorf("invalid TimeCheck: unknown mode. Provided value: %d", p.TimeCheck)
+	}
+	if p.Heartbeat < 0 {
+		return fmt.Errorf("invalid Heartbeat: should not be negative. Provided value: %v", p.Heartbeat)
+	}
+	if p.SubscribeTimeout < 0 {
+		return fmt.Errorf("invalid SubscribeTimeout: should not be negative. Provided value: %v", p.SubscribeTimeout)
+	}
+	if p.BatchRetries < 0 {
+		return fmt.Errorf("invalid BatchRetries: should not be negative. Provided value: %d", p.BatchRetries)
+	}
+	if p.BatchTimeout < 0 {
+		return fmt.Errorf("invalid BatchTimeout: should not be negative. Provided value: %v", p.BatchTimeout)
+	}
+	if p.CacheSize < 0 {
+		return fmt.Errorf("invalid CacheSize: should not be negative. Provided value: %d", p.CacheSize)
+	}
+	if p.CacheMaxBytes < 0 {
+		return fmt.Errorf("invalid CacheMaxBytes: should not be negative. Provided value: %d", p.CacheMaxBytes)
+	}
+	if p.MaxInFlightBytes < 0 {
+		return fmt.Errorf("invalid MaxInFlightBytes: should not be negative. Provided value: %d", p.MaxInFlightBytes)
+	}
+	if p.PrefetchWindow < 0 {
+		return fmt.Errorf("invalid PrefetchWindow: should not be negative. Provided value: %d", p.PrefetchWindow)
+	}
+	if p.PrefetchWindow > 0 && p.CacheSize == 0 {
+		return fmt.Errorf("invalid PrefetchWindow: requires the cache")
+	}
+	if p.TipTTL < 0 {
+		return fmt.Errorf("invalid TipTTL: should not be negative. Provided value: %v", p.TipTTL)
+	}
+	if p.TipJitter < 0 {
+		return fmt.Errorf("invalid TipJitter: should not be negative. Provided value: %v", p.TipJitter)
+	}
+	return nil
+}
+
+// WithBackoff is a functional option that configures the
+// `Backoff` parameter.
+func WithBackoff[T parameters](backoff Backoff) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) {
+		case *ClientParameters:
+			t.Backoff = backoff
+		case *FetcherParameters:
+			t.Backoff = backoff
+		}
+	}
+}
+
+// WithMaxRetries is a functional option that configures the
+// `MaxRetries` parameter.
+func WithMaxRetries[T ClientParameters](retries int) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.MaxRetries = retries
+			t.maxRetriesSet = true
+		}
+	}
+}
+
+// WithClock is a functional option that configures the
+// `Clock` parameter.
+func WithClock[T FetcherParameters](clock Clock) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *FetcherParameters:
+			t.Clock = clock
+		}
+	}
+}
+
+// WithTimeCheck is a functional option that configures the
+// `TimeCheck` parameter.
+func WithTimeCheck[T FetcherParameters](check TimeCheck) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *FetcherParameters:
+			t.TimeCheck = check
+		}
+	}
+}
+
+// WithRequestTimeout is a functional option that configures the
+// `RequestTimeout` parameter.
+func WithRequestTimeout[T ClientParameters](timeout time.Duration) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.RequestTimeout = timeout
+		}
+	}
+}
+
+// WithMethodTimeouts is a functional option that configures the
+// `MethodTimeouts` parameter.
+func WithMethodTimeouts[T ClientParameters](timeouts map[string]time.Duration) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.MethodTimeouts = timeouts
+		}
+	}
+}
+
+// WithSubscribeTimeout is a functional option that configures the
+// `SubscribeTimeout` parameter.
+func WithSubscribeTimeout[T FetcherParameters](timeout time.Duration) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *FetcherParameters:
+			t.SubscribeTimeout = timeout
+		}
+	}
+}
+
+// WithCoalesceNewBlocks is a functional option that configures the
+// `CoalesceNewBlocks` parameter.
+func WithCoalesceNewBlocks[T FetcherParameters](coalesce bool) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *FetcherParameters:
+			t.CoalesceNewBlocks = coalesce
+		}
+	}
+}
+
+// WithHeartbeat is a functional option that configures the
+// `Heartbeat` parameter.
+func WithHeartbeat[T FetcherParameters](interval time.Duration) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *FetcherParameters:
+			t.Heartbeat = interval
+		}
+	}
+}
+
+// WithUserAgent is a functional option that configures the
+// `UserAgent` parameter.
+func WithUserAgent[T ClientParameters](userAgent string) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.UserAgent = userAgent
+		}
+	}
+}
+
+// WithHTTPClient is a functional option that configures the
+// `HTTPClient` parameter.
+func WithHTTPClient[T ClientParameters](client *http.Client) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.HTTPClient = client
+			t.httpClientSet = true
+		}
+	}
+}
+
+// WithBatchRetries is a functional option that configures the
+// `BatchRetries` parameter.
+func WithBatchRetries[T FetcherParameters](retries int) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *FetcherParameters:
+			t.BatchRetries = retries
+		}
+	}
+}
+
+// WithBatchTimeout is a functional option that configures the
+// `BatchTimeout` parameter.
+func WithBatchTimeout[T FetcherParameters](timeout time.Duration) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *FetcherParameters:
+			t.BatchTimeout = timeout
+		}
+	}
+}
+
+// WithClampToAvailable is a functional option that configures the
+// `ClampToAvailable` parameter.
+func WithClampToAvailable[T FetcherParameters](clamp bool) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *FetcherParameters:
+			t.ClampToAvailable = clamp
+		}
+	}
+}
+
+// WithCacheSize is a functional option that configures the
+// `CacheSize` parameter.
+func WithCacheSize[T FetcherParameters](size int) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *FetcherParameters:
+			t.CacheSize = size
+		}
+	}
+}
+
+// WithCacheMaxBytes is a functional option that configures the
+// `CacheMaxBytes` parameter.
+func WithCacheMaxBytes[T FetcherParameters](maxBytes int) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *FetcherParameters:
+			t.CacheMaxBytes = maxBytes
+		}
+	}
+}
+
+// WithMaxInFlightBytes is a functional option that configures the
+// `MaxInFlightBytes` parameter.
+func WithMaxInFlightBytes[T FetcherParameters](maxBytes int) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *FetcherParameters:
+			t.MaxInFlightBytes = maxBytes
+		}
+	}
+}
+
+// WithPrefetchWindow is a functional option that configures the
+// `PrefetchWindow` parameter.
+func WithPrefetchWindow[T FetcherParameters](window int) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *FetcherParameters:
+			t.PrefetchWindow = window
+		}
+	}
+}
+
+// WithTipTTL is a functional option that configures the
+// `TipTTL` parameter.
+func WithTipTTL[T FetcherParameters](ttl time.Duration) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *FetcherParameters:
+			t.TipTTL = ttl
+		}
+	}
+}
+
+// WithTipJitter is a functional option that configures the
+// `TipJitter` parameter.
+func WithTipJitter[T FetcherParameters](jitter time.Duration) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *FetcherParameters:
+			t.TipJitter = jitter
+		}
+	}
+}
+
+// WithChainID is a functional option that configures the
+// `ChainID` parameter.
+func WithChainID[T ClientParameters](chainID string) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.ChainID = chainID
+		}
+	}
+}
+
+// WithEagerConnect is a functional option that configures the
+// `EagerConnect` parameter.
+func WithEagerConnect[T ClientParameters](eager bool) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.EagerConnect = eager
+		}
+	}
+}
+
+// WithKeepAlive is a functional option that configures the
+// `KeepAlive` parameter.
+func WithKeepAlive[T ClientParameters](interval time.Duration) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.KeepAlive = interval
+		}
+	}
+}
+
+// WithTLS is a functional option that configures the
+// `TLS` parameter.
+func WithTLS[T ClientParameters](config *tls.Config) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.TLS = config
+		}
+	}
+}
+
+// WithClientCertificate is a functional option that configures the
+// `ClientCertFile` and `ClientKeyFile` parameters.
+func WithClientCertificate[T ClientParameters](certFile, keyFile string) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.ClientCertFile = certFile
+			t.ClientKeyFile = keyFile
+		}
+	}
+}
+
+// WithConnPool is a functional option that configures the
+// `MaxIdleConnsPerHost` and `MaxConnsPerHost` parameters.
+func WithConnPool[T ClientParameters](maxIdleConnsPerHost, maxConnsPerHost int) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.MaxIdleConnsPerHost = maxIdleConnsPerHost
+			t.MaxConnsPerHost = maxConnsPerHost
+		}
+	}
+}
+
+// WithRateLimit is a functional option that configures the
+// `RateLimit` and `RateBurst` parameters.
+func WithRateLimit[T ClientParameters](rate float64, burst int) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.RateLimit = rate
+			t.RateBurst = burst
+		}
+	}
+}
+
+// WithDialContext is a functional option that configures the
+// `DialContext` parameter.
+func WithDialContext[T ClientParameters](
+	dial func(ctx context.Context, network, addr string) (net.Conn, error),
+) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.DialContext = dial
+		}
+	}
+}
+
+// WithProxyDialer is a functional option that configures the
+// `ProxyDialer` parameter.
+func WithProxyDialer[T ClientParameters](dialer proxy.Dialer) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.ProxyDialer = dialer
+			t.proxyDialerSet = true
+		}
+	}
+}
+
+// WithResolver is a functional option that configures the
+// `DialContext` parameter to resolve the Core hostname with the given resolver.
+func WithResolver[T ClientParameters](resolver *net.Resolver) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.DialContext = (&net.Dialer{Resolver: resolver}).DialContext
+		}
+	}
+}
+
+// WithTCPNoDelay is a functional option that configures the
+// `TCPNoDelay` parameter.
+func WithTCPNoDelay[T ClientParameters](noDelay bool) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.TCPNoDelay = noDelay
+		}
+	}
+}
+
+// WithConnStateObserver is a functional option that configures the
+// `ConnStateObserver` parameter.
+func WithConnStateObserver[T ClientParameters](observer func(ConnState)) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.ConnStateObserver = observer
+		}
+	}
+}
+
+// WithWireObserver is a functional option that configures the
+// `WireObserver` parameter.
+func WithWireObserver[T ClientParameters](observer func(WireEvent)) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.WireObserver = observer
+		}
+	}
+}
+
+// WithTCPKeepAlive is a functional option that configures the
+// `TCPKeepAlive` parameter.
+func WithTCPKeepAlive[T ClientParameters](interval time.Duration) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.TCPKeepAlive = interval
+		}
+	}
+}
+
+// WithCodec is a functional option that configures the
+// `Codec` parameter.
+func WithCodec[T ClientParameters](codec Codec) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.Codec = codec
+		}
+	}
+}
+
+// WithRequestIDGenerator is a functional option that configures the
+// `RequestIDGenerator` parameter.
+func WithRequestIDGenerator[T ClientParameters](generator RequestIDGenerator) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.RequestIDGenerator = generator
+		}
+	}
+}
+
+// WithPreferWebsocket is a functional option that configures the
+// `PreferWebsocket` parameter.
+func WithPreferWebsocket[T ClientParameters](prefer bool) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.PreferWebsocket = prefer
+		}
+	}
+}
+
+// WithWSReadLimit is a functional option that configures the
+// `WSReadLimit` parameter.
+func WithWSReadLimit[T ClientParameters](limit int64) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *ClientParameters:
+			t.WSReadLimit = limit
+		}
+	}
+}
+
+// WithDataHashVerification is a functional option that configures the
+// `VerifyDataHash` parameter.
+func WithDataHashVerification[T FetcherParameters](verify bool) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *FetcherParameters:
+			t.VerifyDataHash = verify
+		}
+	}
+}
+
+// WithReceiveObserver is a functional option that configures the
+// `ReceiveObserver` parameter.
+func WithReceiveObserver[T FetcherParameters](observer func(ReceiveEvent)) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *FetcherParameters:
+			t.ReceiveObserver = observer
+		}
+	}
+}
+
+// WithReconnectObserver is a functional option that configures the
+// `ReconnectObserver` parameter.
+func WithReconnectObserver[T FetcherParameters](observer func(ReconnectEvent)) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *FetcherParameters:
+			t.ReconnectObserver = observer
+		}
+	}
+}
+
+// WithProgressObserver is a functional option that configures the
+// `ProgressObserver` parameter.
+func WithProgressObserver[T FetcherParameters](observer func(ProgressEvent)) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *FetcherParameters:
+			t.ProgressObserver = observer
+		}
+	}
+}
+
+// WithLastResultsVerification is a functional option that configures the
+// `VerifyLastResults` parameter.
+func WithLastResultsVerification[T FetcherParameters](verify bool) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *FetcherParameters:
+			t.VerifyLastResults = verify
+		}
+	}
+}
+
+// WithObserver is a functional option that configures the
+// `Observer` parameter.
+func WithObserver[T FetcherParameters](observer func(FetchEvent)) Option[T] {
+	return func(p *T) {
+		switch t := any(p).(type) { //nolint:gocritic
+		case *FetcherParameters:
+			t.Observer = observer
+		}
+	}
+}
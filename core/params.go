@@ -0,0 +1,67 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tendermint/tendermint/types"
+
+	"github.com/celestiaorg/celestia-app/pkg/appconsts"
+	appshares "github.com/celestiaorg/celestia-app/pkg/shares"
+)
+
+// DAParams are the parameters of the chain bounding the data square the block data extends into.
+type DAParams struct {
+	// Height is the height the parameters are in effect at.
+	Height int64
+	// MinSquareSize and MaxSquareSize bound the width of the original data square.
+	MinSquareSize uint64
+	MaxSquareSize uint64
+	// MaxBlockBytes is the maximum size of a block, from the consensus params.
+	MaxBlockBytes int64
+}
+
+// GetDAParams queries Core for the DAParams in effect at the given height. A nil height requests
+// the params in effect at the latest height.
+// NOTE: The square size limits are constants of the app, so only MaxBlockBytes is queried.
+func (f *BlockFetcher) GetDAParams(ctx context.Context, height *int64) (*DAParams, error) {
+	if err := validateHeight(height); err != nil {
+		return nil, err
+	}
+
+	res, err := f.client.ConsensusParams(ctx, height)
+	if err != nil {
+		return nil, heightError(err)
+	}
+	if height != nil && res.BlockHeight != *height {
+		return nil, &ErrHeightMismatch{Requested: *height, Returned: res.BlockHeight}
+	}
+	return &DAParams{
+		Height:        res.BlockHeight,
+		MinSquareSize: appconsts.MinSquareSize,
+		MaxSquareSize: appconsts.MaxSquareSize,
+		MaxBlockBytes: res.ConsensusParams.Block.MaxBytes,
+	}, nil
+}
+
+// ValidateSquareSize checks the given original square size is a power of two within the limits.
+func (p *DAParams) ValidateSquareSize(size uint64) error {
+	if size < p.MinSquareSize || size > p.MaxSquareSize || !appshares.IsPowerOfTwo(size) {
+		return fmt.Errorf("core: square size %d is not a power of two between %d and %d",
+			size, p.MinSquareSize, p.MaxSquareSize)
+	}
+	return nil
+}
+
+// ValidateBlock checks the given block fits the DAParams before its data is extended, so that
+// oversized blocks are rejected without extending them.
+func (p *DAParams) ValidateBlock(block *types.Block) error {
+	if err := p.ValidateSquareSize(block.Data.OriginalSquareSize); err != nil {
+		return fmt.Errorf("core: block at height %d: %w", block.Height, err)
+	}
+	if size := int64(block.Size()); size > p.MaxBlockBytes {
+		return fmt.Errorf("core: block at height %d of %d bytes exceeds max of %d bytes",
+			block.Height, size, p.MaxBlockBytes)
+	}
+	return nil
+}
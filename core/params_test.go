@@ -0,0 +1,46 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/celestiaorg/celestia-app/pkg/appconsts"
+	"github.com/celestiaorg/celestia-app/testutil/testnode"
+)
+
+func TestBlockFetcher_GetDAParams(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	t.Cleanup(cancel)
+
+	_, client := StartTestCoreWithApp(t)
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+	for {
+		tip, err := fetcher.Tip(ctx)
+		require.NoError(t, err)
+		if tip > 1 {
+			break
+		}
+		time.Sleep(time.Millisecond * 50)
+	}
+
+	height := int64(1)
+	params, err := fetcher.GetDAParams(ctx, &height)
+	require.NoError(t, err)
+	assert.Equal(t, height, params.Height)
+	assert.Equal(t, uint64(appconsts.MinSquareSize), params.MinSquareSize)
+	assert.Equal(t, uint64(appconsts.MaxSquareSize), params.MaxSquareSize)
+	assert.Equal(t, testnode.DefaultParams().Block.MaxBytes, params.MaxBlockBytes)
+
+	block, err := fetcher.GetBlock(ctx, &height)
+	require.NoError(t, err)
+	assert.NoError(t, params.ValidateBlock(block))
+	assert.NoError(t, params.ValidateSquareSize(appconsts.MaxSquareSize))
+	assert.Error(t, params.ValidateSquareSize(appconsts.MaxSquareSize*2))
+	assert.Error(t, params.ValidateSquareSize(3))
+	assert.Error(t, params.ValidateSquareSize(0))
+}
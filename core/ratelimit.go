@@ -0,0 +1,107 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Priority is the priority of requests to Core, deciding their precedence under the RateLimit.
+type Priority int
+
+const (
+	// PriorityNormal requests, e.g. bulk backfill, wait for the RateLimit.
+	PriorityNormal Priority = iota
+	// PriorityHigh requests, e.g. latency-sensitive sampling, bypass the RateLimit. They still
+	// count against it, so that the normal requests give way to them.
+	PriorityHigh
+)
+
+type priorityKey struct{}
+
+// ContextWithPriority returns a copy of the context carrying the given Priority
+// for the requests made with it.
+func ContextWithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// PriorityFromContext returns the Priority carried by the context,
+// defaulting to PriorityNormal.
+func PriorityFromContext(ctx context.Context) Priority {
+	priority, _ := ctx.Value(priorityKey{}).(Priority)
+	return priority
+}
+
+// rateLimiter is a token bucket refilled at the given rate up to the burst.
+type rateLimiter struct {
+	rate  float64
+	burst float64
+
+	lk     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait takes a token, waiting for it to be refilled unless the priority is high.
+func (l *rateLimiter) wait(ctx context.Context, priority Priority) error {
+	delay := l.take()
+	if delay == 0 || priority == PriorityHigh {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// the request is not made, so give the token back
+		l.lk.Lock()
+		l.tokens++
+		l.lk.Unlock()
+		return ctx.Err()
+	}
+}
+
+// take takes a token, returning how long it takes for it to be refilled.
+// Tokens are taken in advance, so they go negative while being waited for.
+func (l *rateLimiter) take() time.Duration {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// rateLimitTransport holds requests back to the rate of the limiter according to their Priority.
+type rateLimitTransport struct {
+	base    http.RoundTripper
+	limiter *rateLimiter
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.wait(req.Context(), PriorityFromContext(req.Context())); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+func TestRateLimit_Priority(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	srv := newRPCServer(t, func(string, json.RawMessage) (any, error) {
+		return &ctypes.ResultHealth{}, nil
+	})
+	client := newTestRemote(t, srv.URL, WithRateLimit(10, 1))
+
+	// saturate the limit with normal requests for a couple of seconds ahead
+	normalCtx, normalCancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = client.Ping(normalCtx)
+		}()
+	}
+	t.Cleanup(func() {
+		normalCancel()
+		wg.Wait()
+	})
+	time.Sleep(time.Millisecond * 50)
+
+	start := time.Now()
+	require.NoError(t, client.Ping(ContextWithPriority(ctx, PriorityHigh)))
+	assert.Less(t, time.Since(start), time.Millisecond*100)
+
+	// while the normal one waits for the saturated limit
+	timeoutCtx, timeoutCancel := context.WithTimeout(ctx, time.Millisecond*100)
+	defer timeoutCancel()
+	assert.ErrorIs(t, client.Ping(timeoutCtx), context.DeadlineExceeded)
+}
+
+func TestPriorityFromContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, PriorityNormal, PriorityFromContext(ctx))
+	assert.Equal(t, PriorityHigh, PriorityFromContext(ContextWithPriority(ctx, PriorityHigh)))
+}
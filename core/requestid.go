@@ -0,0 +1,115 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// RequestIDGenerator generates the IDs of the JSON-RPC requests to Core, e.g. for gateways
+// requiring unique or monotonically increasing IDs. An ID has to be a string or a number. It is
+// called concurrently, so it must be safe for concurrent use.
+type RequestIDGenerator func() any
+
+// SequentialRequestIDs returns a RequestIDGenerator of monotonically increasing numbers, starting
+// from 1.
+func SequentialRequestIDs() RequestIDGenerator {
+	var next atomic.Uint64
+	return func() any {
+		return next.Add(1)
+	}
+}
+
+// requestIDTransport replaces the IDs of the JSON-RPC requests, including the batched ones, with
+// the generated ones, and restores the original IDs in the responses, so that the client matches
+// the responses to its requests as before.
+type requestIDTransport struct {
+	base     http.RoundTripper
+	generate RequestIDGenerator
+}
+
+func (t *requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return t.base.RoundTrip(req)
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	msgs, batch, err := decodeRPCMessages(body)
+	if err != nil {
+		return nil, fmt.Errorf("core: decoding request: %w", err)
+	}
+
+	// the original ID of every request by the generated one
+	original := make(map[string]json.RawMessage, len(msgs))
+	for _, msg := range msgs {
+		id, err := json.Marshal(t.generate())
+		if err != nil {
+			return nil, fmt.Errorf("core: encoding request ID: %w", err)
+		}
+		original[string(id)] = msg["id"]
+		msg["id"] = id
+	}
+	body, err = encodeRPCMessages(msgs, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	// the responses which are not JSON-RPC, e.g. of failed requests, are passed as they are
+	if msgs, batch, err := decodeRPCMessages(body); err == nil {
+		for _, msg := range msgs {
+			if id, ok := original[string(msg["id"])]; ok {
+				msg["id"] = id
+			}
+		}
+		if body, err = encodeRPCMessages(msgs, batch); err != nil {
+			return nil, err
+		}
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
+
+// decodeRPCMessages decodes the JSON-RPC message or the batch of messages, telling which it was.
+func decodeRPCMessages(body []byte) ([]map[string]json.RawMessage, bool, error) {
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		var msgs []map[string]json.RawMessage
+		return msgs, true, json.Unmarshal(body, &msgs)
+	}
+	var msg map[string]json.RawMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, false, err
+	}
+	return []map[string]json.RawMessage{msg}, false, nil
+}
+
+// encodeRPCMessages encodes the JSON-RPC messages decoded by decodeRPCMessages.
+func encodeRPCMessages(msgs []map[string]json.RawMessage, batch bool) ([]byte, error) {
+	if batch {
+		return json.Marshal(msgs)
+	}
+	return json.Marshal(msgs[0])
+}
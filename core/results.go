@@ -0,0 +1,106 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+// ErrLastResultsMismatch is returned when the LastResultsHash of a block does not match the results
+// of the transactions of the previous block, meaning the app and consensus states diverged.
+type ErrLastResultsMismatch struct {
+	Height   int64
+	Expected tmbytes.HexBytes
+	Computed tmbytes.HexBytes
+}
+
+func (e *ErrLastResultsMismatch) Error() string {
+	return fmt.Sprintf("core/fetcher: last results hash mismatch at height %d: header %X, computed %X",
+		e.Height, e.Expected, e.Computed)
+}
+
+// GetBlockResults queries Core for the results of executing the transactions of the block at the
+// given height. A nil height requests the results of the latest block.
+func (f *BlockFetcher) GetBlockResults(ctx context.Context, height *int64) (*ctypes.ResultBlockResults, error) {
+	if err := validateHeight(height); err != nil {
+		return nil, err
+	}
+
+	res, err := f.client.BlockResults(ctx, height)
+	if err != nil {
+		return nil, heightError(err)
+	}
+	if height != nil && res.Height != *height {
+		return nil, &ErrHeightMismatch{Requested: *height, Returned: res.Height}
+	}
+	return res, nil
+}
+
+// VerifyLastResults queries Core for the results of the block preceding the given one and checks
+// them against the LastResultsHash of the block. See VerifyLastResultsHash.
+func (f *BlockFetcher) VerifyLastResults(ctx context.Context, block *types.Block) error {
+	if block.Height == 1 {
+		// there is no previous block
+		return nil
+	}
+
+	prevHeight := block.Height - 1
+	prevResults, err := f.GetBlockResults(ctx, &prevHeight)
+	if err != nil {
+		return fmt.Errorf("core/fetcher: getting results at height %d: %w", prevHeight, err)
+	}
+	return VerifyLastResultsHash(block, prevResults)
+}
+
+// VerifyLastResultsHash checks the LastResultsHash of the block matches the given results of the
+// previous block, returning ErrLastResultsMismatch otherwise.
+func VerifyLastResultsHash(block *types.Block, prevResults *ctypes.ResultBlockResults) error {
+	if prevResults.Height != block.Height-1 {
+		return fmt.Errorf("core/fetcher: results at height %d don't precede block at height %d",
+			prevResults.Height, block.Height)
+	}
+
+	computed := types.NewResults(prevResults.TxsResults).Hash()
+	if !bytes.Equal(computed, block.LastResultsHash) {
+		return &ErrLastResultsMismatch{Height: block.Height, Expected: block.LastResultsHash, Computed: computed}
+	}
+	return nil
+}
+
+// GasUsage is the gas consumed by the transactions of a block, as reported by their results.
+type GasUsage struct {
+	Height  int64
+	TxCount int
+	// GasWanted is the total gas limit requested by the transactions.
+	GasWanted int64
+	// GasUsed is the total gas actually consumed by the transactions.
+	GasUsed int64
+}
+
+// GetGasUsage queries Core for the results of the block at the given height and sums the gas of its
+// transactions. A nil height requests the gas usage of the latest block. See SumGas.
+func (f *BlockFetcher) GetGasUsage(ctx context.Context, height *int64) (*GasUsage, error) {
+	res, err := f.GetBlockResults(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+	return SumGas(res), nil
+}
+
+// SumGas sums the gas wanted and used by the transactions in the given block results, e.g. for
+// monitoring the capacity of the chain. Failed transactions are included, as they consume gas too.
+func SumGas(results *ctypes.ResultBlockResults) *GasUsage {
+	usage := &GasUsage{
+		Height:  results.Height,
+		TxCount: len(results.TxsResults),
+	}
+	for _, tx := range results.TxsResults {
+		usage.GasWanted += tx.GasWanted
+		usage.GasUsed += tx.GasUsed
+	}
+	return usage
+}
@@ -0,0 +1,113 @@
+package core
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/rand"
+	"github.com/tendermint/tendermint/types"
+
+	"github.com/celestiaorg/celestia-app/app"
+	paytypes "github.com/celestiaorg/celestia-app/x/payment/types"
+)
+
+func TestBlockFetcher_VerifyLastResults(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	t.Cleanup(cancel)
+
+	_, _, cfg := StartTestKVApp(ctx, t)
+	endpoint, err := GetEndpoint(cfg)
+	require.NoError(t, err)
+	ip, port, err := net.SplitHostPort(endpoint)
+	require.NoError(t, err)
+	client, err := NewRemote(ip, port)
+	require.NoError(t, err)
+	require.NoError(t, client.Start())
+	t.Cleanup(func() {
+		require.NoError(t, client.Stop())
+	})
+	// the blocks of the KV app don't commit to the data square
+	fetcher, err := NewBlockFetcher(client, WithLastResultsVerification(true), WithDataHashVerification(false))
+	require.NoError(t, err)
+
+	// the results of the block with the tx are committed to by the next block
+	res, err := client.BroadcastTxCommit(ctx, types.Tx("results=hash"))
+	require.NoError(t, err)
+	height := res.Height + 1
+	for {
+		tip, err := fetcher.Tip(ctx)
+		require.NoError(t, err)
+		if tip >= height {
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	prevResults, err := fetcher.GetBlockResults(ctx, &res.Height)
+	require.NoError(t, err)
+	require.Len(t, prevResults.TxsResults, 1)
+
+	sb, err := fetcher.GetVerifiedBlock(ctx, &height)
+	require.NoError(t, err)
+	require.NoError(t, VerifyLastResultsHash(sb.Block, prevResults))
+
+	sb.LastResultsHash = rand.Bytes(32)
+	err = fetcher.VerifyLastResults(ctx, sb.Block)
+	var errMismatch *ErrLastResultsMismatch
+	require.ErrorAs(t, err, &errMismatch)
+	assert.Equal(t, height, errMismatch.Height)
+	assert.Equal(t, sb.LastResultsHash, errMismatch.Expected)
+	assert.Equal(t, types.NewResults(prevResults.TxsResults).Hash(), []byte(errMismatch.Computed))
+}
+
+func TestBlockFetcher_GetGasUsage(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	t.Cleanup(cancel)
+
+	_, client, cctx, accounts := StartTestCoreWithAccounts(t)
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+	// the funded accounts are queryable once the genesis state is committed
+	for {
+		tip, err := fetcher.Tip(ctx)
+		require.NoError(t, err)
+		if tip > 1 {
+			break
+		}
+		time.Sleep(time.Millisecond * 50)
+	}
+
+	signer := paytypes.NewKeyringSigner(cctx.Keyring, accounts[0], cctx.ChainID)
+	require.NoError(t, signer.UpdateAccountFromClient(cctx.Context))
+	from, err := signer.GetSignerInfo().GetAddress()
+	require.NoError(t, err)
+	to, err := paytypes.NewKeyringSigner(cctx.Keyring, accounts[1], cctx.ChainID).GetSignerInfo().GetAddress()
+	require.NoError(t, err)
+
+	const gasLimit = 1000000
+	msg := banktypes.NewMsgSend(from, to, sdk.NewCoins(sdk.NewInt64Coin(app.BondDenom, 10)))
+	tx, err := signer.BuildSignedTx(signer.NewTxBuilder(paytypes.SetGasLimit(gasLimit)), msg)
+	require.NoError(t, err)
+	rawTx, err := signer.EncodeTx(tx)
+	require.NoError(t, err)
+
+	res, err := client.BroadcastTxCommit(ctx, rawTx)
+	require.NoError(t, err)
+	require.Zero(t, res.CheckTx.Code, res.CheckTx.Log)
+	require.Zero(t, res.DeliverTx.Code, res.DeliverTx.Log)
+
+	usage, err := fetcher.GetGasUsage(ctx, &res.Height)
+	require.NoError(t, err)
+	assert.Equal(t, res.Height, usage.Height)
+	require.Equal(t, 1, usage.TxCount)
+	assert.EqualValues(t, gasLimit, usage.GasWanted)
+	assert.Positive(t, usage.GasUsed)
+	assert.LessOrEqual(t, usage.GasUsed, usage.GasWanted)
+	assert.Equal(t, res.DeliverTx.GasUsed, usage.GasUsed)
+}
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	tmmath "github.com/tendermint/tendermint/libs/math"
+	"github.com/tendermint/tendermint/types"
+)
+
+// DefaultTrustLevel is the trust level of the light clients: more than 1/3 of the trusted
+// voting power has to sign a header to trust it when skipping.
+var DefaultTrustLevel = tmmath.Fraction{Numerator: 1, Denominator: 3}
+
+// errCannotTrust is returned by verifySkip when the trusted validators can't establish the trust.
+var errCannotTrust = errors.New("core/fetcher: not enough trusted voting power signed")
+
+// VerifySkipping queries Core for the light block at the target height and verifies it against
+// the trusted one using the skipping verification of the light clients: the target is trusted
+// if more than the trust level of the trusted voting power signed its Commit, without verifying
+// every intermediate header. If the trusted validators can't establish the trust directly, e.g.
+// as the validators changed too much in between, the range is bisected, verifying the header
+// in the middle first.
+// NOTE: The trusted light block is taken as trusted now: it is up to the caller to ensure it is
+// still within the trusting period.
+func (f *BlockFetcher) VerifySkipping(
+	ctx context.Context,
+	trusted *types.LightBlock,
+	target int64,
+	trustLevel tmmath.Fraction,
+) (*types.LightBlock, error) {
+	if err := validateTrustLevel(trustLevel); err != nil {
+		return nil, err
+	}
+	if target <= trusted.Height {
+		return nil, fmt.Errorf("core/fetcher: target height %d is not above trusted height %d",
+			target, trusted.Height)
+	}
+
+	untrusted, err := f.getLightBlock(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.verifySkipping(ctx, trusted, untrusted, trustLevel); err != nil {
+		return nil, err
+	}
+	return untrusted, nil
+}
+
+// verifySkipping verifies the untrusted light block against the trusted one, bisecting the range
+// between them while the trust can't be established directly.
+func (f *BlockFetcher) verifySkipping(
+	ctx context.Context,
+	trusted, untrusted *types.LightBlock,
+	trustLevel tmmath.Fraction,
+) error {
+	err := verifySkip(trusted, untrusted, trustLevel)
+	if !errors.Is(err, errCannotTrust) {
+		return err
+	}
+
+	pivot, err := f.getLightBlock(ctx, (trusted.Height+untrusted.Height)/2)
+	if err != nil {
+		return err
+	}
+	if err := f.verifySkipping(ctx, trusted, pivot, trustLevel); err != nil {
+		return err
+	}
+	return f.verifySkipping(ctx, pivot, untrusted, trustLevel)
+}
+
+// verifySkip verifies the untrusted light block against the trusted one without the intermediate
+// headers. The adjacent blocks are verified by the link of their validator sets instead.
+// errCannotTrust is returned if the trusted validators can't establish the trust.
+func verifySkip(trusted, untrusted *types.LightBlock, trustLevel tmmath.Fraction) error {
+	if err := untrusted.ValidateBasic(trusted.ChainID); err != nil {
+		return fmt.Errorf("core/fetcher: invalid light block at height %d: %w", untrusted.Height, err)
+	}
+	if !untrusted.Time.After(trusted.Time) {
+		return fmt.Errorf("core/fetcher: header at height %d has time %s, which is not after trusted time %s",
+			untrusted.Height, untrusted.Time, trusted.Time)
+	}
+
+	if untrusted.Height == trusted.Height+1 {
+		if !bytes.Equal(untrusted.ValidatorsHash, trusted.NextValidatorsHash) {
+			return fmt.Errorf("core/fetcher: validators hash %X at height %d does not match next validators "+
+				"hash %X of the trusted header", untrusted.ValidatorsHash, untrusted.Height, trusted.NextValidatorsHash)
+		}
+	} else {
+		err := trusted.ValidatorSet.VerifyCommitLightTrusting(trusted.ChainID, untrusted.Commit, trustLevel)
+		var errPower types.ErrNotEnoughVotingPowerSigned
+		if errors.As(err, &errPower) {
+			return fmt.Errorf("%w at height %d: %s", errCannotTrust, untrusted.Height, err)
+		}
+		if err != nil {
+			return fmt.Errorf("core/fetcher: trusting header at height %d: %w", untrusted.Height, err)
+		}
+	}
+
+	err := untrusted.ValidatorSet.VerifyCommitLight(
+		trusted.ChainID, untrusted.Commit.BlockID, untrusted.Height, untrusted.Commit)
+	if err != nil {
+		return fmt.Errorf("core/fetcher: verifying commit at height %d: %w", untrusted.Height, err)
+	}
+	return nil
+}
+
+// getLightBlock queries Core for the signed header and the ValidatorSet at the given height.
+func (f *BlockFetcher) getLightBlock(ctx context.Context, height int64) (*types.LightBlock, error) {
+	sh, err := f.GetSignedHeader(ctx, &height)
+	if err != nil {
+		return nil, err
+	}
+	valSet, err := f.validatorSetByHash(ctx, height, sh.ValidatorsHash)
+	if err != nil {
+		return nil, fmt.Errorf("core/fetcher: getting validator set at height %d: %w", height, err)
+	}
+	return &types.LightBlock{SignedHeader: sh, ValidatorSet: valSet}, nil
+}
+
+// validateTrustLevel ensures the trust level is within [1/3, 1], as required by the light clients.
+func validateTrustLevel(lvl tmmath.Fraction) error {
+	if lvl.Denominator == 0 || lvl.Numerator*3 < lvl.Denominator || lvl.Numerator > lvl.Denominator {
+		return fmt.Errorf("core/fetcher: invalid trust level %v: should be within [1/3, 1]", lvl)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package core
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tmmath "github.com/tendermint/tendermint/libs/math"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	tmversion "github.com/tendermint/tendermint/proto/tendermint/version"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+	"github.com/tendermint/tendermint/version"
+)
+
+// epoch is a run of blocks signed by the same validators.
+type epoch struct {
+	signers []types.PrivValidator
+	blocks  int
+}
+
+// makeEpochChain makes a chain of blocks starting at height 1 signed by the validators of every
+// epoch in turn. If linked, the last block of an epoch commits to the validators of the next one
+// as its NextValidatorsHash, as in the honest chain.
+func makeEpochChain(t *testing.T, epochs []epoch, linked bool) []*SignedBlock {
+	valSets := make([]*types.ValidatorSet, len(epochs))
+	for i, ep := range epochs {
+		sort.Sort(types.PrivValidatorsByAddress(ep.signers))
+		vals := make([]*types.Validator, len(ep.signers))
+		for j, signer := range ep.signers {
+			pubKey, err := signer.GetPubKey()
+			require.NoError(t, err)
+			vals[j] = types.NewValidator(pubKey, 1)
+		}
+		valSets[i] = types.NewValidatorSet(vals)
+	}
+
+	var (
+		chain       []*SignedBlock
+		lastBlockID types.BlockID
+		lastCommit  = &types.Commit{}
+		start       = time.Now()
+	)
+	for i, ep := range epochs {
+		valSet := valSets[i]
+		for j := 0; j < ep.blocks; j++ {
+			height := int64(len(chain) + 1)
+			blockTime := start.Add(time.Duration(height) * time.Second)
+			nextValSet := valSet
+			if linked && j == ep.blocks-1 && i+1 < len(epochs) {
+				nextValSet = valSets[i+1]
+			}
+
+			block := types.MakeBlock(height, types.Data{}, lastCommit)
+			block.Header.Populate(
+				tmversion.Consensus{Block: version.BlockProtocol},
+				signedBlockChainID,
+				blockTime,
+				lastBlockID,
+				valSet.Hash(),
+				nextValSet.Hash(),
+				nil,
+				nil,
+				nil,
+				valSet.GetProposer().Address,
+			)
+			block.DataHash = emptyDataHash()
+			voteSet := types.NewVoteSet(signedBlockChainID, height, 0, tmproto.PrecommitType, valSet)
+			sb, err := MakeSignedBlock(block, voteSet, valSet, ep.signers, blockTime)
+			require.NoError(t, err)
+
+			chain = append(chain, sb)
+			lastBlockID, lastCommit = sb.Commit.BlockID, sb.Commit
+		}
+	}
+	return chain
+}
+
+func TestBlockFetcher_VerifySkipping(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	t.Cleanup(cancel)
+
+	_, a := RandValidatorSet(4, 1)
+	_, n := RandValidatorSet(4, 1)
+	// half of the validators are replaced every epoch
+	b := []types.PrivValidator{a[0], a[1], n[0], n[1]}
+	c := []types.PrivValidator{n[0], n[1], n[2], n[3]}
+
+	verify := func(t *testing.T, chain []*SignedBlock, target int64) (*types.LightBlock, int32, error) {
+		client := newSignedChainClient(chain)
+		var commitCalls atomic.Int32
+		commit := client.commit
+		client.commit = func(ctx context.Context, height *int64) (*ctypes.ResultCommit, error) {
+			commitCalls.Add(1)
+			return commit(ctx, height)
+		}
+		fetcher, err := NewBlockFetcher(client)
+		require.NoError(t, err)
+
+		trusted := &types.LightBlock{
+			SignedHeader: &types.SignedHeader{Header: &chain[0].Header, Commit: chain[0].Commit},
+			ValidatorSet: chain[0].ValidatorSet,
+		}
+		lb, err := fetcher.VerifySkipping(ctx, trusted, target, DefaultTrustLevel)
+		return lb, commitCalls.Load(), err
+	}
+
+	t.Run("within trust", func(t *testing.T) {
+		chain := makeEpochChain(t, []epoch{{a, 15}, {b, 15}}, true)
+		lb, calls, err := verify(t, chain, 30)
+		require.NoError(t, err)
+		assert.EqualValues(t, 30, lb.Height)
+		assert.Equal(t, chain[29].Hash(), lb.Hash())
+		// only the target is fetched
+		assert.EqualValues(t, 1, calls)
+	})
+
+	t.Run("bisection", func(t *testing.T) {
+		chain := makeEpochChain(t, []epoch{{a, 10}, {b, 10}, {c, 10}}, true)
+		lb, calls, err := verify(t, chain, 30)
+		require.NoError(t, err)
+		assert.EqualValues(t, 30, lb.Height)
+		assert.Greater(t, calls, int32(1))
+	})
+
+	t.Run("outside trust", func(t *testing.T) {
+		// the validators are replaced at once without the chain committing to them
+		chain := makeEpochChain(t, []epoch{{a, 20}, {c, 10}}, false)
+		_, _, err := verify(t, chain, 30)
+		assert.ErrorContains(t, err, "does not match next validators hash")
+	})
+
+	t.Run("invalid trust level", func(t *testing.T) {
+		chain := makeEpochChain(t, []epoch{{a, 2}}, true)
+		fetcher, err := NewBlockFetcher(newSignedChainClient(chain))
+		require.NoError(t, err)
+		trusted := &types.LightBlock{
+			SignedHeader: &types.SignedHeader{Header: &chain[0].Header, Commit: chain[0].Commit},
+			ValidatorSet: chain[0].ValidatorSet,
+		}
+		_, err = fetcher.VerifySkipping(ctx, trusted, 2, DefaultTrustLevel)
+		require.NoError(t, err)
+		_, err = fetcher.VerifySkipping(ctx, trusted, 2, tmmath.Fraction{Numerator: 1, Denominator: 4})
+		assert.Error(t, err)
+	})
+}
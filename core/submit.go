@@ -0,0 +1,159 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+// maxTxsPerBatch is the largest number of transactions broadcast in a single batch request,
+// keeping the requests within the size limits of Core.
+const maxTxsPerBatch = 100
+
+// submitPollInterval defines how often SubmitTxs checks for the inclusion of the submitted
+// transactions, as well as how long it waits for space in the full mempool of Core.
+var submitPollInterval = time.Millisecond * 250
+
+// SubmitResult is the outcome of submitting a single transaction with SubmitTxs.
+type SubmitResult struct {
+	// Hash is the hash of the transaction.
+	Hash tmbytes.HexBytes
+	// Height is the height of the block the transaction was included in, or zero if it wasn't.
+	Height int64
+	// Err is the error the transaction was rejected or failed with, if any.
+	Err error
+}
+
+// ErrTxFailed is reported for a transaction rejected by CheckTx, in which case it is never
+// included, or failed by DeliverTx, in which case it is included with no effect.
+type ErrTxFailed struct {
+	Hash tmbytes.HexBytes
+	Code uint32
+	Log  string
+}
+
+func (e *ErrTxFailed) Error() string {
+	return fmt.Sprintf("core: tx %X failed with code %d: %s", e.Hash, e.Code, e.Log)
+}
+
+// SubmitTxs broadcasts the given transactions to Core and waits for all of them to be included,
+// e.g. for load-testing or setting up a chain. The results are ordered as the transactions, each
+// reporting the inclusion height or the error of its transaction. The transactions are broadcast
+// in batch requests where the client supports them, and wait for space while the mempool is full.
+// On failure, the results resolved so far are returned along with the context error.
+func SubmitTxs(ctx context.Context, client Client, txs [][]byte) ([]*SubmitResult, error) {
+	results := make([]*SubmitResult, len(txs))
+	for i, tx := range txs {
+		results[i] = &SubmitResult{Hash: types.Tx(tx).Hash()}
+	}
+
+	for from := 0; from < len(txs); from += maxTxsPerBatch {
+		to := from + maxTxsPerBatch
+		if to > len(txs) {
+			to = len(txs)
+		}
+		if err := broadcastTxs(ctx, client, txs[from:to], results[from:to]); err != nil {
+			return results, err
+		}
+	}
+
+	ticker := time.NewTicker(submitPollInterval)
+	defer ticker.Stop()
+	for {
+		pending := 0
+		for _, res := range results {
+			if res.Height != 0 || res.Err != nil {
+				continue
+			}
+			tx, err := client.Tx(ctx, res.Hash, false)
+			var errNotFound *ErrTxNotFound
+			switch {
+			case errors.As(err, &errNotFound):
+				pending++
+				continue
+			case err != nil:
+				if ctx.Err() != nil {
+					return results, ctx.Err()
+				}
+				// the tx is looked up again on the next poll
+				log.Debugw("looking up submitted tx", "hash", res.Hash, "err", err)
+				pending++
+				continue
+			}
+			res.Height = tx.Height
+			if tx.TxResult.Code != 0 {
+				res.Err = &ErrTxFailed{Hash: res.Hash, Code: tx.TxResult.Code, Log: tx.TxResult.Log}
+			}
+		}
+		if pending == 0 {
+			return results, nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return results, ctx.Err()
+		}
+	}
+}
+
+// broadcastTxs broadcasts the given transactions with CheckTx, recording the rejected ones in
+// their results. The transactions are sent in a single batch request if the client supports it,
+// and one by one otherwise or if the batch fails.
+func broadcastTxs(ctx context.Context, client Client, txs [][]byte, results []*SubmitResult) error {
+	if raw, ok := client.Raw().(*rpchttp.HTTP); ok {
+		batch := raw.NewBatch()
+		for _, tx := range txs {
+			_, _ = batch.BroadcastTxSync(ctx, tx)
+		}
+		// a single failed tx fails the whole batch, so the txs are then resent one by one,
+		// with the already accepted ones reported as cached
+		resps, err := batch.Send(ctx)
+		if err == nil {
+			for i, resp := range resps {
+				checkBroadcast(results[i], resp.(*ctypes.ResultBroadcastTx))
+			}
+			return nil
+		}
+		log.Debugw("broadcasting tx batch, falling back to single txs", "err", err)
+	}
+
+	for i, tx := range txs {
+		for {
+			resp, err := client.BroadcastTxSync(ctx, tx)
+			switch {
+			case err == nil:
+				checkBroadcast(results[i], resp)
+			case strings.Contains(err.Error(), "tx already exists in cache"):
+				// accepted before
+			case strings.Contains(err.Error(), "mempool is full"):
+				select {
+				case <-time.After(submitPollInterval):
+					continue
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			case ctx.Err() != nil:
+				return ctx.Err()
+			default:
+				results[i].Err = fmt.Errorf("core: broadcasting tx %X: %w", results[i].Hash, err)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// checkBroadcast records the rejection of a broadcast transaction by CheckTx in its result.
+func checkBroadcast(res *SubmitResult, resp *ctypes.ResultBroadcastTx) {
+	if resp.Code != 0 {
+		res.Err = &ErrTxFailed{Hash: res.Hash, Code: resp.Code, Log: resp.Log}
+	}
+}
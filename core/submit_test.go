@@ -0,0 +1,59 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubmitTxs(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	t.Cleanup(cancel)
+
+	_, _, cfg := StartTestKVApp(ctx, t)
+	endpoint, err := GetEndpoint(cfg)
+	require.NoError(t, err)
+	ip, port, err := net.SplitHostPort(endpoint)
+	require.NoError(t, err)
+	client, err := NewRemote(ip, port)
+	require.NoError(t, err)
+
+	t.Run("batch", func(t *testing.T) {
+		txs := make([][]byte, 0, maxTxsPerBatch+10)
+		for i := 0; i < cap(txs); i++ {
+			txs = append(txs, []byte(fmt.Sprintf("batch=%d", i)))
+		}
+		results, err := SubmitTxs(ctx, client, txs)
+		require.NoError(t, err)
+		require.Len(t, results, len(txs))
+
+		for i, res := range results {
+			require.NoError(t, res.Err)
+			assert.Positive(t, res.Height)
+
+			tx, err := client.Tx(ctx, res.Hash, false)
+			require.NoError(t, err)
+			assert.Equal(t, res.Height, tx.Height)
+			assert.EqualValues(t, txs[i], tx.Tx)
+		}
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		// the duplicate fails the batch, so the txs are resent one by one
+		txs := [][]byte{[]byte("dup=1"), []byte("dup=2"), []byte("dup=1")}
+		results, err := SubmitTxs(ctx, client, txs)
+		require.NoError(t, err)
+		require.Len(t, results, len(txs))
+
+		for _, res := range results {
+			require.NoError(t, res.Err)
+			assert.Positive(t, res.Height)
+		}
+		assert.Equal(t, results[0], results[2])
+	})
+}
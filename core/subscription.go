@@ -0,0 +1,288 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tmquery "github.com/tendermint/tendermint/libs/pubsub/query"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+// ErrSubscriptionClosed is returned when subscribing over a connection to Core that is closed,
+// e.g. as the client is stopped or Core shuts down. Retrying helps once the connection is back.
+var ErrSubscriptionClosed = errors.New("core/fetcher: subscription connection closed")
+
+// ErrInvalidQuery is returned when subscribing with a query that can't be parsed. Retrying won't
+// help, the query has to be fixed.
+type ErrInvalidQuery struct {
+	Query string
+	Err   error
+}
+
+func (e *ErrInvalidQuery) Error() string {
+	return fmt.Sprintf("core/fetcher: invalid subscription query %q: %s", e.Query, e.Err)
+}
+
+func (e *ErrInvalidQuery) Unwrap() error {
+	return e.Err
+}
+
+// ErrTooManySubscriptions is returned when Core rejects a subscription over its limit of the
+// subscriptions per client or of the subscribed clients. Retrying only helps once some of the
+// subscriptions end, otherwise the limit has to be raised.
+type ErrTooManySubscriptions struct {
+	// Limit is the limit reached.
+	Limit int
+	// PerClient tells whether the limit is of the subscriptions per client rather than of the
+	// subscribed clients.
+	PerClient bool
+}
+
+func (e *ErrTooManySubscriptions) Error() string {
+	if e.PerClient {
+		return fmt.Sprintf("core/fetcher: too many subscriptions: limit of %d per client reached", e.Limit)
+	}
+	return fmt.Sprintf("core/fetcher: too many subscriptions: limit of %d subscribed clients reached", e.Limit)
+}
+
+// validateQuery ensures the subscription query can be parsed, so that a bad query fails before
+// reaching Core.
+func validateQuery(query string) error {
+	if _, err := tmquery.New(query); err != nil {
+		return &ErrInvalidQuery{Query: query, Err: err}
+	}
+	return nil
+}
+
+// subscriptionError translates the errors Tendermint fails a subscription with into
+// ErrInvalidQuery, ErrTooManySubscriptions or ErrSubscriptionClosed. Other errors are returned
+// unchanged.
+func subscriptionError(query string, err error) error {
+	// Core only reports the failures as a part of the error message
+	msg := err.Error()
+	var limit int
+	switch {
+	case strings.Contains(msg, "failed to parse query"),
+		strings.Contains(msg, "maximum query length exceeded"):
+		return &ErrInvalidQuery{Query: query, Err: err}
+	case scanLimit(msg, "max_subscriptions_per_client", &limit):
+		return &ErrTooManySubscriptions{Limit: limit, PerClient: true}
+	case scanLimit(msg, "max_subscription_clients", &limit):
+		return &ErrTooManySubscriptions{Limit: limit}
+	case strings.Contains(msg, "client is not running"),
+		strings.Contains(msg, "service is shutting down"):
+		return fmt.Errorf("%w: %s", ErrSubscriptionClosed, err)
+	}
+	return err
+}
+
+// scanLimit scans the limit from the "<name> <limit> reached" part of the message.
+func scanLimit(msg, name string, limit *int) bool {
+	idx := strings.Index(msg, name+" ")
+	if idx == -1 {
+		return false
+	}
+	_, err := fmt.Sscanf(msg[idx:], name+" %d reached", limit)
+	return err == nil
+}
+
+// querySubscription is the subscription of the fetcher to the events of Core matching a query,
+// shared by all the subscribers of the fetcher to the query. Core keeps a single subscription per
+// query and connection, so subscribing to the query once more would replace the subscription of
+// the other subscribers, and unsubscribing would end it for all of them.
+// The calls to Core are made without holding the lock of the subscriptions, so that they don't
+// hold up the other queries, while the querySubscription stays in place for the other subscribers
+// to the query to wait for them.
+type querySubscription struct {
+	// the subscriber the client is subscribed for
+	subscriber  string
+	subscribers map[string]*subscriberChan
+	// closed once subscribing the client succeeds or fails
+	ready chan struct{}
+	// closed once the last subscriber unsubscribes
+	done chan struct{}
+	// closed once the client is unsubscribed after the last subscriber unsubscribed
+	unsubscribed chan struct{}
+}
+
+// subscriberChan is the channel of the events of a querySubscription to one of its subscribers.
+type subscriberChan struct {
+	out chan ctypes.ResultEvent
+	// closed once the subscriber unsubscribes
+	stop chan struct{}
+}
+
+// subscribe subscribes the subscriber to the events of Core matching the query, sharing the
+// subscription of the client with the other subscribers to the query. Only the first subscriber
+// to the query subscribes the client, as established by subscribeClient, while the others wait
+// for it, as well as for unsubscribing the client once the subscription is ending.
+// The channel is closed once the subscription of the client ends.
+func (f *BlockFetcher) subscribe(ctx context.Context, subscriber, query string) (<-chan ctypes.ResultEvent, error) {
+	if err := validateQuery(query); err != nil {
+		return nil, err
+	}
+
+	for {
+		f.subsLk.Lock()
+		qs, ok := f.subs[query]
+		if !ok {
+			return f.subscribeFirst(ctx, subscriber, query)
+		}
+
+		var wait chan struct{}
+		select {
+		case <-qs.ready:
+			select {
+			case <-qs.done:
+				wait = qs.unsubscribed
+			default:
+			}
+		default:
+			wait = qs.ready
+		}
+		if wait == nil {
+			if qs.subscribers[subscriber] != nil {
+				f.subsLk.Unlock()
+				return nil, fmt.Errorf("core/fetcher: %s already subscribed to %q", subscriber, query)
+			}
+			out := qs.add(subscriber)
+			f.subsLk.Unlock()
+			return out, nil
+		}
+		f.subsLk.Unlock()
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// subscribeFirst subscribes the client for the first subscriber to the query. It must be called
+// with the lock held and unlocks it.
+func (f *BlockFetcher) subscribeFirst(
+	ctx context.Context,
+	subscriber, query string,
+) (<-chan ctypes.ResultEvent, error) {
+	qs := &querySubscription{
+		subscriber:   subscriber,
+		subscribers:  make(map[string]*subscriberChan),
+		ready:        make(chan struct{}),
+		done:         make(chan struct{}),
+		unsubscribed: make(chan struct{}),
+	}
+	f.subs[query] = qs
+	f.subsLk.Unlock()
+
+	eventChan, err := f.subscribeClient(ctx, subscriber, query)
+
+	f.subsLk.Lock()
+	defer f.subsLk.Unlock()
+	defer close(qs.ready)
+	if err != nil {
+		// the others waiting subscribe on their own
+		delete(f.subs, query)
+		return nil, err
+	}
+	out := qs.add(subscriber)
+	go f.fanOut(query, qs, eventChan)
+	return out, nil
+}
+
+// add adds the channel of the subscriber to the querySubscription. It must be called with the
+// lock held.
+func (qs *querySubscription) add(subscriber string) <-chan ctypes.ResultEvent {
+	sub := &subscriberChan{out: make(chan ctypes.ResultEvent), stop: make(chan struct{})}
+	qs.subscribers[subscriber] = sub
+	return sub.out
+}
+
+// unsubscribe unsubscribes the subscriber from the events matching the query, unsubscribing the
+// client once no subscribers are left.
+func (f *BlockFetcher) unsubscribe(ctx context.Context, subscriber, query string) error {
+	f.subsLk.Lock()
+	qs, ok := f.subs[query]
+	if !ok {
+		// the subscription of the client has already ended
+		f.subsLk.Unlock()
+		return nil
+	}
+	sub, ok := qs.subscribers[subscriber]
+	if !ok {
+		f.subsLk.Unlock()
+		return nil
+	}
+	delete(qs.subscribers, subscriber)
+	close(sub.stop)
+	if len(qs.subscribers) > 0 {
+		f.subsLk.Unlock()
+		return nil
+	}
+	close(qs.done)
+	f.subsLk.Unlock()
+
+	err := f.client.Unsubscribe(ctx, qs.subscriber, query)
+
+	f.subsLk.Lock()
+	defer f.subsLk.Unlock()
+	if f.subs[query] == qs {
+		delete(f.subs, query)
+	}
+	close(qs.unsubscribed)
+	return err
+}
+
+// fanOut delivers the events of the subscription of the client to every subscriber of the
+// querySubscription, until the last subscriber unsubscribes or the subscription of the client
+// ends, closing the channels of the subscribers left.
+func (f *BlockFetcher) fanOut(query string, qs *querySubscription, eventChan <-chan ctypes.ResultEvent) {
+	for {
+		select {
+		case event, ok := <-eventChan:
+			if !ok {
+				f.endSubscription(query, qs)
+				return
+			}
+
+			f.subsLk.Lock()
+			subs := make([]*subscriberChan, 0, len(qs.subscribers))
+			for _, sub := range qs.subscribers {
+				subs = append(subs, sub)
+			}
+			f.subsLk.Unlock()
+			for _, sub := range subs {
+				select {
+				case sub.out <- event:
+				case <-sub.stop:
+				case <-qs.done:
+					return
+				}
+			}
+		case <-qs.done:
+			return
+		}
+	}
+}
+
+// endSubscription forgets the ended subscription of the client, closing the channels of its
+// subscribers.
+func (f *BlockFetcher) endSubscription(query string, qs *querySubscription) {
+	f.subsLk.Lock()
+	defer f.subsLk.Unlock()
+	select {
+	case <-qs.done:
+		// forgotten by unsubscribe once the client is unsubscribed
+		return
+	default:
+	}
+	if f.subs[query] == qs {
+		delete(f.subs, query)
+	}
+	for subscriber, sub := range qs.subscribers {
+		close(sub.out)
+		delete(qs.subscribers, subscriber)
+	}
+}
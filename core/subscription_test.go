@@ -0,0 +1,198 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	rpctypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+)
+
+func TestBlockFetcher_SubscriptionErrors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	// Core allows a single subscription
+	var subscribed bool
+	client := &mockClient{
+		subscribe: func(context.Context, string, string) (<-chan ctypes.ResultEvent, error) {
+			if subscribed {
+				return nil, &rpctypes.RPCError{
+					Code:    -32603,
+					Message: "Internal error",
+					Data:    "max_subscriptions_per_client 1 reached",
+				}
+			}
+			subscribed = true
+			return make(chan ctypes.ResultEvent), nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	_, err = fetcher.SubscribeMulti(ctx, []string{"tm.event = "})
+	var errQuery *ErrInvalidQuery
+	require.ErrorAs(t, err, &errQuery)
+	assert.Equal(t, "tm.event = ", errQuery.Query)
+	assert.False(t, subscribed, "invalid query must not reach Core")
+
+	_, err = fetcher.SubscribeNewBlockEvent(ctx)
+	require.NoError(t, err)
+	_, err = fetcher.SubscribeMulti(ctx, []string{"tm.event = 'Tx'"})
+	var errLimit *ErrTooManySubscriptions
+	require.ErrorAs(t, err, &errLimit)
+	assert.Equal(t, 1, errLimit.Limit)
+	assert.True(t, errLimit.PerClient)
+}
+
+func TestBlockFetcher_SubscriptionErrors_Remote(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	// Core rejects the subscription over its limit
+	srv := httptest.NewServer(newEventsWSHandler(func(method string, _ json.RawMessage) (any, error) {
+		if method == "subscribe" {
+			return nil, errors.New("max_subscriptions_per_client 1 reached")
+		}
+		return struct{}{}, nil
+	}, nil))
+	t.Cleanup(srv.Close)
+	client := newTestRemote(t, srv.URL)
+	require.NoError(t, client.Start())
+	t.Cleanup(func() {
+		require.NoError(t, client.Stop())
+	})
+
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+	_, err = fetcher.SubscribeNewBlockEvent(ctx)
+	var errLimit *ErrTooManySubscriptions
+	require.ErrorAs(t, err, &errLimit)
+	assert.Equal(t, 1, errLimit.Limit)
+	assert.True(t, errLimit.PerClient)
+}
+
+func TestBlockFetcher_Subscribe_Concurrent(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	t.Cleanup(cancel)
+
+	const slowQuery, fastQuery = "tm.event = 'Tx'", "tm.event = 'NewBlock'"
+	// subscribing and unsubscribing the slow query block until released
+	releaseSub, releaseUnsub := make(chan struct{}), make(chan struct{})
+	inFlight := make(chan struct{}, 2)
+	var slowSubs atomic.Int32
+	fastEvents := make(chan ctypes.ResultEvent)
+	client := &mockClient{
+		subscribe: func(_ context.Context, _, query string) (<-chan ctypes.ResultEvent, error) {
+			if query == fastQuery {
+				return fastEvents, nil
+			}
+			slowSubs.Add(1)
+			inFlight <- struct{}{}
+			<-releaseSub
+			return make(chan ctypes.ResultEvent), nil
+		},
+		unsubscribe: func(_ context.Context, _, query string) error {
+			if query == slowQuery {
+				inFlight <- struct{}{}
+				<-releaseUnsub
+			}
+			return nil
+		},
+	}
+	fetcher, err := NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	slowSubscribed := make(chan error, 2)
+	for _, subscriber := range []string{"first", "second"} {
+		subscriber := subscriber
+		go func() {
+			_, err := fetcher.subscribe(ctx, subscriber, slowQuery)
+			slowSubscribed <- err
+		}()
+	}
+
+	// the other queries are served while the slow one is being subscribed
+	<-inFlight
+	events, err := fetcher.subscribe(ctx, "fast", fastQuery)
+	require.NoError(t, err)
+	receive := func() {
+		go func() {
+			fastEvents <- ctypes.ResultEvent{Query: fastQuery}
+		}()
+		select {
+		case event := <-events:
+			assert.Equal(t, fastQuery, event.Query)
+		case <-ctx.Done():
+			t.Fatal("event did not arrive")
+		}
+	}
+	receive()
+
+	// the client is subscribed once for both of the subscribers
+	close(releaseSub)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, <-slowSubscribed)
+	}
+	assert.EqualValues(t, 1, slowSubs.Load())
+
+	// as well as while the slow one is being unsubscribed
+	require.NoError(t, fetcher.unsubscribe(ctx, "first", slowQuery))
+	unsubscribed := make(chan error, 1)
+	go func() {
+		unsubscribed <- fetcher.unsubscribe(ctx, "second", slowQuery)
+	}()
+	<-inFlight
+	receive()
+	close(releaseUnsub)
+	require.NoError(t, <-unsubscribed)
+}
+
+func TestSubscriptionError(t *testing.T) {
+	const query = "tm.event = 'NewBlock'"
+	tests := []struct {
+		err   string
+		check func(t *testing.T, err error)
+	}{
+		{
+			err: "failed to parse query: unexpected token",
+			check: func(t *testing.T, err error) {
+				var errQuery *ErrInvalidQuery
+				assert.ErrorAs(t, err, &errQuery)
+			},
+		},
+		{
+			err: "max_subscription_clients 100 reached",
+			check: func(t *testing.T, err error) {
+				var errLimit *ErrTooManySubscriptions
+				require.ErrorAs(t, err, &errLimit)
+				assert.Equal(t, 100, errLimit.Limit)
+				assert.False(t, errLimit.PerClient)
+			},
+		},
+		{
+			err: "client is not running. Use .Start() method to start",
+			check: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, ErrSubscriptionClosed)
+			},
+		},
+		{
+			err: "connection refused",
+			check: func(t *testing.T, err error) {
+				assert.EqualError(t, err, "connection refused")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err, func(t *testing.T) {
+			tt.check(t, subscriptionError(query, errors.New(tt.err)))
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tmquery "github.com/tendermint/tendermint/libs/pubsub/query"
+	"github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+// tendermintClient implements the Celestia specifics of Client on top of a Tendermint RPC client.
+type tendermintClient struct {
+	client.Client
+}
+
+// NewClientFromTendermint wraps the given Tendermint RPC client, e.g. the client of an in-process
+// Core node, into a Client. Its requests are not observed, so its ConnState is always
+// ConnStateIdle, and its Endpoint is unknown, i.e. empty.
+func NewClientFromTendermint(c client.Client) Client {
+	return &tendermintClient{Client: c}
+}
+
+func (c *tendermintClient) Raw() client.Client {
+	return c.Client
+}
+
+func (c *tendermintClient) Ping(ctx context.Context) error {
+	_, err := c.Health(ctx)
+	return err
+}
+
+func (c *tendermintClient) Versions(ctx context.Context) (*Versions, error) {
+	status, err := c.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Versions{
+		App:        status.NodeInfo.ProtocolVersion.App,
+		Block:      status.NodeInfo.ProtocolVersion.Block,
+		Tendermint: status.NodeInfo.Version,
+	}, nil
+}
+
+func (c *tendermintClient) AppInfo(ctx context.Context) (*AppInfo, error) {
+	info, err := c.ABCIInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &AppInfo{
+		Name:             info.Response.Data,
+		Version:          info.Response.Version,
+		AppVersion:       info.Response.AppVersion,
+		LastBlockHeight:  info.Response.LastBlockHeight,
+		LastBlockAppHash: info.Response.LastBlockAppHash,
+	}, nil
+}
+
+func (c *tendermintClient) Tx(ctx context.Context, hash []byte, prove bool) (*ctypes.ResultTx, error) {
+	res, err := c.Client.Tx(ctx, hash, prove)
+	if err != nil {
+		// Core only reports it as a part of the error message
+		if strings.Contains(err.Error(), fmt.Sprintf("tx (%X) not found", hash)) {
+			return nil, &ErrTxNotFound{Hash: hash}
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+func (c *tendermintClient) SearchTxs(
+	ctx context.Context,
+	query string,
+	page, perPage int,
+) (*ctypes.ResultTxSearch, error) {
+	if _, err := tmquery.New(query); err != nil {
+		return nil, fmt.Errorf("core: invalid tx search query %q: %w", query, err)
+	}
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > maxTxSearchPerPage {
+		perPage = maxTxSearchPerPage
+	}
+	return c.TxSearch(ctx, query, false, &page, &perPage, "asc")
+}
+
+func (c *tendermintClient) ConnState() ConnState {
+	return ConnStateIdle
+}
+
+func (c *tendermintClient) Endpoint() string {
+	return ""
+}
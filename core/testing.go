@@ -1,11 +1,14 @@
 package core
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"math/rand"
 	"net"
 	"net/url"
+	"os"
 	"sort"
 	"testing"
 	"time"
@@ -17,8 +20,10 @@ import (
 	tmrand "github.com/tendermint/tendermint/libs/rand"
 	tmservice "github.com/tendermint/tendermint/libs/service"
 	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	tmversion "github.com/tendermint/tendermint/proto/tendermint/version"
 	rpctest "github.com/tendermint/tendermint/rpc/test"
 	tmtypes "github.com/tendermint/tendermint/types"
+	"github.com/tendermint/tendermint/version"
 
 	"github.com/celestiaorg/celestia-app/testutil/testnode"
 )
@@ -27,21 +32,94 @@ import (
 const defaultRetainBlocks int64 = 10000
 
 // StartTestNode starts a mock Core node background process and returns it.
-func StartTestNode(ctx context.Context, t *testing.T, app types.Application, cfg *config.Config) tmservice.Service {
-	nd := rpctest.StartTendermint(app, rpctest.SuppressStdout, func(options *rpctest.Options) {
-		options.SpecificConfig = cfg
-	})
+// The node's output is suppressed, unless WithNodeLogs is given.
+func StartTestNode(
+	ctx context.Context,
+	t *testing.T,
+	app types.Application,
+	cfg *config.Config,
+	opts ...TestNodeOption,
+) tmservice.Service {
+	nodeOpts := &testNodeOptions{}
+	for _, opt := range opts {
+		opt(nodeOpts)
+	}
+
+	rpcOpts := []func(*rpctest.Options){
+		func(options *rpctest.Options) {
+			options.SpecificConfig = cfg
+		},
+	}
+	if nodeOpts.logs {
+		// the node logs to the stdout it is started with, so it is piped to the test log meanwhile
+		r, w, err := os.Pipe()
+		require.NoError(t, err)
+		stdout := os.Stdout
+		os.Stdout = w
+		defer func() {
+			os.Stdout = stdout
+		}()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			logLines(t.Log, r)
+		}()
+		// registered first, so that it runs once the node is stopped
+		t.Cleanup(func() {
+			w.Close()
+			<-done
+			r.Close()
+		})
+	} else {
+		rpcOpts = append(rpcOpts, rpctest.SuppressStdout)
+	}
+
+	nd := rpctest.StartTendermint(app, rpcOpts...)
 	t.Cleanup(func() {
 		rpctest.StopTendermint(nd)
 	})
 	return nd
 }
 
-// StartTestKVApp starts Tendermint KVApp.
-func StartTestKVApp(ctx context.Context, t *testing.T) (tmservice.Service, types.Application, *config.Config) {
+// logLines logs every line read from the reader until it ends.
+func logLines(log func(args ...any), r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		log(scanner.Text())
+	}
+	// the rest is discarded, so that the writer is never blocked
+	_, _ = io.Copy(io.Discard, r)
+}
+
+// TestNodeOption configures the mock Core node started by StartTestNode.
+type TestNodeOption func(*testNodeOptions)
+
+type testNodeOptions struct {
+	logs bool
+}
+
+// WithNodeLogs routes the error logs of the mock Core node to the test log, shown along with the
+// failures of the test or with `go test -v`. It is meant to be enabled temporarily, when
+// debugging a failing test.
+// NOTE: The process stdout is redirected while the node starts, so the output of the tests
+// running in parallel meanwhile ends up in the test log as well.
+func WithNodeLogs() TestNodeOption {
+	return func(o *testNodeOptions) {
+		o.logs = true
+	}
+}
+
+// StartTestKVApp starts Tendermint KVApp, configured by the given options.
+func StartTestKVApp(
+	ctx context.Context,
+	t *testing.T,
+	opts ...TestNodeOption,
+) (tmservice.Service, types.Application, *config.Config) {
 	cfg := rpctest.GetConfig(true)
 	app := CreateKVStore(defaultRetainBlocks)
-	return StartTestNode(ctx, t, app, cfg), app, cfg
+	return StartTestNode(ctx, t, app, cfg, opts...), app, cfg
 }
 
 // CreateKVStore creates a simple kv store app and gives the user
@@ -65,6 +143,13 @@ func getFreePort() (port int, err error) {
 }
 
 func StartTestCoreWithApp(t *testing.T) (tmservice.Service, Client) {
+	tmNode, client, _, _ := StartTestCoreWithAccounts(t)
+	return tmNode, client
+}
+
+// StartTestCoreWithAccounts is StartTestCoreWithApp also returning the app context along with the
+// names of the funded accounts in its keyring, so that the tests can sign and submit transactions.
+func StartTestCoreWithAccounts(t *testing.T) (tmservice.Service, Client, testnode.Context, []string) {
 	// we create an arbitrary number of funded accounts
 	accounts := make([]string, 10)
 	for i := range accounts {
@@ -85,7 +170,7 @@ func StartTestCoreWithApp(t *testing.T) (tmservice.Service, Client) {
 	tmNode.Config().RPC.ListenAddress = fmt.Sprintf("tcp://127.0.0.1:%d", freePort)
 	tmNode.Config().P2P.ListenAddress = "tcp://0.0.0.0:0"
 
-	_, cleanupCoreNode, err := testnode.StartNode(tmNode, cctx)
+	cctx, cleanupCoreNode, err := testnode.StartNode(tmNode, cctx)
 	require.NoError(t, err)
 	t.Cleanup(func() {
 		err := cleanupCoreNode()
@@ -108,7 +193,7 @@ func StartTestCoreWithApp(t *testing.T) (tmservice.Service, Client) {
 		require.NoError(t, err)
 	})
 
-	return tmNode, client
+	return tmNode, client, cctx, accounts
 }
 
 // GetEndpoint returns the remote node's RPC endpoint.
@@ -184,6 +269,178 @@ func MakeCommit(blockID tmtypes.BlockID, height int64, round int32,
 	return voteSet.MakeCommit(), nil
 }
 
+// MakePartialCommit makes a Commit for the given block ID signed only by the given signers, which
+// may be any subset of the validator set in any order. Each signature is placed at the index of
+// its validator in the set, rather than in the subset, and the signatures of the rest are absent.
+// Unlike MakeCommit, the signers do not need to hold more than 2/3 of the voting power, allowing
+// to craft commits for edge cases, e.g. one signed by a single validator in the middle of the set.
+func MakePartialCommit(
+	chainID string,
+	blockID tmtypes.BlockID,
+	height int64,
+	round int32,
+	valSet *tmtypes.ValidatorSet,
+	signers []tmtypes.PrivValidator,
+	now time.Time,
+) (*tmtypes.Commit, error) {
+	sigs := make([]tmtypes.CommitSig, valSet.Size())
+	for i := range sigs {
+		sigs[i] = tmtypes.NewCommitSigAbsent()
+	}
+
+	for _, signer := range signers {
+		pubKey, err := signer.GetPubKey()
+		if err != nil {
+			return nil, fmt.Errorf("can't get pubkey: %w", err)
+		}
+		idx, _ := valSet.GetByAddress(pubKey.Address())
+		if idx == -1 {
+			return nil, fmt.Errorf("signer %s is not in the validator set", pubKey.Address())
+		}
+
+		vote := &tmtypes.Vote{
+			ValidatorAddress: pubKey.Address(),
+			ValidatorIndex:   idx,
+			Height:           height,
+			Round:            round,
+			Type:             tmproto.PrecommitType,
+			BlockID:          blockID,
+			Timestamp:        now,
+		}
+		v := vote.ToProto()
+		if err := signer.SignVote(chainID, v); err != nil {
+			return nil, err
+		}
+		vote.Signature = v.Signature
+		sigs[idx] = vote.CommitSig()
+	}
+
+	return tmtypes.NewCommit(height, round, blockID, sigs), nil
+}
+
+// MakeSignedBlock signs the given block with all the given validators through the given vote set
+// and returns it bundled with the resulting Commit and ValidatorSet.
+//
+// The vote set must be created for the chain of the block. Otherwise, signatures would be produced
+// over the wrong chain ID and any later verification of the block would fail with a cryptic error,
+// so the mismatch is reported right away instead.
+func MakeSignedBlock(
+	block *tmtypes.Block,
+	voteSet *tmtypes.VoteSet,
+	valSet *tmtypes.ValidatorSet,
+	validators []tmtypes.PrivValidator,
+	now time.Time,
+) (*SignedBlock, error) {
+	if voteSet.ChainID() != block.ChainID {
+		return nil, fmt.Errorf("vote set chain ID %s does not match block chain ID %s",
+			voteSet.ChainID(), block.ChainID)
+	}
+
+	blockID := tmtypes.BlockID{
+		Hash:          block.Hash(),
+		PartSetHeader: block.MakePartSet(tmtypes.BlockPartSizeBytes).Header(),
+	}
+	commit, err := MakeCommit(blockID, block.Height, voteSet.GetRound(), voteSet, validators, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignedBlock{
+		Block:        block,
+		Commit:       commit,
+		ValidatorSet: valSet,
+	}, nil
+}
+
+// signedBlockChainID is the chain ID of the blocks made by MakeSignedBlockChain.
+const signedBlockChainID = "private"
+
+// MakeSignedBlockChain makes a chain of `n` adjacent blocks starting at the given height, signed by
+// all the given validators of the set. Every block links to the preceding one by its LastBlockID
+// and carries its Commit as LastCommit, and the set stays the same across the chain.
+// NOTE: The first block has an empty LastCommit, as it has no preceding block in the chain.
+func MakeSignedBlockChain(
+	startHeight int64,
+	n int,
+	valSet *tmtypes.ValidatorSet,
+	validators []tmtypes.PrivValidator,
+) ([]*SignedBlock, error) {
+	var (
+		blocks      = make([]*SignedBlock, 0, n)
+		lastBlockID tmtypes.BlockID
+		lastCommit  = &tmtypes.Commit{}
+		start       = time.Now()
+	)
+	for i := 0; i < n; i++ {
+		height := startHeight + int64(i)
+		blockTime := start.Add(time.Duration(i) * time.Second)
+
+		block := tmtypes.MakeBlock(height, tmtypes.Data{}, lastCommit)
+		block.Header.Populate(
+			tmversion.Consensus{Block: version.BlockProtocol},
+			signedBlockChainID,
+			blockTime,
+			lastBlockID,
+			valSet.Hash(),
+			valSet.Hash(),
+			nil,
+			nil,
+			nil,
+			valSet.GetProposer().Address,
+		)
+		block.DataHash = emptyDataHash()
+
+		voteSet := tmtypes.NewVoteSet(signedBlockChainID, height, 0, tmproto.PrecommitType, valSet)
+		sb, err := MakeSignedBlock(block, voteSet, valSet, validators, blockTime)
+		if err != nil {
+			return nil, fmt.Errorf("signing block at height %d: %w", height, err)
+		}
+
+		blocks = append(blocks, sb)
+		lastBlockID, lastCommit = sb.Commit.BlockID, sb.Commit
+	}
+	return blocks, nil
+}
+
+// BlockDefect enumerates the defects CorruptBlock can introduce into a block.
+type BlockDefect int
+
+const (
+	// DefectDataHash makes the block's DataHash not match its data.
+	DefectDataHash BlockDefect = iota
+	// DefectLastCommitHash makes the block's LastCommitHash not match its LastCommit.
+	DefectLastCommitHash
+	// DefectValidatorsHash makes the block's ValidatorsHash not match the validator set
+	// that produced it.
+	DefectValidatorsHash
+)
+
+// CorruptBlock returns a copy of the given block with the given defect introduced.
+// It is meant for negative tests ensuring bad blocks are rejected.
+func CorruptBlock(block *tmtypes.Block, defect BlockDefect) (*tmtypes.Block, error) {
+	// copy through protobuf, so that the original block stays intact
+	pb, err := block.ToProto()
+	if err != nil {
+		return nil, err
+	}
+	corrupted, err := tmtypes.BlockFromProto(pb)
+	if err != nil {
+		return nil, err
+	}
+
+	switch defect {
+	case DefectDataHash:
+		corrupted.DataHash = tmrand.Bytes(32)
+	case DefectLastCommitHash:
+		corrupted.LastCommitHash = tmrand.Bytes(32)
+	case DefectValidatorsHash:
+		corrupted.ValidatorsHash = tmrand.Bytes(32)
+	default:
+		return nil, fmt.Errorf("unknown block defect: %d", defect)
+	}
+	return corrupted, nil
+}
+
 func signAddVote(privVal tmtypes.PrivValidator, vote *tmtypes.Vote, voteSet *tmtypes.VoteSet) (signed bool, err error) {
 	v := vote.ToProto()
 	err = privVal.SignVote(voteSet.ChainID(), v)
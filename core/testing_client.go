@@ -0,0 +1,281 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	"github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+// CountingClient is a Client test double wrapping another Client and counting the calls of its
+// RPC methods, e.g. to test caching or deduplication of requests.
+// NOTE: Calls made through Raw and the Start/Stop methods are not counted.
+type CountingClient struct {
+	Client
+
+	lk    sync.Mutex
+	calls map[string]int
+}
+
+// NewCountingClient wraps the given Client into a CountingClient.
+func NewCountingClient(base Client) *CountingClient {
+	return &CountingClient{
+		Client: base,
+		calls:  make(map[string]int),
+	}
+}
+
+// CallCount returns how many times the method with the given name, e.g. "Block", was called.
+func (c *CountingClient) CallCount(method string) int {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	return c.calls[method]
+}
+
+// ResetCounts zeroes the counts, e.g. to start a new test window.
+func (c *CountingClient) ResetCounts() {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	c.calls = make(map[string]int)
+}
+
+// AssertNoCalls fails the test if any of the methods with the given names was called.
+func (c *CountingClient) AssertNoCalls(t testing.TB, methods ...string) {
+	t.Helper()
+	for _, method := range methods {
+		if count := c.CallCount(method); count != 0 {
+			t.Errorf("core: unexpected %d calls of %s", count, method)
+		}
+	}
+}
+
+func (c *CountingClient) count(method string) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	c.calls[method]++
+}
+
+func (c *CountingClient) ABCIInfo(ctx context.Context) (*ctypes.ResultABCIInfo, error) {
+	c.count("ABCIInfo")
+	return c.Client.ABCIInfo(ctx)
+}
+
+func (c *CountingClient) ABCIQuery(
+	ctx context.Context,
+	path string,
+	data tmbytes.HexBytes,
+) (*ctypes.ResultABCIQuery, error) {
+	c.count("ABCIQuery")
+	return c.Client.ABCIQuery(ctx, path, data)
+}
+
+func (c *CountingClient) ABCIQueryWithOptions(
+	ctx context.Context,
+	path string,
+	data tmbytes.HexBytes,
+	opts client.ABCIQueryOptions,
+) (*ctypes.ResultABCIQuery, error) {
+	c.count("ABCIQueryWithOptions")
+	return c.Client.ABCIQueryWithOptions(ctx, path, data, opts)
+}
+
+func (c *CountingClient) BroadcastTxCommit(
+	ctx context.Context,
+	tx tmtypes.Tx,
+) (*ctypes.ResultBroadcastTxCommit, error) {
+	c.count("BroadcastTxCommit")
+	return c.Client.BroadcastTxCommit(ctx, tx)
+}
+
+func (c *CountingClient) BroadcastTxAsync(ctx context.Context, tx tmtypes.Tx) (*ctypes.ResultBroadcastTx, error) {
+	c.count("BroadcastTxAsync")
+	return c.Client.BroadcastTxAsync(ctx, tx)
+}
+
+func (c *CountingClient) BroadcastTxSync(ctx context.Context, tx tmtypes.Tx) (*ctypes.ResultBroadcastTx, error) {
+	c.count("BroadcastTxSync")
+	return c.Client.BroadcastTxSync(ctx, tx)
+}
+
+func (c *CountingClient) Subscribe(
+	ctx context.Context,
+	subscriber, query string,
+	outCapacity ...int,
+) (<-chan ctypes.ResultEvent, error) {
+	c.count("Subscribe")
+	return c.Client.Subscribe(ctx, subscriber, query, outCapacity...)
+}
+
+func (c *CountingClient) Unsubscribe(ctx context.Context, subscriber, query string) error {
+	c.count("Unsubscribe")
+	return c.Client.Unsubscribe(ctx, subscriber, query)
+}
+
+func (c *CountingClient) UnsubscribeAll(ctx context.Context, subscriber string) error {
+	c.count("UnsubscribeAll")
+	return c.Client.UnsubscribeAll(ctx, subscriber)
+}
+
+func (c *CountingClient) Genesis(ctx context.Context) (*ctypes.ResultGenesis, error) {
+	c.count("Genesis")
+	return c.Client.Genesis(ctx)
+}
+
+func (c *CountingClient) GenesisChunked(ctx context.Context, id uint) (*ctypes.ResultGenesisChunk, error) {
+	c.count("GenesisChunked")
+	return c.Client.GenesisChunked(ctx, id)
+}
+
+func (c *CountingClient) BlockchainInfo(
+	ctx context.Context,
+	minHeight,
+	maxHeight int64,
+) (*ctypes.ResultBlockchainInfo, error) {
+	c.count("BlockchainInfo")
+	return c.Client.BlockchainInfo(ctx, minHeight, maxHeight)
+}
+
+func (c *CountingClient) NetInfo(ctx context.Context) (*ctypes.ResultNetInfo, error) {
+	c.count("NetInfo")
+	return c.Client.NetInfo(ctx)
+}
+
+func (c *CountingClient) DumpConsensusState(ctx context.Context) (*ctypes.ResultDumpConsensusState, error) {
+	c.count("DumpConsensusState")
+	return c.Client.DumpConsensusState(ctx)
+}
+
+func (c *CountingClient) ConsensusState(ctx context.Context) (*ctypes.ResultConsensusState, error) {
+	c.count("ConsensusState")
+	return c.Client.ConsensusState(ctx)
+}
+
+func (c *CountingClient) ConsensusParams(ctx context.Context, height *int64) (*ctypes.ResultConsensusParams, error) {
+	c.count("ConsensusParams")
+	return c.Client.ConsensusParams(ctx, height)
+}
+
+func (c *CountingClient) Health(ctx context.Context) (*ctypes.ResultHealth, error) {
+	c.count("Health")
+	return c.Client.Health(ctx)
+}
+
+func (c *CountingClient) Block(ctx context.Context, height *int64) (*ctypes.ResultBlock, error) {
+	c.count("Block")
+	return c.Client.Block(ctx, height)
+}
+
+func (c *CountingClient) BlockByHash(ctx context.Context, hash []byte) (*ctypes.ResultBlock, error) {
+	c.count("BlockByHash")
+	return c.Client.BlockByHash(ctx, hash)
+}
+
+func (c *CountingClient) BlockResults(ctx context.Context, height *int64) (*ctypes.ResultBlockResults, error) {
+	c.count("BlockResults")
+	return c.Client.BlockResults(ctx, height)
+}
+
+func (c *CountingClient) Commit(ctx context.Context, height *int64) (*ctypes.ResultCommit, error) {
+	c.count("Commit")
+	return c.Client.Commit(ctx, height)
+}
+
+func (c *CountingClient) DataCommitment(
+	ctx context.Context,
+	beginBlock,
+	endBlock uint64,
+) (*ctypes.ResultDataCommitment, error) {
+	c.count("DataCommitment")
+	return c.Client.DataCommitment(ctx, beginBlock, endBlock)
+}
+
+func (c *CountingClient) Validators(
+	ctx context.Context,
+	height *int64,
+	page, perPage *int,
+) (*ctypes.ResultValidators, error) {
+	c.count("Validators")
+	return c.Client.Validators(ctx, height, page, perPage)
+}
+
+func (c *CountingClient) Tx(ctx context.Context, hash []byte, prove bool) (*ctypes.ResultTx, error) {
+	c.count("Tx")
+	return c.Client.Tx(ctx, hash, prove)
+}
+
+func (c *CountingClient) TxSearch(
+	ctx context.Context,
+	query string,
+	prove bool,
+	page, perPage *int,
+	orderBy string,
+) (*ctypes.ResultTxSearch, error) {
+	c.count("TxSearch")
+	return c.Client.TxSearch(ctx, query, prove, page, perPage, orderBy)
+}
+
+func (c *CountingClient) BlockSearch(
+	ctx context.Context,
+	query string,
+	page, perPage *int,
+	orderBy string,
+) (*ctypes.ResultBlockSearch, error) {
+	c.count("BlockSearch")
+	return c.Client.BlockSearch(ctx, query, page, perPage, orderBy)
+}
+
+func (c *CountingClient) Status(ctx context.Context) (*ctypes.ResultStatus, error) {
+	c.count("Status")
+	return c.Client.Status(ctx)
+}
+
+func (c *CountingClient) BroadcastEvidence(
+	ctx context.Context,
+	ev tmtypes.Evidence,
+) (*ctypes.ResultBroadcastEvidence, error) {
+	c.count("BroadcastEvidence")
+	return c.Client.BroadcastEvidence(ctx, ev)
+}
+
+func (c *CountingClient) UnconfirmedTxs(ctx context.Context, limit *int) (*ctypes.ResultUnconfirmedTxs, error) {
+	c.count("UnconfirmedTxs")
+	return c.Client.UnconfirmedTxs(ctx, limit)
+}
+
+func (c *CountingClient) NumUnconfirmedTxs(ctx context.Context) (*ctypes.ResultUnconfirmedTxs, error) {
+	c.count("NumUnconfirmedTxs")
+	return c.Client.NumUnconfirmedTxs(ctx)
+}
+
+func (c *CountingClient) CheckTx(ctx context.Context, tx tmtypes.Tx) (*ctypes.ResultCheckTx, error) {
+	c.count("CheckTx")
+	return c.Client.CheckTx(ctx, tx)
+}
+
+func (c *CountingClient) Ping(ctx context.Context) error {
+	c.count("Ping")
+	return c.Client.Ping(ctx)
+}
+
+func (c *CountingClient) Versions(ctx context.Context) (*Versions, error) {
+	c.count("Versions")
+	return c.Client.Versions(ctx)
+}
+
+func (c *CountingClient) AppInfo(ctx context.Context) (*AppInfo, error) {
+	c.count("AppInfo")
+	return c.Client.AppInfo(ctx)
+}
+
+func (c *CountingClient) SearchTxs(
+	ctx context.Context,
+	query string,
+	page, perPage int,
+) (*ctypes.ResultTxSearch, error) {
+	c.count("SearchTxs")
+	return c.Client.SearchTxs(ctx, query, page, perPage)
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+func TestCountingClient(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+
+	client := NewCountingClient(&mockClient{
+		block: func(_ context.Context, height *int64) (*ctypes.ResultBlock, error) {
+			return &ctypes.ResultBlock{Block: newHeightBlock(*height)}, nil
+		},
+		status: func(context.Context) (*ctypes.ResultStatus, error) {
+			return &ctypes.ResultStatus{}, nil
+		},
+	})
+	fetcher, err := NewBlockFetcher(client, WithCacheSize[FetcherParameters](128))
+	require.NoError(t, err)
+
+	height := int64(1)
+	for i := 0; i < 3; i++ {
+		_, err = fetcher.GetBlock(ctx, &height)
+		require.NoError(t, err)
+	}
+	_, err = client.Status(ctx)
+	require.NoError(t, err)
+
+	// the block is cached after the first call
+	assert.Equal(t, 1, client.CallCount("Block"))
+	assert.Equal(t, 1, client.CallCount("Status"))
+	client.AssertNoCalls(t, "Commit", "Validators")
+
+	recorder := &errorRecorder{TB: t}
+	client.AssertNoCalls(recorder, "Block", "Commit")
+	assert.Equal(t, 1, recorder.errors)
+
+	client.ResetCounts()
+	client.AssertNoCalls(t, "Block", "Status")
+}
+
+// errorRecorder is a testing.TB counting the reported errors instead of failing the test.
+type errorRecorder struct {
+	testing.TB
+	errors int
+}
+
+func (r *errorRecorder) Errorf(string, ...any) {
+	r.errors++
+}
@@ -0,0 +1,167 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// FakeClock is a Clock test double whose time only moves when advanced, so that tests can drive
+// the cache expiry, the Backoff waits and the timers deterministically, without real sleeps.
+type FakeClock struct {
+	lk      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+type fakeWaiter struct {
+	deadline time.Time
+	// the period of a ticker, zero for the one-off waiters
+	period time.Duration
+	ch     chan time.Time
+}
+
+// NewFakeClock creates a new FakeClock set to the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.lk)
+	return c
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	w := &fakeWaiter{ch: make(chan time.Time, 1)}
+	c.schedule(w, d)
+	return w.ch
+}
+
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	w := &fakeWaiter{ch: make(chan time.Time, 1)}
+	c.schedule(w, d)
+	return &fakeTimer{clock: c, waiter: w}
+}
+
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("core: non-positive interval for NewTicker")
+	}
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	w := &fakeWaiter{period: d, ch: make(chan time.Time, 1)}
+	c.schedule(w, d)
+	return &fakeTicker{clock: c, waiter: w}
+}
+
+// schedule makes the waiter fire once the given duration elapses, right away if it is not
+// positive. It must be called with the lock held.
+func (c *FakeClock) schedule(w *fakeWaiter, d time.Duration) {
+	w.deadline = c.now.Add(d)
+	if d <= 0 {
+		c.fire(w)
+		return
+	}
+	c.waiters = append(c.waiters, w)
+	c.cond.Broadcast()
+}
+
+// fire sends the current time to the waiter, dropping it if the waiter still holds the previous
+// one, as time.Ticker does.
+// It must be called with the lock held.
+func (c *FakeClock) fire(w *fakeWaiter) {
+	select {
+	case w.ch <- c.now:
+	default:
+	}
+}
+
+// unschedule removes the waiter, returning whether it was waiting to fire.
+// It must be called with the lock held.
+func (c *FakeClock) unschedule(w *fakeWaiter) bool {
+	for i, waiting := range c.waiters {
+		if waiting == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Advance moves the time forward by the given duration, firing the timers it elapses.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	c.now = c.now.Add(d)
+
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline.After(c.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		c.fire(w)
+		if w.period > 0 {
+			for !w.deadline.After(c.now) {
+				w.deadline = w.deadline.Add(w.period)
+			}
+			waiters = append(waiters, w)
+		}
+	}
+	c.waiters = waiters
+}
+
+// BlockUntil blocks until at least the given number of timers are waiting to fire, e.g. to make
+// sure the code under test started waiting before advancing the time.
+func (c *FakeClock) BlockUntil(n int) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
+
+type fakeTimer struct {
+	clock  *FakeClock
+	waiter *fakeWaiter
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.waiter.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.lk.Lock()
+	defer t.clock.lk.Unlock()
+	return t.clock.unschedule(t.waiter)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.lk.Lock()
+	defer t.clock.lk.Unlock()
+	active := t.clock.unschedule(t.waiter)
+	t.clock.schedule(t.waiter, d)
+	return active
+}
+
+type fakeTicker struct {
+	clock  *FakeClock
+	waiter *fakeWaiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.waiter.ch
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.lk.Lock()
+	defer t.clock.lk.Unlock()
+	t.clock.unschedule(t.waiter)
+}
@@ -0,0 +1,182 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	tmversion "github.com/tendermint/tendermint/proto/tendermint/version"
+	tmtypes "github.com/tendermint/tendermint/types"
+	"github.com/tendermint/tendermint/version"
+)
+
+func TestStartTestNode_Logs(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	t.Cleanup(cancel)
+
+	stdout := os.Stdout
+	_, _, cfg := StartTestKVApp(ctx, t, WithNodeLogs())
+	assert.Equal(t, stdout, os.Stdout)
+	endpoint, err := GetEndpoint(cfg)
+	require.NoError(t, err)
+	assert.NotEmpty(t, endpoint)
+
+	// the lines are logged one by one, and an overlong one does not block the writer
+	var lines []string
+	log := func(args ...any) {
+		lines = append(lines, fmt.Sprint(args...))
+	}
+	logLines(log, strings.NewReader("first\nsecond\n"+strings.Repeat("x", 2<<20)+"\nlast\n"))
+	assert.Equal(t, []string{"first", "second"}, lines)
+}
+
+func TestMakeSignedBlock(t *testing.T) {
+	const chainID = "private"
+	valSet, vals := RandValidatorSet(3, 1)
+	block := newTestBlock(chainID, 1, valSet)
+
+	voteSet := tmtypes.NewVoteSet(chainID, block.Height, 0, tmproto.PrecommitType, valSet)
+	sb, err := MakeSignedBlock(block, voteSet, valSet, vals, time.Now())
+	require.NoError(t, err)
+
+	err = sb.ValidatorSet.VerifyCommit(chainID, sb.Commit.BlockID, sb.Height, sb.Commit)
+	require.NoError(t, err)
+}
+
+func TestMakeSignedBlock_ChainIDMismatch(t *testing.T) {
+	valSet, vals := RandValidatorSet(3, 1)
+	block := newTestBlock("private", 1, valSet)
+
+	voteSet := tmtypes.NewVoteSet("public", block.Height, 0, tmproto.PrecommitType, valSet)
+	_, err := MakeSignedBlock(block, voteSet, valSet, vals, time.Now())
+	require.ErrorContains(t, err, "does not match block chain ID")
+}
+
+// newTestBlock creates an empty block for the given chain and height produced by the given
+// validator set.
+func newTestBlock(chainID string, height int64, valSet *tmtypes.ValidatorSet) *tmtypes.Block {
+	block := tmtypes.MakeBlock(height, tmtypes.Data{}, &tmtypes.Commit{})
+	block.Header.Populate(
+		tmversion.Consensus{Block: version.BlockProtocol},
+		chainID,
+		time.Now(),
+		tmtypes.BlockID{},
+		valSet.Hash(),
+		valSet.Hash(),
+		nil,
+		nil,
+		nil,
+		valSet.GetProposer().Address,
+	)
+	block.DataHash = emptyDataHash()
+	return block
+}
+
+func TestCorruptBlock(t *testing.T) {
+	const chainID = "private"
+	valSet, vals := RandValidatorSet(3, 1)
+	block := newTestBlock(chainID, 1, valSet)
+	voteSet := tmtypes.NewVoteSet(chainID, block.Height, 0, tmproto.PrecommitType, valSet)
+	sb, err := MakeSignedBlock(block, voteSet, valSet, vals, time.Now())
+	require.NoError(t, err)
+
+	tests := []struct {
+		defect BlockDefect
+		check  func(*tmtypes.Block) error
+	}{
+		{
+			defect: DefectDataHash,
+			check: func(b *tmtypes.Block) error {
+				// the data is no longer what the validators committed to
+				blockID := tmtypes.BlockID{Hash: b.Hash(), PartSetHeader: sb.Commit.BlockID.PartSetHeader}
+				return valSet.VerifyCommit(chainID, blockID, b.Height, sb.Commit)
+			},
+		},
+		{
+			defect: DefectLastCommitHash,
+			check: func(b *tmtypes.Block) error {
+				return b.ValidateBasic()
+			},
+		},
+		{
+			defect: DefectValidatorsHash,
+			check: func(b *tmtypes.Block) error {
+				if !bytes.Equal(b.ValidatorsHash, valSet.Hash()) {
+					return errors.New("validators hash mismatch")
+				}
+				return nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		require.NoError(t, tt.check(sb.Block))
+
+		corrupted, err := CorruptBlock(sb.Block, tt.defect)
+		require.NoError(t, err)
+		assert.Error(t, tt.check(corrupted))
+	}
+}
+
+func TestMakePartialCommit(t *testing.T) {
+	const chainID = "private"
+	valSet, vals := RandValidatorSet(4, 1)
+	block := newTestBlock(chainID, 1, valSet)
+	blockID := tmtypes.BlockID{
+		Hash:          block.Hash(),
+		PartSetHeader: block.MakePartSet(tmtypes.BlockPartSizeBytes).Header(),
+	}
+
+	// the signers come in the reverse order of the set
+	signers := []tmtypes.PrivValidator{vals[2], vals[1]}
+	commit, err := MakePartialCommit(chainID, blockID, block.Height, 0, valSet, signers, time.Now())
+	require.NoError(t, err)
+	require.Len(t, commit.Signatures, valSet.Size())
+
+	for idx, sig := range commit.Signatures {
+		if idx != 1 && idx != 2 {
+			assert.True(t, sig.Absent())
+			continue
+		}
+		require.True(t, sig.ForBlock())
+		assert.Equal(t, valSet.Validators[idx].Address, sig.ValidatorAddress)
+		// the signature verifies against the validator at its index
+		signBytes := commit.VoteSignBytes(chainID, int32(idx))
+		assert.True(t, valSet.Validators[idx].PubKey.VerifySignature(signBytes, sig.Signature))
+	}
+
+	_, err = MakePartialCommit(chainID, blockID, block.Height, 0, valSet,
+		[]tmtypes.PrivValidator{tmtypes.NewMockPV()}, time.Now())
+	require.ErrorContains(t, err, "not in the validator set")
+}
+
+func TestMakeSignedBlockChain(t *testing.T) {
+	valSet, vals := RandValidatorSet(3, 1)
+	blocks, err := MakeSignedBlockChain(5, 10, valSet, vals)
+	require.NoError(t, err)
+	require.Len(t, blocks, 10)
+
+	for i, sb := range blocks {
+		require.NoError(t, sb.ValidateBasic())
+		require.NoError(t, sb.Verify())
+		assert.EqualValues(t, 5+i, sb.Height)
+		if i == 0 {
+			continue
+		}
+
+		prev := blocks[i-1]
+		assert.Equal(t, prev.Commit.BlockID, sb.LastBlockID)
+		assert.Equal(t, prev.Hash(), sb.LastBlockID.Hash)
+		assert.Equal(t, prev.Commit.Hash(), sb.LastCommitHash)
+		assert.Equal(t, prev.NextValidatorsHash, sb.ValidatorsHash)
+		assert.True(t, sb.Time.After(prev.Time))
+	}
+}
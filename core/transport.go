@@ -0,0 +1,208 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// rpcRequest is the part of a JSON-RPC request inspected by the transports.
+type rpcRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// readRPCRequest decodes the JSON-RPC request carried by the given HTTP request, leaving the body
+// intact for further reads. It returns false for batched requests or bodies it cannot decode.
+func readRPCRequest(req *http.Request) (rpcRequest, bool) {
+	var rpcReq rpcRequest
+	if req.Body == nil || req.Body == http.NoBody {
+		return rpcReq, false
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return rpcReq, false
+	}
+	return rpcReq, json.Unmarshal(body, &rpcReq) == nil
+}
+
+// deadlineTransport bounds every request with the timeout configured for its JSON-RPC method,
+// falling back to the default timeout for the methods without one.
+type deadlineTransport struct {
+	base http.RoundTripper
+
+	timeout        time.Duration
+	methodTimeouts map[string]time.Duration
+}
+
+func (t *deadlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	timeout := t.timeout
+	if rpcReq, ok := readRPCRequest(req); ok {
+		if methodTimeout, ok := t.methodTimeouts[rpcReq.Method]; ok {
+			timeout = methodTimeout
+		}
+	}
+	if timeout == 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// the body is read after the round trip, so keep the deadline until it's closed
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// stopTransport cancels the requests in flight once the client is stopped, failing them with
+// ErrClientStopped, and fails the requests made after.
+type stopTransport struct {
+	base    http.RoundTripper
+	stopped <-chan struct{}
+}
+
+func (t *stopTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	select {
+	case <-t.stopped:
+		return nil, ErrClientStopped
+	default:
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	go func() {
+		select {
+		case <-t.stopped:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		select {
+		case <-t.stopped:
+			return nil, fmt.Errorf("%w: %s", ErrClientStopped, err)
+		default:
+			return nil, err
+		}
+	}
+	// the body is read after the round trip, so keep the request cancellable until it's closed
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose cancels the context of a request once its response body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+// userAgentTransport identifies the client to Core with the User-Agent header.
+type userAgentTransport struct {
+	base      http.RoundTripper
+	userAgent string
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+	return t.base.RoundTrip(req)
+}
+
+// defaultUserAgent returns the User-Agent identifying celestia-node of the running version.
+func defaultUserAgent() string {
+	const modulePath = "github.com/celestiaorg/celestia-node"
+
+	version := "unknown"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Main.Path == modulePath {
+			version = info.Main.Version
+		}
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				version = dep.Version
+			}
+		}
+	}
+	return "celestia-node/" + version
+}
+
+// tcpConn is the part of *net.TCPConn configuring the connection.
+type tcpConn interface {
+	SetNoDelay(noDelay bool) error
+	SetKeepAlive(keepAlive bool) error
+	SetKeepAlivePeriod(period time.Duration) error
+}
+
+// dialFunc returns the func dialing the connections to Core as configured by the given params,
+// or nil for the default one.
+func dialFunc(params *ClientParameters) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if params.ProxyDialer == nil {
+		return params.DialContext
+	}
+	if dialer, ok := params.ProxyDialer.(proxy.ContextDialer); ok {
+		return dialer.DialContext
+	}
+	// the dial is then bounded by the timeouts of the dialer rather than the context
+	return func(_ context.Context, network, addr string) (net.Conn, error) {
+		return params.ProxyDialer.Dial(network, addr)
+	}
+}
+
+// dialWithTCPOptions wraps the given dial func to configure the dialed TCP connections with the
+// TCPNoDelay and TCPKeepAlive params, leaving the dial func as is for the Go defaults.
+func dialWithTCPOptions(
+	params *ClientParameters,
+	dial func(ctx context.Context, network, addr string) (net.Conn, error),
+) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if params.TCPNoDelay && params.TCPKeepAlive == 0 {
+		return dial
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		tc, ok := conn.(tcpConn)
+		if !ok {
+			return conn, nil
+		}
+
+		err = tc.SetNoDelay(params.TCPNoDelay)
+		switch {
+		case err != nil:
+		case params.TCPKeepAlive < 0:
+			err = tc.SetKeepAlive(false)
+		case params.TCPKeepAlive > 0:
+			if err = tc.SetKeepAlive(true); err == nil {
+				err = tc.SetKeepAlivePeriod(params.TCPKeepAlive)
+			}
+		}
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("core: configuring TCP connection: %w", err)
+		}
+		return conn, nil
+	}
+}
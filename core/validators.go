@@ -0,0 +1,50 @@
+package core
+
+import (
+	"github.com/tendermint/tendermint/types"
+)
+
+// PowerChange is a validator present in both of the diffed validator sets with a different
+// voting power.
+type PowerChange struct {
+	// Validator is the validator as it is in the new set.
+	Validator *types.Validator
+	// OldPower is the voting power of the validator in the old set.
+	OldPower int64
+}
+
+// DiffValidatorSets compares the old validator set with the new one, returning the validators
+// added to it, removed from it, and those whose voting power changed, in the order of the set
+// they are taken from. A nil set is treated as empty.
+func DiffValidatorSets(
+	oldSet, newSet *types.ValidatorSet,
+) (added, removed []*types.Validator, changed []*PowerChange) {
+	oldVals := make(map[string]*types.Validator)
+	if oldSet != nil {
+		for _, val := range oldSet.Validators {
+			oldVals[string(val.Address)] = val
+		}
+	}
+	newVals := make(map[string]*types.Validator)
+	if newSet != nil {
+		for _, val := range newSet.Validators {
+			newVals[string(val.Address)] = val
+
+			oldVal, ok := oldVals[string(val.Address)]
+			switch {
+			case !ok:
+				added = append(added, val)
+			case oldVal.VotingPower != val.VotingPower:
+				changed = append(changed, &PowerChange{Validator: val, OldPower: oldVal.VotingPower})
+			}
+		}
+	}
+	if oldSet != nil {
+		for _, val := range oldSet.Validators {
+			if _, ok := newVals[string(val.Address)]; !ok {
+				removed = append(removed, val)
+			}
+		}
+	}
+	return added, removed, changed
+}
@@ -0,0 +1,43 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestDiffValidatorSets(t *testing.T) {
+	oldSet, _ := RandValidatorSet(4, 10)
+	joining, _ := RandValidatorSet(1, 10)
+
+	// drops the first validator, bumps the power of the second and adds a new one
+	leaving := oldSet.Validators[0]
+	bumped := oldSet.Validators[1].Copy()
+	bumped.VotingPower += 5
+	vals := []*types.Validator{bumped, joining.Validators[0]}
+	for _, val := range oldSet.Validators[2:] {
+		vals = append(vals, val.Copy())
+	}
+	newSet := types.NewValidatorSet(vals)
+
+	added, removed, changed := DiffValidatorSets(oldSet, newSet)
+	require.Len(t, added, 1)
+	assert.Equal(t, joining.Validators[0].Address, added[0].Address)
+	require.Len(t, removed, 1)
+	assert.Equal(t, leaving.Address, removed[0].Address)
+	require.Len(t, changed, 1)
+	assert.Equal(t, bumped.Address, changed[0].Validator.Address)
+	assert.EqualValues(t, 15, changed[0].Validator.VotingPower)
+	assert.EqualValues(t, 10, changed[0].OldPower)
+
+	added, removed, changed = DiffValidatorSets(oldSet, oldSet)
+	assert.Empty(t, added)
+	assert.Empty(t, removed)
+	assert.Empty(t, changed)
+
+	added, removed, _ = DiffValidatorSets(nil, oldSet)
+	assert.Len(t, added, len(oldSet.Validators))
+	assert.Empty(t, removed)
+}
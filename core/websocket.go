@@ -0,0 +1,365 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsRedialInterval is the minimum interval between the attempts to dial the websocket, so that
+// the reads fall back to HTTP right away while the websocket is down.
+const wsRedialInterval = 5 * time.Second
+
+// wsDialTimeout bounds dialing the websocket for the reads, which is independent of the read
+// triggering it.
+const wsDialTimeout = 5 * time.Second
+
+// errWSDown is returned for calls which could not be served over the websocket.
+var errWSDown = errors.New("core: websocket unavailable")
+
+// ErrWSReadLimit is returned when a message from Core over the websocket exceeds the WSReadLimit,
+// breaking the connection. Retrying only helps for smaller messages, otherwise the limit has to be
+// raised.
+type ErrWSReadLimit struct {
+	// Limit is the limit exceeded, in bytes.
+	Limit int64
+}
+
+func (e *ErrWSReadLimit) Error() string {
+	return fmt.Sprintf("core: websocket message over the read limit of %d bytes", e.Limit)
+}
+
+func (e *ErrWSReadLimit) Unwrap() error {
+	return websocket.ErrReadLimit
+}
+
+// wsWriteMethods are the JSON-RPC methods which are never routed over the websocket, as they are
+// not safe to repeat over HTTP when the websocket breaks mid-call.
+var wsWriteMethods = map[string]bool{
+	"broadcast_tx_async":  true,
+	"broadcast_tx_sync":   true,
+	"broadcast_tx_commit": true,
+	"broadcast_evidence":  true,
+}
+
+// wsTransport routes the JSON-RPC read requests over a persistent websocket connection to Core,
+// falling back to the base transport while the websocket is unavailable. The responses are
+// served as if they came over HTTP, so that the callers can't tell the two paths apart.
+type wsTransport struct {
+	base http.RoundTripper
+
+	url       string
+	header    http.Header
+	dialer    *websocket.Dialer
+	readLimit int64
+
+	lk       sync.Mutex
+	conn     *wsConn
+	nextDial time.Time
+	// closed once the dial in progress completes, nil if there is none
+	dialing chan struct{}
+	closed  bool
+}
+
+// newWSTransport creates the wsTransport reading from the Core endpoint at the given address, as
+// configured by the given params. The base transport is set once the HTTP client is built.
+func newWSTransport(ip, port string, params *ClientParameters) (*wsTransport, error) {
+	url, dialer, err := newWSDialer(ip, port, params)
+	if err != nil {
+		return nil, err
+	}
+	return &wsTransport{
+		url:       url,
+		header:    http.Header{"User-Agent": []string{params.UserAgent}},
+		dialer:    dialer,
+		readLimit: params.WSReadLimit,
+	}, nil
+}
+
+// newWSDialer builds the dialer of the websocket connections to the Core endpoint at the given
+// address, as configured by the given params, returning it along with the URL to dial.
+func newWSDialer(ip, port string, params *ClientParameters) (string, *websocket.Dialer, error) {
+	dial := dialFunc(params)
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	scheme := "ws"
+	dialer := &websocket.Dialer{
+		Proxy:          http.ProxyFromEnvironment,
+		NetDialContext: dialWithTCPOptions(params, dial),
+	}
+	if params.TLS != nil {
+		tlsConfig, err := newTLSConfig(params)
+		if err != nil {
+			return "", nil, err
+		}
+		scheme = "wss"
+		dialer.TLSClientConfig = tlsConfig
+	}
+	return fmt.Sprintf("%s://%s:%s/websocket", scheme, ip, port), dialer, nil
+}
+
+func (t *wsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rpcReq, ok := readRPCRequest(req)
+	if !ok || wsWriteMethods[rpcReq.Method] {
+		return t.base.RoundTrip(req)
+	}
+
+	conn, err := t.connect(req.Context())
+	if err != nil {
+		return t.base.RoundTrip(req)
+	}
+	result, err := conn.call(req.Context(), rpcReq)
+	switch {
+	case errors.Is(err, errWSDown):
+		t.drop(conn)
+		return t.base.RoundTrip(req)
+	case err != nil:
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(result)),
+		ContentLength: int64(len(result)),
+		Request:       req,
+	}, nil
+}
+
+// connect returns the live websocket connection, dialing a new one if the previous broke and the
+// redial interval has passed. The connection is dialed once for all the concurrent reads, without
+// holding the lock, and the reads wait for it as long as their context allows.
+func (t *wsTransport) connect(ctx context.Context) (*wsConn, error) {
+	for {
+		t.lk.Lock()
+		if t.closed {
+			t.lk.Unlock()
+			return nil, errWSDown
+		}
+		if t.conn != nil {
+			conn := t.conn
+			t.lk.Unlock()
+			return conn, nil
+		}
+		if time.Now().Before(t.nextDial) {
+			t.lk.Unlock()
+			return nil, errWSDown
+		}
+		if dialing := t.dialing; dialing != nil {
+			t.lk.Unlock()
+			select {
+			case <-dialing:
+				continue
+			case <-ctx.Done():
+				return nil, errWSDown
+			}
+		}
+		dialing := make(chan struct{})
+		t.dialing = dialing
+		t.lk.Unlock()
+
+		// dialed independently of the read, so that the other reads waiting for the connection don't
+		// fail with it
+		go t.dial(dialing)
+		select {
+		case <-dialing:
+		case <-ctx.Done():
+			return nil, errWSDown
+		}
+	}
+}
+
+// dial dials the websocket connection, closing the given channel once done.
+func (t *wsTransport) dial(dialing chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), wsDialTimeout)
+	defer cancel()
+	conn, _, err := t.dialer.DialContext(ctx, t.url, t.header) //nolint:bodyclose
+
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	defer close(dialing)
+	t.dialing = nil
+	switch {
+	case err != nil:
+		t.nextDial = time.Now().Add(wsRedialInterval)
+		log.Debugw("dialing websocket, falling back to HTTP", "err", err)
+	case t.closed:
+		_ = conn.Close()
+	default:
+		t.conn = newWSConn(conn, t.readLimit, nil)
+	}
+}
+
+// drop forgets the given broken connection, so that the next read dials a new one.
+func (t *wsTransport) drop(conn *wsConn) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	if t.conn == conn {
+		t.conn = nil
+	}
+	conn.close()
+}
+
+// close closes the websocket connection, routing all further reads over HTTP.
+func (t *wsTransport) close() {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	t.closed = true
+	if t.conn != nil {
+		t.conn.close()
+		t.conn = nil
+	}
+}
+
+// wsConn multiplexes JSON-RPC calls over a single websocket connection, matching the responses to
+// the calls by ID. The messages matching no call, like the events of the subscriptions, are passed
+// to the onEvent handler, if any. A message over the read limit breaks the connection with
+// ErrWSReadLimit.
+type wsConn struct {
+	conn      *websocket.Conn
+	readLimit int64
+	onEvent   func([]byte)
+
+	writeLk sync.Mutex
+	nextID  uint64
+
+	pendingLk sync.Mutex
+	pending   map[string]chan []byte
+
+	done      chan struct{}
+	closeOnce sync.Once
+	// the error the connection broke with, set before done is closed
+	err error
+}
+
+func newWSConn(conn *websocket.Conn, readLimit int64, onEvent func([]byte)) *wsConn {
+	c := &wsConn{
+		conn:      conn,
+		readLimit: readLimit,
+		onEvent:   onEvent,
+		pending:   make(map[string]chan []byte),
+		done:      make(chan struct{}),
+	}
+	conn.SetReadLimit(readLimit)
+	go c.readLoop()
+	return c
+}
+
+// call sends the request over the websocket and waits for its response, returning it with the ID
+// of the original request. It returns errWSDown if the connection breaks before the response.
+func (c *wsConn) call(ctx context.Context, rpcReq rpcRequest) ([]byte, error) {
+	id := strconv.FormatUint(atomic.AddUint64(&c.nextID, 1), 10)
+	respCh := make(chan []byte, 1)
+	c.pendingLk.Lock()
+	c.pending[id] = respCh
+	c.pendingLk.Unlock()
+	defer func() {
+		c.pendingLk.Lock()
+		delete(c.pending, id)
+		c.pendingLk.Unlock()
+	}()
+
+	params := rpcReq.Params
+	if len(params) == 0 {
+		params = json.RawMessage("{}")
+	}
+	msg, err := json.Marshal(struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Method  string          `json:"method"`
+		Params  json.RawMessage `json:"params"`
+	}{"2.0", json.RawMessage(id), rpcReq.Method, params})
+	if err != nil {
+		return nil, err
+	}
+
+	c.writeLk.Lock()
+	deadline, _ := ctx.Deadline()
+	_ = c.conn.SetWriteDeadline(deadline)
+	err = c.conn.WriteMessage(websocket.TextMessage, msg)
+	c.writeLk.Unlock()
+	if err != nil {
+		c.close()
+		return nil, errWSDown
+	}
+
+	select {
+	case resp := <-respCh:
+		return replaceRPCID(resp, rpcReq.ID)
+	case <-c.done:
+		return nil, errWSDown
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// readLoop delivers the responses to the pending calls until the connection breaks.
+func (c *wsConn) readLoop() {
+	for {
+		_, msg, err := c.conn.ReadMessage()
+		if errors.Is(err, websocket.ErrReadLimit) {
+			err = &ErrWSReadLimit{Limit: c.readLimit}
+		}
+		if err != nil {
+			c.closeWithErr(err)
+			return
+		}
+
+		var resp struct {
+			ID json.RawMessage `json:"id"`
+		}
+		if err := json.Unmarshal(msg, &resp); err != nil {
+			continue
+		}
+		c.pendingLk.Lock()
+		respCh, ok := c.pending[string(resp.ID)]
+		delete(c.pending, string(resp.ID))
+		c.pendingLk.Unlock()
+		switch {
+		case ok:
+			respCh <- msg
+		case c.onEvent != nil:
+			c.onEvent(msg)
+		}
+	}
+}
+
+func (c *wsConn) close() {
+	c.closeWithErr(errWSDown)
+}
+
+// closeWithErr closes the connection, recording the given error as the reason it broke, unless
+// already closed.
+func (c *wsConn) closeWithErr(err error) {
+	c.closeOnce.Do(func() {
+		c.err = err
+		close(c.done)
+		_ = c.conn.Close()
+	})
+}
+
+// replaceRPCID sets the ID of the given JSON-RPC response to the given one.
+func replaceRPCID(resp []byte, id json.RawMessage) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(resp, &fields); err != nil {
+		return nil, err
+	}
+	fields["id"] = id
+	return json.Marshal(fields)
+}
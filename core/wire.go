@@ -0,0 +1,85 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// redactedHeaders are the headers of the requests to Core carrying credentials, which are never
+// reported to the WireObserver.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// WireEvent is a raw JSON-RPC call to Core as sent and received over HTTP, reported to the
+// WireObserver for debugging a misbehaving endpoint.
+type WireEvent struct {
+	// Method is the JSON-RPC method of the call. It is empty for batched calls.
+	Method string
+	// Header is the header of the HTTP request, with the credentials redacted.
+	Header http.Header
+	// Request is the raw body of the request.
+	Request []byte
+	// StatusCode is the HTTP status of the response, or zero on failure.
+	StatusCode int
+	// Response is the raw body of the response, or nil on failure.
+	Response []byte
+	// Err is the error the call failed with, if any.
+	Err error
+}
+
+// wireTransport reports every call to Core with its raw request and response to the observer.
+type wireTransport struct {
+	base     http.RoundTripper
+	observer func(WireEvent)
+}
+
+// newWireTransport wraps the given transport with the wireTransport reporting to the WireObserver
+// of the given params, unless it is unset.
+func newWireTransport(params *ClientParameters, base http.RoundTripper) http.RoundTripper {
+	if params.WireObserver == nil {
+		return base
+	}
+	return &wireTransport{
+		base:     base,
+		observer: params.WireObserver,
+	}
+}
+
+func (t *wireTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	event := WireEvent{Header: req.Header.Clone()}
+	for _, key := range redactedHeaders {
+		if event.Header.Get(key) != "" {
+			event.Header.Set(key, "REDACTED")
+		}
+	}
+	if rpcReq, ok := readRPCRequest(req); ok {
+		event.Method = rpcReq.Method
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		// readRPCRequest leaves the body buffered, so it is read without consuming the stream
+		body, err := io.ReadAll(req.Body)
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		event.Request = body
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		event.Err = err
+		t.observer(event)
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		event.Err = err
+		t.observer(event)
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	event.StatusCode, event.Response = resp.StatusCode, body
+	t.observer(event)
+	return resp, nil
+}
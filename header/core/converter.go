@@ -0,0 +1,50 @@
+package core
+
+import (
+	"context"
+
+	"github.com/ipfs/go-blockservice"
+
+	"github.com/celestiaorg/celestia-node/core"
+	"github.com/celestiaorg/celestia-node/header"
+)
+
+// converter is the built-in core.Converter generating ExtendedHeaders.
+type converter struct {
+	construct header.ConstructFn
+	bServ     blockservice.BlockService
+}
+
+// NewConverter creates a new core.Converter generating ExtendedHeaders with the given
+// ConstructFn, which stores the extended block data in the given BlockService.
+func NewConverter(
+	construct header.ConstructFn,
+	bServ blockservice.BlockService,
+) core.Converter[*header.ExtendedHeader] {
+	return &converter{
+		construct: construct,
+		bServ:     bServ,
+	}
+}
+
+func (c *converter) Convert(ctx context.Context, sb *core.SignedBlock) (*header.ExtendedHeader, error) {
+	return c.construct(ctx, sb.Block, sb.Commit, sb.ValidatorSet, c.bServ)
+}
+
+// GetExtendedHeader queries Core for the verified block at the given height and builds the
+// ExtendedHeader of it in a single call, storing the extended block data in the given
+// BlockService. The header is validated against its DataAvailabilityHeader, so its DataHash is
+// guaranteed to commit to the stored data. A nil height requests the latest header.
+func GetExtendedHeader(
+	ctx context.Context,
+	fetcher *core.BlockFetcher,
+	bServ blockservice.BlockService,
+	height *int64,
+) (*header.ExtendedHeader, error) {
+	return core.GetConverted[*header.ExtendedHeader](
+		ctx,
+		fetcher,
+		NewConverter(header.MakeExtendedHeader, bServ),
+		height,
+	)
+}
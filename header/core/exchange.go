@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"runtime"
 
 	"github.com/ipfs/go-blockservice"
 	logging "github.com/ipfs/go-log/v2"
@@ -20,14 +21,40 @@ type Exchange struct {
 	fetcher    *core.BlockFetcher
 	shareStore blockservice.BlockService
 	construct  header.ConstructFn
+
+	parallelism int
 }
 
-func NewExchange(fetcher *core.BlockFetcher, bServ blockservice.BlockService, construct header.ConstructFn) *Exchange {
-	return &Exchange{
-		fetcher:    fetcher,
-		shareStore: bServ,
-		construct:  construct,
+// ExchangeOption configures the Exchange.
+type ExchangeOption func(*Exchange)
+
+// WithParallelism sets the number of headers of a range the Exchange builds in parallel,
+// verifying their commits across as many goroutines. Defaults to GOMAXPROCS.
+func WithParallelism(parallelism int) ExchangeOption {
+	return func(ce *Exchange) {
+		ce.parallelism = parallelism
+	}
+}
+
+func NewExchange(
+	fetcher *core.BlockFetcher,
+	bServ blockservice.BlockService,
+	construct header.ConstructFn,
+	opts ...ExchangeOption,
+) *Exchange {
+	ce := &Exchange{
+		fetcher:     fetcher,
+		shareStore:  bServ,
+		construct:   construct,
+		parallelism: runtime.GOMAXPROCS(0),
+	}
+	for _, opt := range opts {
+		opt(ce)
+	}
+	if ce.parallelism < 1 {
+		ce.parallelism = 1
 	}
+	return ce
 }
 
 func (ce *Exchange) GetByHeight(ctx context.Context, height uint64) (*header.ExtendedHeader, error) {
@@ -42,17 +69,15 @@ func (ce *Exchange) GetRangeByHeight(ctx context.Context, from, amount uint64) (
 	}
 
 	log.Debugw("requesting headers", "from", from, "to", from+amount)
-	headers := make([]*header.ExtendedHeader, amount)
-	for i := range headers {
-		extHeader, err := ce.GetByHeight(ctx, from+uint64(i))
-		if err != nil {
-			return nil, err
-		}
-
-		headers[i] = extHeader
-	}
-
-	return headers, nil
+	// the headers are built in parallel, but still returned in height order for adjacency checks
+	return core.GetConvertedRange[*header.ExtendedHeader](
+		ctx,
+		ce.fetcher,
+		NewConverter(ce.construct, ce.shareStore),
+		int64(from),
+		int64(from+amount-1),
+		ce.parallelism,
+	)
 }
 
 func (ce *Exchange) GetVerifiedRange(ctx context.Context, from *header.ExtendedHeader, amount uint64,
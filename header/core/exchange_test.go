@@ -27,6 +27,25 @@ func TestCoreExchange_RequestHeaders(t *testing.T) {
 	assert.Equal(t, 10, len(headers))
 }
 
+func TestGetExtendedHeader(t *testing.T) {
+	ctx := context.Background()
+	fetcher := createCoreFetcher(t)
+	generateBlocks(t, fetcher)
+
+	height := int64(5)
+	eh, err := GetExtendedHeader(ctx, fetcher, mdutils.Bserv(), &height)
+	require.NoError(t, err)
+	assert.Equal(t, height, eh.Height)
+	require.True(t, eh.HasDAH())
+	require.NoError(t, eh.ValidateBasic())
+	assert.Equal(t, eh.DAH.Hash(), []byte(eh.DataHash))
+
+	prevHeight := height - 1
+	prev, err := GetExtendedHeader(ctx, fetcher, mdutils.Bserv(), &prevHeight)
+	require.NoError(t, err)
+	require.NoError(t, prev.VerifyAdjacent(eh))
+}
+
 func Test_hashMatch(t *testing.T) {
 	expected := []byte("AE0F153556A4FA5C0B7C3BFE0BAF0EC780C031933B281A8D759BB34C1DA31C56")
 	mismatch := []byte("57A0D7FE69FE88B3D277C824B3ACB9B60E5E65837A802485DE5CBB278C43576A")
@@ -36,7 +55,9 @@ func Test_hashMatch(t *testing.T) {
 
 func createCoreFetcher(t *testing.T) *core.BlockFetcher {
 	_, client := core.StartTestCoreWithApp(t)
-	return core.NewBlockFetcher(client)
+	fetcher, err := core.NewBlockFetcher(client)
+	require.NoError(t, err)
+	return fetcher
 }
 
 func generateBlocks(t *testing.T, fetcher *core.BlockFetcher) {
@@ -2,7 +2,9 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/ipfs/go-blockservice"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
@@ -22,9 +24,62 @@ import (
 type Listener struct {
 	bcast     header.Broadcaster
 	fetcher   *core.BlockFetcher
-	bServ     blockservice.BlockService
-	construct header.ConstructFn
+	converter core.Converter[*header.ExtendedHeader]
+	startMode StartMode
+	clock     core.Clock
 	cancel    context.CancelFunc
+
+	gapThreshold int
+	gapWindow    time.Duration
+	// the times of the recent gaps, within the gapWindow
+	gaps []time.Time
+
+	done chan struct{}
+	err  error
+}
+
+// ErrTooManyGaps is returned by Listener.Err when the Listener stopped after detecting too many gaps
+// in the blocks received from Core, as configured by WithGapLimit.
+var ErrTooManyGaps = errors.New("listener: too many gaps in the new blocks")
+
+// StartMode defines the height the Listener starts from.
+type StartMode int
+
+const (
+	// StartFromTip starts listening from the new blocks of Core.
+	StartFromTip StartMode = iota
+	// StartFromEarliest backfills the blocks from the earliest height retained by Core up to
+	// its tip, e.g. for a fresh bridge node, before going on with the new blocks.
+	StartFromEarliest
+)
+
+// ListenerOption configures the Listener.
+type ListenerOption func(*Listener)
+
+// WithStartMode sets the StartMode of the Listener. StartFromTip is the default.
+func WithStartMode(mode StartMode) ListenerOption {
+	return func(cl *Listener) {
+		cl.startMode = mode
+	}
+}
+
+// WithClock sets the Clock the Listener times the gaps in the new blocks with, e.g. so that tests
+// can control it. The system time is the default.
+func WithClock(clock core.Clock) ListenerOption {
+	return func(cl *Listener) {
+		cl.clock = clock
+	}
+}
+
+// WithGapLimit makes the Listener fail once it detects the given number of gaps in the new blocks
+// received from Core within the given window, as frequent gaps indicate an unhealthy Core
+// endpoint. The failure is signaled by Listener.Done and Listener.Err, so that a supervisor can
+// switch endpoints. A zero threshold, the default, tolerates any number of gaps.
+func WithGapLimit(threshold int, window time.Duration) ListenerOption {
+	return func(cl *Listener) {
+		cl.gapThreshold = threshold
+		cl.gapWindow = window
+	}
 }
 
 func NewListener(
@@ -32,13 +87,29 @@ func NewListener(
 	fetcher *core.BlockFetcher,
 	bServ blockservice.BlockService,
 	construct header.ConstructFn,
+	opts ...ListenerOption,
+) *Listener {
+	return NewListenerWithConverter(bcast, fetcher, NewConverter(construct, bServ), opts...)
+}
+
+// NewListenerWithConverter creates a new Listener generating ExtendedHeaders
+// with the given Converter.
+func NewListenerWithConverter(
+	bcast header.Broadcaster,
+	fetcher *core.BlockFetcher,
+	converter core.Converter[*header.ExtendedHeader],
+	opts ...ListenerOption,
 ) *Listener {
-	return &Listener{
+	cl := &Listener{
 		bcast:     bcast,
 		fetcher:   fetcher,
-		bServ:     bServ,
-		construct: construct,
+		converter: converter,
+		clock:     core.RealClock(),
+	}
+	for _, opt := range opts {
+		opt(cl)
 	}
+	return cl
 }
 
 // Start kicks off the Listener listener loop.
@@ -47,13 +118,25 @@ func (cl *Listener) Start(ctx context.Context) error {
 		return fmt.Errorf("listener: already started")
 	}
 
+	// the earliest height is looked up before subscribing, so that the blocks in between
+	// are not missed
+	var from int64
+	if cl.startMode == StartFromEarliest {
+		earliest, err := cl.fetcher.EarliestHeight(ctx)
+		if err != nil {
+			return fmt.Errorf("listener: getting earliest height: %w", err)
+		}
+		from = earliest
+	}
+
 	sub, err := cl.fetcher.SubscribeNewBlockEvent(ctx)
 	if err != nil {
 		return err
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
-	go cl.listen(ctx, sub)
+	cl.done, cl.err, cl.gaps = make(chan struct{}), nil, nil
+	go cl.run(ctx, sub, from)
 	cl.cancel = cancel
 	return nil
 }
@@ -65,44 +148,163 @@ func (cl *Listener) Stop(ctx context.Context) error {
 	return cl.fetcher.UnsubscribeNewBlockEvent(ctx)
 }
 
+// Done returns a channel closed once the Listener stops listening, either when stopped or on
+// failure. It is nil until the Listener is started.
+func (cl *Listener) Done() <-chan struct{} {
+	return cl.done
+}
+
+// Err returns the error the Listener stopped listening with, e.g. ErrTooManyGaps, or nil if it is
+// still listening or was stopped.
+func (cl *Listener) Err() error {
+	select {
+	case <-cl.done:
+		return cl.err
+	default:
+		return nil
+	}
+}
+
+// run listens until the context is done or listening fails, recording the error and closing the
+// done channel.
+func (cl *Listener) run(ctx context.Context, sub <-chan *types.Block, from int64) {
+	defer close(cl.done)
+	cl.err = cl.listen(ctx, sub, from)
+	if cl.err != nil {
+		log.Errorw("listener: listening failed", "err", cl.err)
+	}
+}
+
 // listen kicks off a loop, listening for new block events from Core,
 // generating ExtendedHeaders and broadcasting them to the header-sub
-// gossipsub network.
-func (cl *Listener) listen(ctx context.Context, sub <-chan *types.Block) {
+// gossipsub network. Unless `from` is zero, the blocks from that height on are backfilled first,
+// as well as any blocks missed by the subscription afterwards.
+func (cl *Listener) listen(ctx context.Context, sub <-chan *types.Block, from int64) error {
 	defer log.Info("listener: listening stopped")
+
+	// the height of the last processed block
+	last := from - 1
+	// whether a new block was processed, after which the heights skipped by the subscription are gaps
+	live := false
 	for {
 		select {
 		case b, ok := <-sub:
 			if !ok {
-				return
+				return nil
 			}
 
-			syncing, err := cl.fetcher.IsSyncing(ctx)
-			if err != nil {
-				log.Errorw("listener: getting sync state", "err", err)
-				return
+			if live && b.Height > last+1 {
+				if err := cl.detectGap(last+1, b.Height-1); err != nil {
+					return err
+				}
 			}
 
-			comm, vals, err := cl.fetcher.GetBlockInfo(ctx, &b.Height)
+			if from > 0 {
+				if b.Height <= last {
+					// already backfilled
+					continue
+				}
+				var err error
+				if last, err = cl.backfill(ctx, last+1, b.Height-1); err != nil {
+					return err
+				}
+			}
+
+			syncing, err := cl.fetcher.IsSyncing(ctx)
 			if err != nil {
-				log.Errorw("listener: getting block info", "err", err)
-				return
+				return fmt.Errorf("listener: getting sync state: %w", err)
 			}
 
-			eh, err := cl.construct(ctx, b, comm, vals, cl.bServ)
+			comm, vals, err := cl.fetcher.GetBlockInfo(ctx, &b.Height)
 			if err != nil {
-				log.Errorw("listener: making extended header", "err", err)
-				return
+				// the new blocks piled up in the subscription during a long backfill may have been
+				// pruned meanwhile, so go on with the ones left
+				var errPruned *core.ErrHeightPruned
+				if errors.As(err, &errPruned) {
+					log.Warnw("listener: new block pruned by Core", "height", b.Height, "lowest", errPruned.Lowest)
+					last = b.Height
+					continue
+				}
+				return fmt.Errorf("listener: getting block info: %w", err)
 			}
 
 			// broadcast new ExtendedHeader, but if core is still syncing, notify only local subscribers
-			err = cl.bcast.Broadcast(ctx, eh, pubsub.WithLocalPublication(syncing))
+			err = cl.process(ctx, &core.SignedBlock{Block: b, Commit: comm, ValidatorSet: vals}, syncing)
 			if err != nil {
-				log.Errorw("listener: broadcasting next header", "height", eh.Height,
-					"err", err)
+				return fmt.Errorf("listener: making extended header: %w", err)
 			}
+			last, live = b.Height, true
 		case <-ctx.Done():
-			return
+			return nil
 		}
 	}
 }
+
+// detectGap records the gap of the given heights missing from the new blocks, returning
+// ErrTooManyGaps once the gaps within the window reach the threshold.
+func (cl *Listener) detectGap(from, to int64) error {
+	log.Warnw("listener: gap in the new blocks", "from", from, "to", to)
+	if cl.gapThreshold <= 0 {
+		return nil
+	}
+
+	now := cl.clock.Now()
+	recent := cl.gaps[:0]
+	for _, at := range cl.gaps {
+		if now.Sub(at) < cl.gapWindow {
+			recent = append(recent, at)
+		}
+	}
+	cl.gaps = append(recent, now)
+	if len(cl.gaps) >= cl.gapThreshold {
+		return fmt.Errorf("%w: %d gaps within %s", ErrTooManyGaps, len(cl.gaps), cl.gapWindow)
+	}
+	return nil
+}
+
+// backfill processes the blocks in the range [from:to], notifying only local subscribers about
+// them, as they are behind the network. Heights pruned by Core in the meantime are skipped.
+// It returns the height of the last processed block, along with the error the listener should
+// stop with, if any.
+func (cl *Listener) backfill(ctx context.Context, from, to int64) (int64, error) {
+	if from > to {
+		return from - 1, nil
+	}
+	log.Infow("listener: backfilling", "from", from, "to", to)
+
+	for height := from; height <= to; height++ {
+		h := height
+		sb, err := cl.fetcher.GetSignedBlock(ctx, &h)
+		if err != nil {
+			// the height may have been pruned while backfilling, so go on from what is left
+			earliest, earliestErr := cl.fetcher.EarliestHeight(ctx)
+			if earliestErr == nil && earliest > height {
+				log.Warnw("listener: backfilled heights pruned by Core", "from", height, "to", earliest-1)
+				height = earliest - 1
+				continue
+			}
+			return height - 1, fmt.Errorf("listener: getting block to backfill at height %d: %w", height, err)
+		}
+
+		if err := cl.process(ctx, sb, true); err != nil {
+			return height - 1, fmt.Errorf("listener: making extended header: %w", err)
+		}
+	}
+	return to, nil
+}
+
+// process generates the ExtendedHeader for the given block and broadcasts it,
+// or only notifies local subscribers about it if `local` is set.
+func (cl *Listener) process(ctx context.Context, sb *core.SignedBlock, local bool) error {
+	eh, err := cl.converter.Convert(ctx, sb)
+	if err != nil {
+		return err
+	}
+
+	err = cl.bcast.Broadcast(ctx, eh, pubsub.WithLocalPublication(local))
+	if err != nil {
+		log.Errorw("listener: broadcasting next header", "height", eh.Height,
+			"err", err)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"net"
 	"testing"
 	"time"
 
@@ -11,6 +12,10 @@ import (
 	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/abci/example/kvstore"
+	abci "github.com/tendermint/tendermint/abci/types"
+	rpctest "github.com/tendermint/tendermint/rpc/test"
+	"github.com/tendermint/tendermint/types"
 
 	"github.com/celestiaorg/celestia-node/core"
 	"github.com/celestiaorg/celestia-node/header"
@@ -53,6 +58,141 @@ func TestListener(t *testing.T) {
 	require.Nil(t, cl.cancel)
 }
 
+// TestListener_StartFromEarliest tests the listener backfills from the earliest height retained
+// by Core up to its tip before going live.
+func TestListener_StartFromEarliest(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	t.Cleanup(cancel)
+
+	// a node retaining only a few blocks, so that the earliest height moves on quickly
+	cfg := rpctest.GetConfig(true)
+	core.StartTestNode(ctx, t, &emptyDAKVStore{Application: core.CreateKVStore(20)}, cfg)
+	endpoint, err := core.GetEndpoint(cfg)
+	require.NoError(t, err)
+	ip, port, err := net.SplitHostPort(endpoint)
+	require.NoError(t, err)
+	client, err := core.NewRemote(ip, port)
+	require.NoError(t, err)
+	require.NoError(t, client.Start())
+	t.Cleanup(func() {
+		require.NoError(t, client.Stop())
+	})
+	fetcher, err := core.NewBlockFetcher(client)
+	require.NoError(t, err)
+
+	// wait for the blocks to be pruned
+	var earliest int64
+	for earliest <= 1 {
+		earliest, err = fetcher.EarliestHeight(ctx)
+		require.NoError(t, err)
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	bcast := &recordingBroadcaster{headers: make(chan *header.ExtendedHeader, 1000)}
+	cl := NewListener(bcast, fetcher, mdutils.Bserv(), header.MakeExtendedHeader, WithStartMode(StartFromEarliest))
+	require.NoError(t, cl.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, cl.Stop(ctx))
+	})
+	tip, err := fetcher.GetSignedHeader(ctx, nil)
+	require.NoError(t, err)
+
+	var last int64
+	for last < tip.Height {
+		select {
+		case eh := <-bcast.headers:
+			if last == 0 {
+				// the earliest height may have been pruned meanwhile, but not the ones before it
+				assert.GreaterOrEqual(t, eh.Height, earliest)
+				assert.Less(t, eh.Height, tip.Height)
+			} else {
+				assert.Greater(t, eh.Height, last)
+			}
+			last = eh.Height
+		case <-ctx.Done():
+			t.Fatal("listener did not reach the tip")
+		}
+	}
+}
+
+// TestListener_GapLimit tests the listener fails once it detects the configured number of gaps in
+// the new blocks.
+func TestListener_GapLimit(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	t.Cleanup(cancel)
+
+	fetcher := createCoreFetcher(t)
+	generateBlocks(t, fetcher)
+
+	bcast := &recordingBroadcaster{headers: make(chan *header.ExtendedHeader, 1000)}
+	cl := NewListenerWithConverter(bcast, fetcher, emptyDAHConverter(), WithGapLimit(3, time.Minute))
+
+	// a subscription skipping every other block
+	sub := make(chan *types.Block)
+	cl.done = make(chan struct{})
+	go cl.run(ctx, sub, 0)
+	for height := int64(1); height <= 9; height += 2 {
+		h := height
+		b, err := fetcher.GetBlock(ctx, &h)
+		require.NoError(t, err)
+		select {
+		case sub <- b:
+		case <-cl.Done():
+		}
+	}
+
+	select {
+	case <-cl.Done():
+	case <-ctx.Done():
+		t.Fatal("listener did not fail")
+	}
+	assert.ErrorIs(t, cl.Err(), ErrTooManyGaps)
+	// the blocks before the third gap are processed
+	close(bcast.headers)
+	var heights []int64
+	for eh := range bcast.headers {
+		heights = append(heights, eh.Height)
+	}
+	assert.Equal(t, []int64{1, 3, 5}, heights)
+}
+
+// emptyDAHConverter returns a Converter generating ExtendedHeaders with an empty DAH.
+func emptyDAHConverter() core.Converter[*header.ExtendedHeader] {
+	return core.ConverterFunc[*header.ExtendedHeader](
+		func(_ context.Context, sb *core.SignedBlock) (*header.ExtendedHeader, error) {
+			dah := header.EmptyDAH()
+			return &header.ExtendedHeader{
+				RawHeader:    sb.Header,
+				Commit:       sb.Commit,
+				ValidatorSet: sb.ValidatorSet,
+				DAH:          &dah,
+			}, nil
+		})
+}
+
+// emptyDAKVStore is a KV app committing its blocks to the empty data square, so that they pass as
+// Celestia blocks as long as they carry no transactions.
+type emptyDAKVStore struct {
+	*kvstore.Application
+}
+
+func (app *emptyDAKVStore) PrepareProposal(req abci.RequestPrepareProposal) abci.ResponsePrepareProposal {
+	resp := app.Application.PrepareProposal(req)
+	dah := header.EmptyDAH()
+	resp.BlockData.Hash = dah.Hash()
+	return resp
+}
+
+// recordingBroadcaster is a header.Broadcaster recording the broadcasted headers.
+type recordingBroadcaster struct {
+	headers chan *header.ExtendedHeader
+}
+
+func (b *recordingBroadcaster) Broadcast(_ context.Context, eh *header.ExtendedHeader, _ ...pubsub.PubOpt) error {
+	b.headers <- eh
+	return nil
+}
+
 func createMocknetWithTwoPubsubEndpoints(ctx context.Context, t *testing.T) (*pubsub.PubSub, *pubsub.PubSub) {
 	net, err := mocknet.FullMeshLinked(2)
 	require.NoError(t, err)
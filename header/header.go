@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/ipfs/go-blockservice"
@@ -42,6 +43,33 @@ type ExtendedHeader struct {
 	DAH          *DataAvailabilityHeader `json:"dah"`
 }
 
+// ExtendStep is a step of extending the block data into the data square.
+type ExtendStep string
+
+const (
+	// ExtendStepSplit is splitting the block data into shares.
+	ExtendStepSplit ExtendStep = "split"
+	// ExtendStepExtend is erasure coding the shares into the extended data square.
+	ExtendStepExtend ExtendStep = "extend"
+)
+
+// ErrExtendData is returned when the block data fails to extend into the data square, telling
+// the step it failed at, so that malformed blocks can be told from failures of extending itself.
+type ErrExtendData struct {
+	// SquareSize is the original square size declared by the block.
+	SquareSize uint64
+	Step       ExtendStep
+	Err        error
+}
+
+func (e *ErrExtendData) Error() string {
+	return fmt.Sprintf("header: extending block data of square size %d: %s step: %s", e.SquareSize, e.Step, e.Err)
+}
+
+func (e *ErrExtendData) Unwrap() error {
+	return e.Err
+}
+
 // MakeExtendedHeader assembles new ExtendedHeader.
 func MakeExtendedHeader(
 	ctx context.Context,
@@ -54,11 +82,11 @@ func MakeExtendedHeader(
 	if len(b.Txs) > 0 {
 		shares, err := appshares.Split(b.Data, true)
 		if err != nil {
-			return nil, err
+			return nil, &ErrExtendData{SquareSize: b.Data.OriginalSquareSize, Step: ExtendStepSplit, Err: err}
 		}
 		extended, err := share.AddShares(ctx, appshares.ToBytes(shares), bServ)
 		if err != nil {
-			return nil, err
+			return nil, &ErrExtendData{SquareSize: b.Data.OriginalSquareSize, Step: ExtendStepExtend, Err: err}
 		}
 		dah = da.NewDataAvailabilityHeader(extended)
 	} else {
@@ -76,6 +104,36 @@ func MakeExtendedHeader(
 	return eh, eh.ValidateBasic()
 }
 
+// ErrNoDAH is returned when validating a header made without the DataAvailabilityHeader as if it
+// had one.
+var ErrNoDAH = errors.New("header: no DataAvailabilityHeader")
+
+// MakeExtendedHeaderWithoutDAH assembles new ExtendedHeader without computing the
+// DataAvailabilityHeader, skipping the expensive extension of the block data. It is meant as the
+// ConstructFn of the consumers that do not need the availability data, like header-only syncers.
+// Such headers are non-DA, as reported by HasDAH: they can't be sampled, fully validated or
+// serialized. The BlockService is unused.
+func MakeExtendedHeaderWithoutDAH(
+	_ context.Context,
+	b *core.Block,
+	comm *core.Commit,
+	vals *core.ValidatorSet,
+	_ blockservice.BlockService,
+) (*ExtendedHeader, error) {
+	eh := &ExtendedHeader{
+		RawHeader:    b.Header,
+		Commit:       comm,
+		ValidatorSet: vals,
+	}
+	return eh, eh.validateWithoutDAH()
+}
+
+// HasDAH returns whether the header carries the DataAvailabilityHeader, i.e. whether it was not
+// made by MakeExtendedHeaderWithoutDAH.
+func (eh *ExtendedHeader) HasDAH() bool {
+	return eh.DAH != nil
+}
+
 // Hash returns Hash of the wrapped RawHeader.
 // NOTE: It purposely overrides Hash method of RawHeader to get it directly from Commit without
 // recomputing.
@@ -99,7 +157,26 @@ func (eh *ExtendedHeader) Equals(header *ExtendedHeader) bool {
 }
 
 // ValidateBasic performs *basic* validation to check for missed/incorrect fields.
+// ErrNoDAH is returned for the headers without the DataAvailabilityHeader.
 func (eh *ExtendedHeader) ValidateBasic() error {
+	if err := eh.validateWithoutDAH(); err != nil {
+		return err
+	}
+	if !eh.HasDAH() {
+		return ErrNoDAH
+	}
+
+	// ensure data root from raw header matches computed root
+	if !bytes.Equal(eh.DAH.Hash(), eh.DataHash) {
+		return fmt.Errorf("mismatch between data hash commitment from core header and computed data root: "+
+			"data hash: %X, computed root: %X", eh.DataHash, eh.DAH.Hash())
+	}
+
+	return eh.DAH.ValidateBasic()
+}
+
+// validateWithoutDAH performs the part of ValidateBasic not involving the DataAvailabilityHeader.
+func (eh *ExtendedHeader) validateWithoutDAH() error {
 	err := eh.RawHeader.ValidateBasic()
 	if err != nil {
 		return err
@@ -122,17 +199,7 @@ func (eh *ExtendedHeader) ValidateBasic() error {
 		)
 	}
 
-	if err := eh.ValidatorSet.VerifyCommitLight(eh.ChainID, eh.Commit.BlockID, eh.Height, eh.Commit); err != nil {
-		return err
-	}
-
-	// ensure data root from raw header matches computed root
-	if !bytes.Equal(eh.DAH.Hash(), eh.DataHash) {
-		return fmt.Errorf("mismatch between data hash commitment from core header and computed data root: "+
-			"data hash: %X, computed root: %X", eh.DataHash, eh.DAH.Hash())
-	}
-
-	return eh.DAH.ValidateBasic()
+	return eh.ValidatorSet.VerifyCommitLight(eh.ChainID, eh.Commit.BlockID, eh.Height, eh.Commit)
 }
 
 // MarshalBinary marshals ExtendedHeader to binary.
@@ -3,13 +3,22 @@ package header
 import (
 	"context"
 	"testing"
+	"time"
 
 	mdutils "github.com/ipfs/go-merkledag/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tendermint/tendermint/libs/rand"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	"github.com/tendermint/tendermint/proto/tendermint/version"
+	"github.com/tendermint/tendermint/types"
+	tmversion "github.com/tendermint/tendermint/version"
+
+	"github.com/celestiaorg/celestia-app/pkg/da"
+	appshares "github.com/celestiaorg/celestia-app/pkg/shares"
 
 	"github.com/celestiaorg/celestia-node/core"
+	"github.com/celestiaorg/celestia-node/share"
 )
 
 func TestMakeExtendedHeaderForEmptyBlock(t *testing.T) {
@@ -17,7 +26,8 @@ func TestMakeExtendedHeaderForEmptyBlock(t *testing.T) {
 	t.Cleanup(cancel)
 
 	_, client := core.StartTestCoreWithApp(t)
-	fetcher := core.NewBlockFetcher(client)
+	fetcher, err := core.NewBlockFetcher(client)
+	require.NoError(t, err)
 
 	store := mdutils.Bserv()
 
@@ -46,3 +56,96 @@ func TestMismatchedDataHash_ComputedRoot(t *testing.T) {
 	err := header.ValidateBasic()
 	assert.ErrorContains(t, err, "mismatch between data hash")
 }
+
+func TestMakeExtendedHeader_ErrExtendData(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	b := &types.Block{Data: types.Data{
+		Txs:                types.Txs{rand.Bytes(32)},
+		OriginalSquareSize: 3,
+	}}
+	_, err := MakeExtendedHeader(ctx, b, &types.Commit{}, nil, mdutils.Bserv())
+
+	var errExtend *ErrExtendData
+	require.ErrorAs(t, err, &errExtend)
+	assert.Equal(t, uint64(3), errExtend.SquareSize)
+	assert.Equal(t, ExtendStepSplit, errExtend.Step)
+	assert.ErrorContains(t, errExtend.Err, "not a power of two")
+}
+
+// newBlockWithTxs makes a block of `count` random transactions of the given size committed to by
+// all the validators, along with its Commit and ValidatorSet.
+func newBlockWithTxs(tb testing.TB, count, size int) (*types.Block, *types.Commit, *types.ValidatorSet) {
+	ctx := context.Background()
+	valSet, vals := core.RandValidatorSet(3, 1)
+
+	txs := make(types.Txs, count)
+	for i := range txs {
+		txs[i] = rand.Bytes(size)
+	}
+	data := types.Data{Txs: txs, OriginalSquareSize: 64}
+	shares, err := appshares.Split(data, true)
+	require.NoError(tb, err)
+	extended, err := share.AddShares(ctx, appshares.ToBytes(shares), mdutils.Bserv())
+	require.NoError(tb, err)
+	dah := da.NewDataAvailabilityHeader(extended)
+
+	const chainID = "private"
+	block := types.MakeBlock(1, data, &types.Commit{})
+	block.Header.Populate(
+		version.Consensus{Block: tmversion.BlockProtocol},
+		chainID,
+		time.Now(),
+		types.BlockID{},
+		valSet.Hash(),
+		valSet.Hash(),
+		nil,
+		nil,
+		nil,
+		valSet.GetProposer().Address,
+	)
+	block.DataHash = dah.Hash()
+
+	voteSet := types.NewVoteSet(chainID, block.Height, 0, tmproto.PrecommitType, valSet)
+	sb, err := core.MakeSignedBlock(block, voteSet, valSet, vals, time.Now())
+	require.NoError(tb, err)
+	return sb.Block, sb.Commit, sb.ValidatorSet
+}
+
+func TestMakeExtendedHeaderWithoutDAH(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	b, comm, vals := newBlockWithTxs(t, 16, 1024)
+	eh, err := MakeExtendedHeaderWithoutDAH(ctx, b, comm, vals, nil)
+	require.NoError(t, err)
+	assert.False(t, eh.HasDAH())
+	assert.Equal(t, b.Hash(), eh.Hash())
+	assert.ErrorIs(t, eh.ValidateBasic(), ErrNoDAH)
+	_, err = eh.MarshalBinary()
+	assert.Error(t, err)
+
+	full, err := MakeExtendedHeader(ctx, b, comm, vals, mdutils.Bserv())
+	require.NoError(t, err)
+	assert.True(t, full.HasDAH())
+	assert.Equal(t, full.RawHeader, eh.RawHeader)
+}
+
+func BenchmarkMakeExtendedHeader(b *testing.B) {
+	ctx := context.Background()
+	block, comm, vals := newBlockWithTxs(b, 512, 1024)
+
+	b.Run("with DAH", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := MakeExtendedHeader(ctx, block, comm, vals, mdutils.Bserv())
+			require.NoError(b, err)
+		}
+	})
+	b.Run("without DAH", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := MakeExtendedHeaderWithoutDAH(ctx, block, comm, vals, nil)
+			require.NoError(b, err)
+		}
+	})
+}
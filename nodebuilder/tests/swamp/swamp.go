@@ -19,6 +19,7 @@ import (
 
 	"github.com/celestiaorg/celestia-app/testutil/testnode"
 
+	"github.com/celestiaorg/celestia-node/core"
 	"github.com/celestiaorg/celestia-node/libs/keystore"
 	"github.com/celestiaorg/celestia-node/logs"
 	"github.com/celestiaorg/celestia-node/nodebuilder"
@@ -236,7 +237,7 @@ func (s *Swamp) NewNodeWithStore(
 	switch t {
 	case node.Bridge:
 		options = append(options,
-			coremodule.WithClient(s.ClientContext.Client),
+			coremodule.WithClient(core.NewClientFromTendermint(s.ClientContext.Client)),
 		)
 		n = s.newNode(node.Bridge, store, options...)
 		s.BridgeNodes = append(s.BridgeNodes, n)